@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+)
+
+// AccruedInterest holds the interest accrued on a loan as of a valuation date
+type AccruedInterest struct {
+	LoanID              string `json:"loan_id"`               // LoanID is the ID of the loan
+	ValuationDate       Date   `json:"valuation_date"`        // ValuationDate is the last accrual date counted as accrued
+	PeriodStart         Date   `json:"period_start"`          // PeriodStart is the first accrual date of the period ending on the valuation date
+	MaturityDate        Date   `json:"maturity_date"`         // MaturityDate is the end date of the loan adjusted for its business day convention
+	AccruedToDate       Money  `json:"accrued_to_date"`       // AccruedToDate is the interest accrued from the start of the loan up to and including the valuation date
+	AccruedInPeriod     Money  `json:"accrued_in_period"`     // AccruedInPeriod is the interest accrued from the period start up to and including the valuation date
+	RemainingToMaturity Money  `json:"remaining_to_maturity"` // RemainingToMaturity is the interest still to accrue after the valuation date until maturity
+	TotalToMaturity     Money  `json:"total_to_maturity"`     // TotalToMaturity is the interest accrued over the whole loan
+}
+
+// Accrued returns the interest accrued on the loan as of a valuation date, in the period starting on from and until maturity.
+// A zero from starts the period on the first day of the valuation date's month, for month-end accruals.
+func (l Loan) Accrued(valuationDate, from Date) (AccruedInterest, error) {
+	if from.IsZero() {
+		from = NewDate(valuationDate.Year(), valuationDate.Month(), 1)
+	}
+	if from.After(valuationDate) {
+		return AccruedInterest{}, errors.Wrap(ErrInvalidInput, "period start needs to be on or before the valuation date")
+	}
+
+	toDate := l.totalInterestOn(valuationDate)
+	total := l.totalInterestOn(l.LoanDetails.MaturityDate())
+
+	return AccruedInterest{
+		LoanID:              l.LoanDetails.ID,
+		ValuationDate:       valuationDate,
+		PeriodStart:         from,
+		MaturityDate:        l.LoanDetails.MaturityDate(),
+		AccruedToDate:       toDate,
+		AccruedInPeriod:     toDate.Sub(l.totalInterestOn(from.AddDate(0, 0, -1))),
+		RemainingToMaturity: total.Sub(toDate),
+		TotalToMaturity:     total,
+	}, nil
+}
+
+// totalInterestOn returns the running total of interest accrued up to and including a date, which is zero before the loan starts
+func (l Loan) totalInterestOn(date Date) Money {
+	currency := l.LoanDetails.Currency()
+	total := NewMoney(NewDecimal(0, currency.MinorUnits()), currency)
+
+	for _, interest := range l.DailyInterest {
+		if interest.AccrualDate.After(date) {
+			break
+		}
+		total = interest.TotalInterest
+	}
+
+	return total
+}
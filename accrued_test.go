@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLoanAccrued(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-15")
+	endDate, _ := ParseDate("2024-03-15")
+
+	// 36500 * 6% / 365 = 6 per day over 60 days
+	loan, err := NewLoan(LoanDetails{
+		ID:               "loan1",
+		StartDate:        startDate,
+		EndDate:          endDate,
+		PrincipalAmount:  NewMoney(NewDecimal(36500, 0), CurrencyEUR),
+		BaseInterestRate: NewDecimal(5, 0),
+		Margin:           NewDecimal(1, 0),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		date      string
+		from      string
+		toDate    string
+		inPeriod  string
+		remaining string
+	}{
+		{name: "month end", date: "2024-02-29", toDate: "276.00", inPeriod: "174.00", remaining: "84.00"},
+		{name: "period", date: "2024-01-20", from: "2024-01-18", toDate: "36.00", inPeriod: "18.00", remaining: "324.00"},
+		{name: "before start", date: "2024-01-01", toDate: "0.00", inPeriod: "0.00", remaining: "360.00"},
+		{name: "after maturity", date: "2024-06-30", toDate: "360.00", inPeriod: "0.00", remaining: "0.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, _ := parseDateInput(tt.date)
+			from, _ := parseOptionalDateInput(tt.from)
+
+			accrued, err := loan.Accrued(date, from)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if accrued.AccruedToDate.Amount.String() != tt.toDate || accrued.AccruedInPeriod.Amount.String() != tt.inPeriod || accrued.RemainingToMaturity.Amount.String() != tt.remaining {
+				t.Errorf("Unexpected accrued interest. got to date %s, in period %s, remaining %s", accrued.AccruedToDate.Amount, accrued.AccruedInPeriod.Amount, accrued.RemainingToMaturity.Amount)
+			}
+			if accrued.TotalToMaturity.Amount.String() != "360.00" {
+				t.Errorf("Unexpected total to maturity. got %s", accrued.TotalToMaturity.Amount)
+			}
+		})
+	}
+
+	if _, err := loan.Accrued(startDate, endDate); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected a period starting after the valuation date to be invalid. got %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"slices"
+)
+
+const (
+	CalculationDailySimple         = "daily-simple"
+	CalculationDailyCompound       = "daily-compound"
+	CalculationMonthlyCompound     = "monthly-compound"
+	CalculationCompoundedInArrears = "compounded-in-arrears"
+)
+
+// compoundingPrecision is the number of decimal places compounded rate indices are held to
+const compoundingPrecision = 18
+
+var (
+	AllowedCalculationMethods = []CalculationMethod{
+		CalculationDailySimple,
+		CalculationDailyCompound,
+		CalculationMonthlyCompound,
+		CalculationCompoundedInArrears,
+	}
+
+	interestCalculators = map[CalculationMethod]InterestCalculator{
+		CalculationDailySimple:         dailySimpleCalculator{},
+		CalculationDailyCompound:       dailyCompoundCalculator{},
+		CalculationMonthlyCompound:     monthlyCompoundCalculator{},
+		CalculationCompoundedInArrears: compoundedInArrearsCalculator{},
+	}
+)
+
+// InterestCalculator is a strategy for calculating the daily accrued interest of a loan
+type InterestCalculator interface {
+	// Calculate calculates the daily accrued interest over the loan period
+	Calculate(loan LoanDetails, transactions []Transaction) []Interest
+}
+
+// CalculationMethod holds the name of the method used to calculate interest
+type CalculationMethod string
+
+// String stringifies the calculation method
+func (c CalculationMethod) String() string {
+	return string(c)
+}
+
+// Validate validates whether the calculation method is supported
+func (c CalculationMethod) Validate() error {
+	if _, ok := interestCalculators[c]; !ok {
+		return ErrInvalidCalculationMethod
+	}
+
+	return nil
+}
+
+// InterestCalculator returns the InterestCalculator for the method, defaulting to daily simple interest when unset or unknown
+func (c CalculationMethod) InterestCalculator() InterestCalculator {
+	if calculator, ok := interestCalculators[c]; ok {
+		return calculator
+	}
+
+	return interestCalculators[CalculationDailySimple]
+}
+
+// dailySimpleCalculator accrues simple interest on the outstanding balance each day
+type dailySimpleCalculator struct{}
+
+// Calculate implements InterestCalculator
+func (d dailySimpleCalculator) Calculate(loan LoanDetails, transactions []Transaction) []Interest {
+	return CalculateDailySimpleInterest(loan, transactions)
+}
+
+// dailyCompoundCalculator capitalises accrued interest every day, so each day accrues on the balance plus all interest to date
+type dailyCompoundCalculator struct{}
+
+// Calculate implements InterestCalculator
+func (d dailyCompoundCalculator) Calculate(loan LoanDetails, transactions []Transaction) []Interest {
+	return calculateInterest(loan, transactions, func(day accrualDay, totalInterest Decimal) (Decimal, Decimal) {
+		compoundingBalance := day.balance.Add(totalInterest)
+		return day.accrue(compoundingBalance, day.baseRate), day.accrue(compoundingBalance, day.allInRate)
+	})
+}
+
+// monthlyCompoundCalculator capitalises accrued interest at the start of each calendar month
+type monthlyCompoundCalculator struct{}
+
+// Calculate implements InterestCalculator
+func (m monthlyCompoundCalculator) Calculate(loan LoanDetails, transactions []Transaction) []Interest {
+	month := loan.StartDate.Month()
+	capitalisedInterest := Decimal{}
+
+	return calculateInterest(loan, transactions, func(day accrualDay, totalInterest Decimal) (Decimal, Decimal) {
+		if day.date.Month() != month {
+			month = day.date.Month()
+			capitalisedInterest = totalInterest
+		}
+
+		compoundingBalance := day.balance.Add(capitalisedInterest)
+		return day.accrue(compoundingBalance, day.baseRate), day.accrue(compoundingBalance, day.allInRate)
+	})
+}
+
+// compoundedInArrearsCalculator compounds the daily base rate in arrears using the non-cumulative compounded rate
+// formula used for risk free rates such as SONIA and SOFR, with the margin accruing as simple interest on top
+type compoundedInArrearsCalculator struct{}
+
+// Calculate implements InterestCalculator
+func (c compoundedInArrearsCalculator) Calculate(loan LoanDetails, transactions []Transaction) []Interest {
+	one := NewDecimal(1, 0)
+	compoundedIndex := one
+
+	return calculateInterest(loan, transactions, func(day accrualDay, totalInterest Decimal) (Decimal, Decimal) {
+		// the base rate is taken after any floor or cap on the all-in rate has been applied
+		baseRate := day.allInRate.Sub(day.margin)
+		dailyFactor := baseRate.Mul(NewDecimal(int64(day.days), 0)).Div(NewDecimal(int64(100*day.daysInYear), 0), compoundingPrecision, RoundHalfEven)
+		nextIndex := compoundedIndex.Mul(one.Add(dailyFactor)).Round(compoundingPrecision, RoundHalfEven)
+
+		interestWithoutMargin := day.balance.Mul(nextIndex.Sub(compoundedIndex)).Round(day.places, day.rounding)
+		marginInterest := day.accrue(day.balance, day.margin)
+		compoundedIndex = nextIndex
+
+		return interestWithoutMargin, interestWithoutMargin.Add(marginInterest)
+	})
+}
+
+// accrualDay holds everything needed to accrue a single day of interest
+type accrualDay struct {
+	date       Date
+	balance    Decimal
+	baseRate   Decimal
+	allInRate  Decimal
+	margin     Decimal
+	days       int
+	daysInYear int
+	places     int
+	rounding   RoundingMode
+}
+
+// accrue calculates the interest accrued on a principal at an annual percentage rate for the day
+func (a accrualDay) accrue(principal, rate Decimal) Decimal {
+	return accrueInterest(principal, rate, a.days, a.daysInYear, a.places, a.rounding)
+}
+
+// accrualFunc accrues a single day of interest given the total interest accrued so far, returning the interest without and with margin
+type accrualFunc func(day accrualDay, totalInterest Decimal) (Decimal, Decimal)
+
+// accrualPeriod returns the first and last dates accruing interest, which depend on whether the loan's start date and maturity date accrue
+func (l LoanDetails) accrualPeriod() (Date, Date) {
+	firstDate, lastDate := l.StartDate, l.MaturityDate()
+	if !l.AccrualBoundaries.includesStart() {
+		firstDate = firstDate.AddDate(0, 0, 1)
+	}
+	if !l.AccrualBoundaries.includesEnd() {
+		lastDate = lastDate.AddDate(0, 0, -1)
+	}
+
+	return firstDate, lastDate
+}
+
+// calculateInterest walks each day of the loan period, tracking the outstanding balance and rates, and accrues interest using accrue
+func calculateInterest(loan LoanDetails, transactions []Transaction, accrue accrualFunc) []Interest {
+	dayCounter := loan.DayCountConvention.DayCounter()
+	rounding := loan.roundingMode()
+	currency := loan.Currency()
+	firstDate, lastDate := loan.accrualPeriod()
+	totalDays := max(daysBetween(firstDate, lastDate)+1, 0)
+	dailyInterest := make([]Interest, totalDays)
+	totalInterest := Decimal{}
+	balance := loan.PrincipalAmount.Amount
+	nextTransaction := 0
+
+	transactions = slices.Clone(transactions)
+	sortTransactions(transactions)
+
+	// when rounding on the total, daily amounts are held to a higher precision and only the running total is rounded
+	places := currency.MinorUnits()
+	if loan.RoundingPoint == RoundOnTotal {
+		places = calculationPrecision
+	}
+
+	for i := 0; i < totalDays; i++ {
+		accrualDate := firstDate.AddDate(0, 0, i)
+		days, daysInYear := loan.accrualDays(dayCounter, accrualDate)
+		baseRate, allInRate := loan.interestRates(accrualDate)
+
+		// apply every transaction effective on or before this day to the balance
+		for nextTransaction < len(transactions) && daysBetween(transactions[nextTransaction].EffectiveDate, accrualDate) >= 0 {
+			balance = balance.Add(transactions[nextTransaction].BalanceChange())
+			nextTransaction++
+		}
+
+		dailyInterestWithoutMargin, dailyInterestWithMargin := accrue(accrualDay{
+			date:       accrualDate,
+			balance:    balance,
+			baseRate:   baseRate,
+			allInRate:  allInRate,
+			margin:     loan.Margin,
+			days:       days,
+			daysInYear: daysInYear,
+			places:     places,
+			rounding:   rounding,
+		}, totalInterest)
+		totalInterest = totalInterest.Add(dailyInterestWithMargin)
+
+		interest := Interest{
+			AccrualDate:                accrualDate,
+			DaysElapsed:                i + 1,
+			Balance:                    NewMoney(balance, currency),
+			BaseInterestRate:           baseRate,
+			InterestRate:               allInRate,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, currency),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, currency),
+			TotalInterest:              NewMoney(totalInterest, currency).Round(rounding),
+		}
+		dailyInterest[i] = interest
+	}
+
+	return dailyInterest
+}
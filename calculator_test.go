@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestInterestCalculators(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-30")
+	endDate, _ := ParseDate("2024-02-03")
+
+	tests := []struct {
+		method        CalculationMethod
+		point         RoundingPoint
+		withoutMargin []string
+		accrued       []string
+		totals        []string
+	}{
+		{
+			method:        CalculationDailySimple,
+			point:         RoundPerDay,
+			withoutMargin: []string{"9.00", "9.00", "9.00", "9.00"},
+			accrued:       []string{"10.00", "10.00", "10.00", "10.00"},
+			totals:        []string{"10.00", "20.00", "30.00", "40.00"},
+		},
+		{
+			method:        CalculationDailyCompound,
+			point:         RoundPerDay,
+			withoutMargin: []string{"9.00", "9.00", "9.00", "9.01"},
+			accrued:       []string{"10.00", "10.00", "10.01", "10.01"},
+			totals:        []string{"10.00", "20.00", "30.01", "40.02"},
+		},
+		{
+			method:        CalculationMonthlyCompound,
+			point:         RoundPerDay,
+			withoutMargin: []string{"9.00", "9.00", "9.00", "9.00"},
+			accrued:       []string{"10.00", "10.00", "10.01", "10.01"},
+			totals:        []string{"10.00", "20.00", "30.01", "40.02"},
+		},
+		{
+			method:        CalculationCompoundedInArrears,
+			point:         RoundOnTotal,
+			withoutMargin: []string{"9.0000000000", "9.0022191781"},
+			accrued:       []string{"10.0000000000", "10.0022191781"},
+			totals:        []string{"10.00", "20.00"},
+		},
+	}
+
+	for _, test := range tests {
+		if err := test.method.Validate(); err != nil {
+			t.Errorf("Unexpected error validating calculation method %s: %v", test.method, err)
+		}
+
+		loan, err := NewLoan(LoanDetails{
+			StartDate:         startDate,
+			EndDate:           endDate,
+			PrincipalAmount:   NewMoney(NewDecimal(36500, 0), CurrencyUSD),
+			BaseInterestRate:  NewDecimal(9, 0),
+			Margin:            NewDecimal(1, 0),
+			CalculationMethod: test.method,
+			RoundingPoint:     test.point,
+		}, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating loan using %s: %v", test.method, err)
+		}
+
+		for i := range test.accrued {
+			interest := loan.DailyInterest[i]
+			if got := interest.DailyInterestWithoutMargin.Amount.String(); got != test.withoutMargin[i] {
+				t.Errorf("Unexpected interest without margin on day %d using %s. got %s, want %s", i+1, test.method, got, test.withoutMargin[i])
+			}
+			if got := interest.DailyInterestAccrued.Amount.String(); got != test.accrued[i] {
+				t.Errorf("Unexpected interest accrued on day %d using %s. got %s, want %s", i+1, test.method, got, test.accrued[i])
+			}
+			if got := interest.TotalInterest.Amount.String(); got != test.totals[i] {
+				t.Errorf("Unexpected total interest on day %d using %s. got %s, want %s", i+1, test.method, got, test.totals[i])
+			}
+		}
+	}
+
+	if err := CalculationMethod("quarterly-magic").Validate(); err == nil {
+		t.Errorf("Expected error validating an invalid calculation method but got none")
+	}
+}
+
+func TestAccrualBoundaries(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-30")
+	endDate, _ := ParseDate("2024-02-03")
+
+	tests := []struct {
+		boundaries AccrualBoundaries
+		days       int
+		first      string
+		last       string
+	}{
+		{boundaries: "", days: 4, first: "2024-01-30", last: "2024-02-02"},
+		{boundaries: AccrualStartInclusive, days: 4, first: "2024-01-30", last: "2024-02-02"},
+		{boundaries: AccrualEndInclusive, days: 4, first: "2024-01-31", last: "2024-02-03"},
+		{boundaries: AccrualBothInclusive, days: 5, first: "2024-01-30", last: "2024-02-03"},
+		{boundaries: AccrualBothExclusive, days: 3, first: "2024-01-31", last: "2024-02-02"},
+	}
+
+	for _, test := range tests {
+		loan, err := NewLoan(LoanDetails{
+			StartDate:         startDate,
+			EndDate:           endDate,
+			PrincipalAmount:   NewMoney(NewDecimal(36500, 0), CurrencyUSD),
+			BaseInterestRate:  NewDecimal(9, 0),
+			Margin:            NewDecimal(1, 0),
+			AccrualBoundaries: test.boundaries,
+		}, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating %q loan: %v", test.boundaries, err)
+		}
+
+		if loan.LoanDetails.AccrualBoundaries == "" {
+			t.Errorf("Expected %q loan to default its accrual boundaries but got none", test.boundaries)
+		}
+		if got := len(loan.DailyInterest); got != test.days {
+			t.Fatalf("Unexpected number of days accrued by %q loan. got %d, want %d", test.boundaries, got, test.days)
+		}
+		if got := loan.DailyInterest[0].AccrualDate.String(); got != test.first {
+			t.Errorf("Unexpected first accrual date of %q loan. got %s, want %s", test.boundaries, got, test.first)
+		}
+		if got := loan.DailyInterest[test.days-1].AccrualDate.String(); got != test.last {
+			t.Errorf("Unexpected last accrual date of %q loan. got %s, want %s", test.boundaries, got, test.last)
+		}
+		if got, want := loan.DailyInterest[test.days-1].TotalInterest.Amount.String(), NewDecimal(int64(10*test.days), 0).Round(2, RoundHalfEven).String(); got != want {
+			t.Errorf("Unexpected total interest of %q loan. got %s, want %s", test.boundaries, got, want)
+		}
+	}
+
+	if err := AccrualBoundaries("inclusive-ish").Validate(); err == nil {
+		t.Errorf("Expected error validating invalid accrual boundaries but got none")
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	CalendarTARGET2 = "TARGET2"
+	CalendarUK      = "UK"
+	CalendarUS      = "US"
+)
+
+const (
+	BusinessDayFollowing         = "following"
+	BusinessDayModifiedFollowing = "modified-following"
+	BusinessDayPreceding         = "preceding"
+)
+
+var (
+	AllowedCalendars = []Calendar{
+		CalendarTARGET2,
+		CalendarUK,
+		CalendarUS,
+	}
+
+	AllowedBusinessDayConventions = []BusinessDayConvention{
+		BusinessDayFollowing,
+		BusinessDayModifiedFollowing,
+		BusinessDayPreceding,
+	}
+
+	// holidayCalendars holds the holidays of each loaded calendar
+	holidayCalendars = map[Calendar]holidayCalendar{}
+)
+
+// holidayCalendar holds the holidays of a calendar and the years they cover
+type holidayCalendar struct {
+	holidays  map[Date]bool
+	firstYear int // firstYear is the year of the earliest holiday, before which the calendar's holidays are unknown
+	lastYear  int // lastYear is the year of the latest holiday, after which the calendar's holidays are unknown
+}
+
+// Calendar holds the name of the holiday calendar business days are taken from
+type Calendar string
+
+// String stringifies the calendar
+func (c Calendar) String() string {
+	return string(c)
+}
+
+// Validate validates whether the calendar is supported and its holidays have been loaded
+func (c Calendar) Validate() error {
+	if ok := slices.Contains(AllowedCalendars, c); !ok {
+		return ErrInvalidCalendar
+	}
+	if _, ok := holidayCalendars[c]; !ok {
+		return errors.Wrapf(ErrInvalidCalendar, "holidays of %s have not been loaded", c)
+	}
+
+	return nil
+}
+
+// IsBusinessDay returns whether a date is neither a weekend nor a holiday of the calendar, where a blank calendar only excludes weekends
+func (c Calendar) IsBusinessDay(date Date) bool {
+	if weekday := date.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+
+	return !holidayCalendars[c].holidays[date]
+}
+
+// validateCovers validates that the holidays of the calendar cover every year from the start date to the end date,
+// as any date outside them would be taken as a business day on a holiday that is not known
+func (c Calendar) validateCovers(start, end Date) error {
+	calendar := holidayCalendars[c]
+	if len(calendar.holidays) == 0 {
+		return errors.Wrapf(ErrInvalidCalendar, "%s has no holidays", c)
+	}
+	if start.Year() < calendar.firstYear || end.Year() > calendar.lastYear {
+		return errors.Wrapf(ErrInvalidCalendar, "holidays of %s only cover %d to %d, but the loan runs from %s to %s",
+			c, calendar.firstYear, calendar.lastYear, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	return nil
+}
+
+// RegisterHolidayCalendar registers the holidays of a calendar so it can be used by loans, replacing any already registered
+func RegisterHolidayCalendar(calendar Calendar, holidays []Date) {
+	if !slices.Contains(AllowedCalendars, calendar) {
+		AllowedCalendars = append(AllowedCalendars, calendar)
+	}
+
+	registered := holidayCalendar{holidays: make(map[Date]bool, len(holidays))}
+	for n, holiday := range holidays {
+		registered.holidays[holiday] = true
+		if n == 0 || holiday.Year() < registered.firstYear {
+			registered.firstYear = holiday.Year()
+		}
+		registered.lastYear = max(registered.lastYear, holiday.Year())
+	}
+	holidayCalendars[calendar] = registered
+}
+
+// LoadHolidayCalendars registers a calendar for each .txt file of holidays in dir, named after the file, e.g. UK.txt
+func LoadHolidayCalendars(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		holidays, err := LoadHolidaysFile(path)
+		if err != nil {
+			return errors.Wrap(err, filepath.Base(path))
+		}
+		RegisterHolidayCalendar(Calendar(strings.TrimSuffix(filepath.Base(path), ".txt")), holidays)
+	}
+
+	return nil
+}
+
+// LoadHolidaysFile loads holidays from the file at path
+func LoadHolidaysFile(path string) ([]Date, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadHolidays(file)
+}
+
+// LoadHolidays loads holidays from lines starting with a date (YYYY-MM-DD) and optionally followed by its name,
+// skipping blank lines and comments starting with #
+func LoadHolidays(r io.Reader) ([]Date, error) {
+	scanner := bufio.NewScanner(r)
+
+	var holidays []Date
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		field, _, _ := strings.Cut(text, " ")
+		date, err := ParseDate(field)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidCalendar, "line %d: invalid date %q", line, field)
+		}
+		holidays = append(holidays, date)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+// calendarName returns the name of a calendar, describing a blank calendar as weekends only
+func calendarName(calendar Calendar) string {
+	if calendar == "" {
+		return "weekends only"
+	}
+	return calendar.String()
+}
+
+// BusinessDayConvention holds how a date falling on a non-business day is adjusted
+type BusinessDayConvention string
+
+// String stringifies the business day convention
+func (b BusinessDayConvention) String() string {
+	return string(b)
+}
+
+// Validate validates whether the business day convention is supported
+func (b BusinessDayConvention) Validate() error {
+	if ok := slices.Contains(AllowedBusinessDayConventions, b); !ok {
+		return ErrInvalidBusinessDayConvention
+	}
+
+	return nil
+}
+
+// Adjust moves a date falling on a non-business day of the calendar to a business day.
+// Following moves forward, preceding moves back, and modified following moves forward unless that crosses into the next month.
+func (b BusinessDayConvention) Adjust(date Date, calendar Calendar) Date {
+	switch b {
+	case BusinessDayFollowing:
+		return rollDate(date, calendar, 1)
+	case BusinessDayModifiedFollowing:
+		if following := rollDate(date, calendar, 1); following.Month() == date.Month() {
+			return following
+		}
+		return rollDate(date, calendar, -1)
+	case BusinessDayPreceding:
+		return rollDate(date, calendar, -1)
+	default:
+		return date
+	}
+}
+
+// rollDate steps a date a day at a time in the given direction until it falls on a business day of the calendar
+func rollDate(date Date, calendar Calendar, direction int) Date {
+	for !calendar.IsBusinessDay(date) {
+		date = date.AddDate(0, 0, direction)
+	}
+
+	return date
+}
+
+// adjustDate adjusts a date for the loan's business day convention, leaving it unadjusted when the loan has none
+func (l LoanDetails) adjustDate(date Date) Date {
+	return l.BusinessDayConvention.Adjust(date, l.Calendar)
+}
+
+// MaturityDate returns the end date of the loan adjusted for its business day convention, which interest accrues up to
+func (l LoanDetails) MaturityDate() Date {
+	return l.adjustDate(l.EndDate)
+}
+
+// runningOn returns whether a date falls between the start date and maturity date of the loan, inclusive
+func (l LoanDetails) runningOn(date Date) bool {
+	return !date.Before(l.StartDate) && !date.After(l.MaturityDate())
+}
+
+// validateBusinessDays validates the loan's calendar and business day convention, and that the adjusted maturity is after the start date
+func (l LoanDetails) validateBusinessDays() error {
+	if l.Calendar != "" {
+		if err := l.Calendar.Validate(); err != nil {
+			return err
+		}
+	}
+	if l.BusinessDayConvention != "" {
+		if err := l.BusinessDayConvention.Validate(); err != nil {
+			return err
+		}
+		if l.Calendar != "" {
+			if err := l.Calendar.validateCovers(l.StartDate, l.EndDate); err != nil {
+				return err
+			}
+		}
+	}
+	if !l.MaturityDate().After(l.StartDate) {
+		return errors.Wrapf(ErrInvalidInput, "end date adjusted to %s needs to be after start date", l.MaturityDate().Format("2006-01-02"))
+	}
+
+	return nil
+}
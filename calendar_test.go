@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadHolidays(t *testing.T) {
+	input := "# UK bank holidays\n\n2024-12-25 Christmas Day\n2024-12-26 Boxing Day\n"
+
+	holidays, err := LoadHolidays(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error loading holidays: %v", err)
+	}
+	if len(holidays) != 2 || holidays[1].Format("2006-01-02") != "2024-12-26" {
+		t.Errorf("Unexpected holidays. got %v", holidays)
+	}
+
+	if _, err := LoadHolidays(strings.NewReader("2024-12-25\nChristmas\n")); !errors.Is(err, ErrInvalidCalendar) {
+		t.Errorf("Expected invalid calendar error but got %v", err)
+	}
+}
+
+func TestLoadHolidayCalendars(t *testing.T) {
+	if err := LoadHolidayCalendars("calendars"); err != nil {
+		t.Fatalf("Unexpected error loading calendars: %v", err)
+	}
+
+	for _, calendar := range []Calendar{CalendarTARGET2, CalendarUK, CalendarUS} {
+		if err := calendar.Validate(); err != nil {
+			t.Errorf("Expected %s to be loaded but got %v", calendar, err)
+		}
+	}
+
+	goodFriday, _ := ParseDate("2024-03-29")
+	if Calendar(CalendarUK).IsBusinessDay(goodFriday) || !Calendar(CalendarUS).IsBusinessDay(goodFriday) {
+		t.Errorf("Expected Good Friday to be a UK holiday but not a US one")
+	}
+}
+
+func TestBusinessDayConventionAdjust(t *testing.T) {
+	RegisterHolidayCalendar("TEST", []Date{
+		NewDate(2024, 3, 29),
+		NewDate(2024, 4, 1),
+	})
+
+	tests := []struct {
+		name       string
+		date       string
+		convention BusinessDayConvention
+		calendar   Calendar
+		want       string
+	}{
+		{name: "unadjusted", date: "2024-03-30", want: "2024-03-30"},
+		{name: "business day", date: "2024-03-28", convention: BusinessDayFollowing, calendar: "TEST", want: "2024-03-28"},
+		{name: "following weekend", date: "2024-03-30", convention: BusinessDayFollowing, want: "2024-04-01"},
+		{name: "following holidays", date: "2024-03-30", convention: BusinessDayFollowing, calendar: "TEST", want: "2024-04-02"},
+		{name: "modified following stays in month", date: "2024-03-31", convention: BusinessDayModifiedFollowing, calendar: "TEST", want: "2024-03-28"},
+		{name: "modified following rolls forward", date: "2024-06-01", convention: BusinessDayModifiedFollowing, want: "2024-06-03"},
+		{name: "preceding", date: "2024-04-01", convention: BusinessDayPreceding, calendar: "TEST", want: "2024-03-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, _ := ParseDate(tt.date)
+			if got := tt.convention.Adjust(date, tt.calendar).Format("2006-01-02"); got != tt.want {
+				t.Errorf("Unexpected adjusted date. got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLoanBusinessDays(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-06-30") // a Sunday
+
+	details := LoanDetails{
+		ID:                    "loan1",
+		StartDate:             startDate,
+		EndDate:               endDate,
+		PrincipalAmount:       NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+		BaseInterestRate:      NewDecimal(5, 0),
+		Margin:                NewDecimal(1, 0),
+		RepaymentProfile:      RepaymentProfile{Type: RepaymentEqualPrincipal, Frequency: FrequencyQuarterly},
+		BusinessDayConvention: BusinessDayModifiedFollowing,
+	}
+
+	loan, err := NewLoan(details, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	// the end date rolls back to Friday as the following Monday is in July
+	if got := loan.DailyInterest[len(loan.DailyInterest)-1].AccrualDate.Format("2006-01-02"); got != "2024-06-27" {
+		t.Errorf("Expected interest to accrue up to the adjusted maturity. got last accrual date %s", got)
+	}
+	if got := loan.Schedule[0].DueDate.Format("2006-01-02"); got != "2024-04-01" {
+		t.Errorf("Unexpected first due date. got %s", got)
+	}
+	if got := loan.Schedule[len(loan.Schedule)-1].DueDate.Format("2006-01-02"); got != "2024-06-28" {
+		t.Errorf("Expected the final instalment on the adjusted maturity. got %s", got)
+	}
+
+	details.Calendar = CalendarUK
+	details.EndDate = NewDate(2030, 6, 28)
+	if _, err := NewLoan(details, nil); !errors.Is(err, ErrInvalidCalendar) {
+		t.Errorf("Expected invalid calendar error for a loan running beyond the calendar's holidays but got %v", err)
+	}
+	details.StartDate, details.EndDate = NewDate(2023, 6, 1), endDate
+	if _, err := NewLoan(details, nil); !errors.Is(err, ErrInvalidCalendar) {
+		t.Errorf("Expected invalid calendar error for a loan starting before the calendar's holidays but got %v", err)
+	}
+	details.StartDate = startDate
+	if _, err := NewLoan(details, nil); err != nil {
+		t.Errorf("Unexpected error for a loan within the calendar's holidays: %v", err)
+	}
+
+	details.Calendar = "MISSING"
+	if _, err := NewLoan(details, nil); !errors.Is(err, ErrInvalidCalendar) {
+		t.Errorf("Expected invalid calendar error but got %v", err)
+	}
+}
@@ -2,12 +2,13 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
-	"math"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/pkg/errors"
@@ -20,27 +21,34 @@ const (
 	Reset = "\033[0m"
 )
 
+// maxListedCurrencies is the most allowed currencies listed when requesting a loan currency
+const maxListedCurrencies = 10
+
 // cli encapsulates the command line interface reading and writing
 type cli struct {
 	reader         *bufio.Reader
 	loanRepository LoanRepository
+	user           string          // user is who changes made through the cli are recorded against
+	retention      RetentionPolicy // retention decides how long deleted loans are kept before they are purged
 }
 
-// NewCLI creates a new instance of a cli
-func NewCLI(loanRepository LoanRepository) *cli {
+// NewCLI creates a new instance of a cli, recording changes to loans against the user and purging deleted loans by the retention policy
+func NewCLI(loanRepository LoanRepository, user string, retention RetentionPolicy) *cli {
 	return &cli{
 		reader:         bufio.NewReader(os.Stdin),
 		loanRepository: loanRepository,
+		user:           user,
+		retention:      retention,
 	}
 }
 
 // DrawMenu draws the menu for the calculator
-func (c *cli) DrawMenu() error {
+func (c *cli) DrawMenu(ctx context.Context) error {
 	fmt.Println("Simple Daily Interest Loan Calculator 🧮")
 
 	for {
 		fmt.Println()
-		input, err := c.requestString("Action", "create, history, export, list, update, delete or exit", true)
+		input, err := c.requestString("Action", "create, import, history, accrued, schedule, export, backup, restore, portfolio, list, update, versions, payment, drawdown, delete, undelete, deleted, purge or exit", true)
 		if err != nil {
 			printErr(err)
 			continue
@@ -49,17 +57,41 @@ func (c *cli) DrawMenu() error {
 
 		switch input {
 		case "create":
-			err = c.handleCreate()
+			err = c.handleCreate(ctx)
+		case "import":
+			err = c.handleImport(ctx)
 		case "history":
-			err = c.handleHistory()
+			err = c.handleHistory(ctx)
+		case "accrued":
+			err = c.handleAccrued(ctx)
+		case "schedule":
+			err = c.handleSchedule(ctx)
 		case "export":
-			err = c.handleExport()
+			err = c.handleExport(ctx)
+		case "backup":
+			err = c.handleBackup(ctx)
+		case "restore":
+			err = c.handleRestore(ctx)
+		case "portfolio":
+			err = c.handlePortfolio(ctx)
 		case "list":
-			err = c.handleList()
+			err = c.handleList(ctx)
 		case "update":
-			err = c.handleUpdate()
+			err = c.handleUpdate(ctx)
+		case "versions":
+			err = c.handleVersions(ctx)
+		case "payment":
+			err = c.handleTransaction(ctx, TransactionRepayment)
+		case "drawdown":
+			err = c.handleTransaction(ctx, TransactionDrawdown)
 		case "delete":
-			err = c.handleDelete()
+			err = c.handleDelete(ctx)
+		case "undelete":
+			err = c.handleUndelete(ctx)
+		case "deleted":
+			err = c.handleDeleted(ctx)
+		case "purge":
+			err = c.handlePurge(ctx)
 		case "exit":
 			return nil
 		default:
@@ -73,7 +105,7 @@ func (c *cli) DrawMenu() error {
 }
 
 // handleCreate handles creating a new loan
-func (c *cli) handleCreate() error {
+func (c *cli) handleCreate(ctx context.Context) error {
 	id := randomString(8)
 
 	loanDetails, err := c.requestLoanDetails(id)
@@ -81,12 +113,12 @@ func (c *cli) handleCreate() error {
 		return err
 	}
 
-	loan := Loan{
-		LoanDetails:   loanDetails,
-		DailyInterest: CalculateDailySimpleInterest(loanDetails),
+	loan, err := NewLoan(loanDetails, nil)
+	if err != nil {
+		return err
 	}
 
-	if err := c.loanRepository.Create(loan); err != nil {
+	if err := c.loanRepository.Create(ctx, loan, c.change("")); err != nil {
 		return err
 	}
 
@@ -96,364 +128,1397 @@ func (c *cli) handleCreate() error {
 	return nil
 }
 
-// handleHistory handles a loan history
-func (c *cli) handleHistory() error {
-	id, err := c.requestString("Loan ID", "8 character ID", true)
+// handleImport handles creating loans in bulk from a CSV or JSON lines file
+func (c *cli) handleImport(ctx context.Context) error {
+	path, err := c.requestString("File", "CSV or JSON lines file of loan details", true)
 	if err != nil {
 		return err
 	}
-	loan, err := c.loanRepository.Read(id)
+
+	format, err := requestOption(c, "Format", AllowedImportFormats, false)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("\nFetched history for loan (%s)\n", sprintColoured(loan.LoanDetails.ID, Cyan))
-	printLoan(loan)
+	mode, err := requestOption(c, "Mode", AllowedImportModes, false)
+	if err != nil {
+		return err
+	}
+	if mode == "" {
+		mode = ImportAllOrNothing
+	}
+
+	result, err := ImportLoansFile(ctx, c.loanRepository, path, format, mode, c.change("imported from "+path))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nImported %d of %d loans\n", len(result.Created), result.Rows)
+	for _, id := range result.Created {
+		fmt.Println("\t", sprintColoured(id, Cyan))
+	}
+	for _, rowErr := range result.Errors {
+		printErr(rowErr)
+	}
 
 	return nil
 }
 
-// handleExport handles exporting a loan
-func (c *cli) handleExport() error {
+// handleHistory handles a loan history
+func (c *cli) handleHistory(ctx context.Context) error {
 	id, err := c.requestString("Loan ID", "8 character ID", true)
 	if err != nil {
 		return err
 	}
-	loan, err := c.loanRepository.Read(id)
+	loan, err := c.loanRepository.Read(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("\nExported history for loan (%s) as JSON\n", sprintColoured(loan.LoanDetails.ID, Cyan))
-
-	data, err := json.MarshalIndent(loan, "", "    ")
+	filter, err := c.requestHistoryFilter()
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s\n", data)
-
-	return nil
-}
+	pageSize, err := c.requestNonNegativeInt("Page Size", "days or periods per page, blank for all", false)
+	if err != nil {
+		return err
+	}
 
-// handleList handles fetching a list of loans
-func (c *cli) handleList() error {
-	loans := c.loanRepository.List()
+	fmt.Printf("\nFetched history for loan (%s)\n", sprintColoured(loan.LoanDetails.ID, Cyan))
+	printLoanDetails(loan)
 
-	if len(loans) == 0 {
-		fmt.Println("\tThere are no loans to be listed")
-		return nil
+	dailyInterest := filter.Apply(loan.DailyInterest)
+	if filter.Summary != "" {
+		printPages(c, SummariseInterest(dailyInterest, filter.Summary), pageSize, printSummaries)
+	} else {
+		printPages(c, dailyInterest, pageSize, printInterest)
 	}
 
-	for id := range loans {
-		fmt.Println("\t", id)
-	}
+	printValf("\n", "Loan ID", "%s\n", loan.LoanDetails.ID)
 
 	return nil
 }
 
-// handleUpdate handles a loan update
-func (c *cli) handleUpdate() error {
+// handleAccrued handles printing the interest accrued on a loan as of a valuation date
+func (c *cli) handleAccrued(ctx context.Context) error {
 	id, err := c.requestString("Loan ID", "8 character ID", true)
 	if err != nil {
 		return err
 	}
-	if _, err := c.loanRepository.Read(id); err != nil {
+	loan, err := c.loanRepository.Read(ctx, id)
+	if err != nil {
 		return err
 	}
 
-	loanDetails, err := c.requestLoanDetails(id)
+	valuationDate, err := c.requestDate("Valuation Date", true)
 	if err != nil {
 		return err
 	}
 
-	updatedLoan := Loan{
-		LoanDetails:   loanDetails,
-		DailyInterest: CalculateDailySimpleInterest(loanDetails),
+	from, err := c.requestString("Period Start", "YYYY-MM-DD or blank for the start of the month", false)
+	if err != nil {
+		return err
+	}
+	periodStart, err := parseOptionalDateInput(from)
+	if err != nil {
+		return err
 	}
 
-	if err := c.loanRepository.Update(updatedLoan); err != nil {
+	accrued, err := loan.Accrued(valuationDate, periodStart)
+	if err != nil {
 		return err
 	}
 
-	fmt.Printf("\nUpdated loan (%s) with following details\n", sprintColoured(loanDetails.ID, Cyan))
-	printLoan(updatedLoan)
+	fmt.Printf("\nFetched accrued interest for loan (%s)\n", sprintColoured(loan.LoanDetails.ID, Cyan))
+	printAccrued(accrued)
 
 	return nil
 }
 
-// handleDelete handles deleting a loan
-func (c *cli) handleDelete() error {
+// handleSchedule handles printing the amortisation schedule of a loan
+func (c *cli) handleSchedule(ctx context.Context) error {
 	id, err := c.requestString("Loan ID", "8 character ID", true)
 	if err != nil {
 		return err
 	}
-
-	if ok := c.requestConfirmation("Are you sure you want to continue?"); !ok {
-		printColouredln("\tDelete was cancelled	", Red)
-		return nil
-	}
-
-	err = c.loanRepository.Delete(id)
+	loan, err := c.loanRepository.Read(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	fmt.Printf("\nFetched %s repayment schedule for loan (%s)\n", loan.LoanDetails.RepaymentProfile.repaymentType(), sprintColoured(loan.LoanDetails.ID, Cyan))
+	printSchedule(loan.Schedule)
+
 	return nil
 }
 
-// requestLoanDetails draws the loan details input form, validates the inputs, and outputs a LoanDetails struct
-func (c *cli) requestLoanDetails(id string) (LoanDetails, error) {
-	fmt.Println("\nInput the values for the loan")
+// handleExport handles exporting a loan as JSON or CSV, to stdout or a file
+func (c *cli) handleExport(ctx context.Context) error {
+	id, err := c.requestString("Loan ID", "8 character ID", true)
+	if err != nil {
+		return err
+	}
+	loan, err := c.loanRepository.Read(ctx, id)
+	if err != nil {
+		return err
+	}
 
-	var (
-		startDate        time.Time
-		endDate          time.Time
-		loanAmount       float64
-		loanCurrency     Currency
-		baseInterestRate float64
-		margin           float64
-		err              error
-	)
+	format, err := requestOption(c, "Format", AllowedExportFormats, false)
+	if err != nil {
+		return err
+	}
 
-	for {
-		startDate, err = c.requestDate("Start Date", true)
-		if err == nil {
-			break
+	options := CSVOptions{Delimiter: ',', Precision: loan.LoanDetails.Currency().MinorUnits()}
+	if format == ExportCSV {
+		delimiter, err := c.requestString("Delimiter", "single character, tab or blank for a comma", false)
+		if err != nil {
+			return err
+		}
+		if options.Delimiter, err = parseDelimiterInput(delimiter); err != nil {
+			return err
 		}
-		printErr(err)
-	}
 
-	for {
-		endDate, err = c.requestDateAfter("End Date", startDate, true)
-		if err == nil {
-			break
+		precision, err := c.requestString("Decimal Places", fmt.Sprintf("blank for %d", options.Precision), false)
+		if err != nil {
+			return err
+		}
+		if len(precision) > 0 {
+			if options.Precision, err = parseNonNegativeIntInput(precision); err != nil {
+				return err
+			}
 		}
-		printErr(err)
 	}
 
-	for {
-		loanAmount, err = c.requestPositiveFloat64("Loan Amount", "principal amount being loaned", true)
-		if err == nil {
-			break
-		}
-		printErr(err)
+	if options.Filter, err = c.requestHistoryFilter(); err != nil {
+		return err
 	}
 
-	for {
-		loanCurrency, err = c.requestCurrency("Loan Currency", AllowedCurrencies, true)
-		if err == nil {
-			break
-		}
-		printErr(err)
+	path, err := c.requestString("Output File", "path or blank to print", false)
+	if err != nil {
+		return err
 	}
 
-	for {
-		baseInterestRate, err = c.requestPositiveFloat64("Base Interest Rate", "percentage", true)
-		if err == nil {
-			break
-		}
-		printErr(err)
+	if len(path) == 0 {
+		fmt.Printf("\nExported history for loan (%s) as %s\n", sprintColoured(loan.LoanDetails.ID, Cyan), strings.ToUpper(format.String()))
 	}
 
-	for {
-		margin, err = c.requestPositiveFloat64("Margin", "percentage", true)
-		if err == nil {
-			break
+	err = writeToPath(path, func(w io.Writer) error {
+		if format == ExportCSV {
+			return WriteLoanCSV(w, loan, options)
 		}
-		printErr(err)
+		return WriteLoanHistoryJSON(w, loan, options.Filter)
+	})
+	if err != nil {
+		return err
 	}
 
-	return LoanDetails{
-		ID:               id,
-		StartDate:        startDate,
-		EndDate:          endDate,
-		Currency:         loanCurrency,
-		PrincipalAmount:  loanAmount,
-		BaseInterestRate: baseInterestRate,
-		Margin:           margin,
-	}, nil
+	if len(path) > 0 {
+		fmt.Printf("\nExported history for loan (%s) to %s\n", sprintColoured(loan.LoanDetails.ID, Cyan), path)
+	}
+
+	return nil
 }
 
-// requestString requests a string input from the user
-func (c *cli) requestString(name, hint string, required bool) (string, error) {
-	if len(hint) > 0 {
-		fmt.Printf("%s%s (%s): %s", Cyan, name, hint, Reset)
-	} else {
-		fmt.Printf("%s%s: %s", Cyan, name, Reset)
+// handleBackup handles exporting every loan, including deleted loans, as a JSON array that can be restored
+func (c *cli) handleBackup(ctx context.Context) error {
+	path, err := c.requestString("Output File", "path of the backup", true)
+	if err != nil {
+		return err
 	}
 
-	input, err := c.reader.ReadString('\n')
+	loans, err := BackupLoans(ctx, c.loanRepository)
 	if err != nil {
-		return "", err
+		return err
 	}
-	input = strings.TrimSuffix(input, "\n")
 
-	if required && len(input) == 0 {
-		return "", ErrInvalidInput
+	if err := writeToPath(path, func(w io.Writer) error { return WriteLoansJSON(w, loans) }); err != nil {
+		return err
 	}
 
-	return input, nil
+	fmt.Printf("\nBacked up %d loans to %s\n", len(loans), path)
+
+	return nil
 }
 
-// requestFloat64 requests a float input from the user
-func (c *cli) requestFloat64(name, hint string, required bool) (float64, error) {
-	val, err := c.requestString(name, hint, required)
+// handleRestore handles restoring loans from a JSON export or backup, verifying their daily interest
+func (c *cli) handleRestore(ctx context.Context) error {
+	path, err := c.requestString("File", "JSON export of a loan or backup of loans", true)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	if err := validateDecimalPlaces(val, 2); err != nil {
-		return 0, err
-	}
+	replace := c.requestConfirmation("Replace loans that already exist?")
 
-	floatVal, err := strconv.ParseFloat(val, 32)
+	ids, err := RestoreLoansFile(ctx, c.loanRepository, path, replace, c.change("restored from "+path))
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	floatVal = math.Round(floatVal*100) / 100
+	fmt.Printf("\nRestored %d loans\n", len(ids))
+	for _, id := range ids {
+		fmt.Println("\t", sprintColoured(id, Cyan))
+	}
 
-	return floatVal, nil
+	return nil
 }
 
-// requestPositiveFloat64 requests a float input from the user that is >= 0
-func (c *cli) requestPositiveFloat64(name, hint string, required bool) (float64, error) {
-	val, err := c.requestFloat64(name, hint, required)
+// handlePortfolio handles printing the exposure of every loan converted to a reporting currency
+func (c *cli) handlePortfolio(ctx context.Context) error {
+	path, err := c.requestString("FX Rates File", "CSV file of date,base,quote,rate", true)
 	if err != nil {
-		return 0, err
+		return err
 	}
-
-	if val < 0 {
-		return 0, errors.Wrap(ErrInvalidInput, "value must be greater than 0")
+	rates, err := LoadFXRatesFile(path)
+	if err != nil {
+		return err
 	}
 
-	return val, nil
-}
-
-// requestDate requests a date input from the user in the format YYYY-MM-DD
-func (c *cli) requestDate(name string, required bool) (time.Time, error) {
-	val, err := c.requestString(name, "YYYY-MM-DD", required)
+	input, err := c.requestString("Reporting Currency", "ISO 4217 code, e.g. GBP", true)
 	if err != nil {
-		return time.Time{}, err
+		return err
 	}
 
-	date, err := time.Parse("2006-01-02", val)
+	valuationDate, err := c.requestDate("Valuation Date", true)
 	if err != nil {
-		return time.Time{}, err
+		return err
 	}
-	return date, nil
-}
 
-// requestDateAfter requests a date input from the user in the format YYYY-MM-DD after a specific date
-func (c *cli) requestDateAfter(name string, date time.Time, required bool) (time.Time, error) {
-	input, err := c.requestDate(name, required)
+	page, err := c.loanRepository.List(ctx, LoanQuery{})
 	if err != nil {
-		return time.Time{}, err
+		return err
 	}
 
-	if !input.After(date) {
-		return time.Time{}, errors.Wrap(ErrInvalidInput, "end date needs to be after start date")
+	report, err := NewPortfolioReport(page.Loans, Currency(strings.ToUpper(input)), valuationDate, rates)
+	if err != nil {
+		return err
 	}
 
-	return input, nil
+	fmt.Printf("\nFetched portfolio of %s loans\n", sprintColoured(strconv.Itoa(len(report.Loans)), Cyan))
+	printPortfolio(report)
+
+	return nil
 }
 
-// requestCurrency requests a currency input from the user in the ISO 4217 format
-func (c *cli) requestCurrency(name string, allowedCurrencies []Currency, required bool) (Currency, error) {
-	currencies := ""
-	for _, c := range allowedCurrencies {
-		currencies += c.String() + ", "
+// handleList handles fetching a filtered and sorted list of loans
+func (c *cli) handleList(ctx context.Context) error {
+	query, err := c.requestLoanQuery()
+	if err != nil {
+		return err
 	}
-	currencies = currencies[:len(currencies)-2]
 
-	input, err := c.requestString(name, currencies, required)
+	pageSize, err := c.requestNonNegativeInt("Page Size", "loans per page, blank for all", false)
 	if err != nil {
-		return "", err
+		return err
 	}
-	input = strings.ToUpper(input)
 
-	currency := Currency(input)
-	if err := currency.Validate(); err != nil {
-		return "", err
+	page, err := c.loanRepository.List(ctx, query)
+	if err != nil {
+		return err
 	}
 
-	return currency, nil
-}
+	if page.Total == 0 {
+		fmt.Println("\tThere are no loans to be listed")
+		return nil
+	}
 
-// requestConfirmation requests a yes/y/no/n confirmation
-func (c *cli) requestConfirmation(msg string) bool {
-	for {
-		val, err := c.requestString(msg, "", true)
-		if err != nil {
-			return false
-		}
+	fmt.Printf("\nFetched %s loans\n", sprintColoured(strconv.Itoa(page.Total), Cyan))
+	printPages(c, page.Loans, pageSize, printLoanTable)
 
-		val = strings.ToLower(val)
+	return nil
+}
 
-		switch val {
-		case "y", "yes":
-			return true
-		case "n", "no":
-			return false
-		default:
-			continue
-		}
+// handleUpdate handles a loan update
+func (c *cli) handleUpdate(ctx context.Context) error {
+	id, err := c.requestString("Loan ID", "8 character ID", true)
+	if err != nil {
+		return err
+	}
+	loan, err := c.loanRepository.Read(ctx, id)
+	if err != nil {
+		return err
 	}
-}
 
-// validateDecimalPlaces validates whether an input string's decimal places is within the allowed amount
-func validateDecimalPlaces(input string, places int) error {
-	decimalIndex := strings.Index(input, ".")
-	if decimalIndex == -1 {
-		return nil
+	loanDetails, err := c.requestLoanDetails(id)
+	if err != nil {
+		return err
 	}
-	decimalPlaces := len(input) - decimalIndex - 1
-	if decimalPlaces > places {
-		return errors.Wrapf(ErrInvalidDecimalPlaces, "max decimal places should not exceed %d", places)
+
+	updatedLoan, err := NewLoan(loanDetails, loan.Transactions)
+	if err != nil {
+		return err
 	}
-	return nil
-}
+	// the update is only saved if nobody else changed the loan while its details were being entered
+	updatedLoan.Revision = loan.Revision
 
-// printErr prints an error with ANSI escape codes to colourise the output in red
-func printErr(err error) {
-	errStr := err.Error()
-	errStr = strings.ToUpper(string(errStr[0])) + errStr[1:]
-	fmt.Printf("\t%s\n", sprintColoured(errStr, Red))
-}
+	reason, err := c.requestString("Reason", "why the loan is being updated", false)
+	if err != nil {
+		return err
+	}
 
-// sprintColoured returns a string wrapped in colourised ANSI escape codes
-func sprintColoured(value, colour string) string {
-	return fmt.Sprintf("%s%s%s", colour, value, Reset)
-}
+	if err := c.loanRepository.Update(ctx, updatedLoan, c.change(reason)); err != nil {
+		return conflictHint(err)
+	}
+	updatedLoan.Revision++
 
-// printColouredln returns prints a colourised string to the console with a new line
-func printColouredln(value, colour string) {
-	fmt.Println(sprintColoured(value, colour))
-}
+	fmt.Printf("\nUpdated loan (%s) with following details\n", sprintColoured(loanDetails.ID, Cyan))
+	printLoan(updatedLoan)
 
-// printValf prints a value with a colourised name
-func printValf(prefix, name, fmtStr string, args ...any) {
-	fmt.Printf("%s%s: %s", prefix, sprintColoured(name, Cyan), fmt.Sprintf(fmtStr, args...))
+	return nil
 }
 
-// printLoan prints out the loan details in a stylised way
-func printLoan(loan Loan) {
-	printValf("", "Loan ID", "%s\n", loan.LoanDetails.ID)
-	printValf("", "Start Date", "%s\n", loan.LoanDetails.StartDate.Format("2006-01-02"))
-	printValf("", "End Date", "%s\n", loan.LoanDetails.EndDate.Format("2006-01-02"))
-	printValf("", "Loan Amount", " %s%.2f\n", loan.LoanDetails.Currency.Symbol(), loan.LoanDetails.PrincipalAmount)
-	printValf("", "Loan Currency", "%s\n", loan.LoanDetails.Currency)
-	printValf("", "Base Interest Rate", " %v%%\n", loan.LoanDetails.BaseInterestRate)
-	printValf("", "Margin", "%v%%\n", loan.LoanDetails.Margin)
-
-	for _, interest := range loan.DailyInterest {
-		printValf("\t- ", "Accrual Date", "%s\n", interest.AccrualDate.Format("2006-01-02"))
-		printValf("\t  ", "Days Elapsed", "%d\n", interest.DaysElapsed)
-		printValf("\t  ", "Daily Interest Amount without Margin", " %s%f\n", loan.LoanDetails.Currency.Symbol(), interest.DailyInterestWithoutMargin)
-		printValf("\t  ", "Daily Interest Amount Accrued", " %s%f\n", loan.LoanDetails.Currency.Symbol(), interest.DailyInterestAccrued)
-		printValf("\t  ", "Total Interest", " %s%f\n", loan.LoanDetails.Currency.Symbol(), interest.TotalInterest)
+// handleVersions handles listing the versions of a loan's details, viewing the loan as of a version, or comparing two versions
+func (c *cli) handleVersions(ctx context.Context) error {
+	id, err := c.requestString("Loan ID", "8 character ID", true)
+	if err != nil {
+		return err
+	}
+	loan, err := c.loanRepository.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+	versions, err := c.loanRepository.Versions(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	input, err := c.requestString("Version", "number to view the loan as of, from,to to compare two, or blank to list them", false)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(input) == 0:
+		printVersions(versions)
+	case strings.Contains(input, ","):
+		from, to, err := parseVersionRangeInput(input)
+		if err != nil {
+			return err
+		}
+		changes, err := DiffVersions(versions, from, to)
+		if err != nil {
+			return err
+		}
+		printChanges(changes)
+	default:
+		number, err := parseVersionInput(input)
+		if err != nil {
+			return err
+		}
+		asOfLoan, err := LoanAsOf(loan, versions, number)
+		if err != nil {
+			return err
+		}
+		printLoan(asOfLoan)
+	}
+
+	return nil
+}
+
+// handleTransaction handles adding a repayment or drawdown to a loan and recalculating its daily interest
+func (c *cli) handleTransaction(ctx context.Context, transactionType TransactionType) error {
+	id, err := c.requestString("Loan ID", "8 character ID", true)
+	if err != nil {
+		return err
+	}
+	loan, err := c.loanRepository.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	effectiveDate, err := c.requestDateWithin("Effective Date", loan.LoanDetails.StartDate, loan.LoanDetails.MaturityDate(), true)
+	if err != nil {
+		return err
+	}
+
+	amount, err := c.requestPositiveDecimal("Amount", loan.LoanDetails.Currency().String(), loan.LoanDetails.Currency().MinorUnits(), true)
+	if err != nil {
+		return err
+	}
+
+	transaction := Transaction{
+		ID:            randomString(8),
+		Type:          transactionType,
+		EffectiveDate: effectiveDate,
+		Amount:        NewMoney(amount, loan.LoanDetails.Currency()),
+	}
+
+	updatedLoan, err := loan.AddTransaction(transaction)
+	if err != nil {
+		return err
+	}
+
+	if err := c.loanRepository.Update(ctx, updatedLoan, c.change("")); err != nil {
+		return conflictHint(err)
+	}
+	updatedLoan.Revision++
+
+	fmt.Printf("\nAdded %s (%s) to loan (%s) with following details\n", transactionType, sprintColoured(transaction.ID, Cyan), sprintColoured(loan.LoanDetails.ID, Cyan))
+	printLoan(updatedLoan)
+
+	return nil
+}
+
+// handleDelete handles soft deleting a loan, which can be undeleted until it is purged
+func (c *cli) handleDelete(ctx context.Context) error {
+	id, err := c.requestString("Loan ID", "8 character ID", true)
+	if err != nil {
+		return err
+	}
+	loan, err := c.loanRepository.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if ok := c.requestConfirmation("Are you sure you want to continue?"); !ok {
+		printColouredln("\tDelete was cancelled	", Red)
+		return nil
+	}
+
+	reason, err := c.requestString("Reason", "why the loan is being deleted", false)
+	if err != nil {
+		return err
+	}
+
+	err = c.loanRepository.Delete(ctx, id, loan.Revision, c.change(reason))
+	if err != nil {
+		return conflictHint(err)
+	}
+
+	fmt.Printf("\nDeleted loan (%s), which can be undeleted until it is purged\n", sprintColoured(id, Cyan))
+
+	return nil
+}
+
+// handleUndelete handles restoring a deleted loan
+func (c *cli) handleUndelete(ctx context.Context) error {
+	id, err := c.requestString("Loan ID", "8 character ID", true)
+	if err != nil {
+		return err
+	}
+
+	if err := c.loanRepository.Undelete(ctx, id); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nUndeleted loan (%s)\n", sprintColoured(id, Cyan))
+
+	return nil
+}
+
+// handleDeleted handles listing the deleted loans that have not been purged
+func (c *cli) handleDeleted(ctx context.Context) error {
+	deleted, err := c.loanRepository.Deleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(deleted) == 0 {
+		fmt.Println("\tThere are no deleted loans to be listed")
+		return nil
+	}
+
+	printDeletedLoans(deleted)
+
+	return nil
+}
+
+// handlePurge handles permanently removing the deleted loans kept for longer than the retention period
+func (c *cli) handlePurge(ctx context.Context) error {
+	if ok := c.requestConfirmation(fmt.Sprintf("Permanently remove loans deleted more than %d days ago?", c.retention.Days)); !ok {
+		printColouredln("\tPurge was cancelled", Red)
+		return nil
+	}
+
+	ids, err := c.retention.Purge(ctx, c.loanRepository, time.Now().UTC())
+	fmt.Printf("\nPurged %d loans\n", len(ids))
+	for _, id := range ids {
+		fmt.Println("\t", sprintColoured(id, Cyan))
+	}
+
+	return err
+}
+
+// requestLoanDetails draws the loan details input form, validates the inputs, and outputs a LoanDetails struct
+func (c *cli) requestLoanDetails(id string) (LoanDetails, error) {
+	fmt.Println("\nInput the values for the loan")
+
+	var (
+		startDate        Date
+		endDate          Date
+		loanAmount       Decimal
+		loanCurrency     Currency
+		baseInterestRate Decimal
+		margin           Decimal
+		floatingRate     *FloatingRate
+		repaymentProfile RepaymentProfile
+		method           CalculationMethod
+		dayCount         DayCountConvention
+		roundingMode     RoundingMode
+		roundingPoint    RoundingPoint
+		accrual          AccrualBoundaries
+		businessDay      BusinessDayConvention
+		calendar         Calendar
+		err              error
+	)
+
+	for {
+		startDate, err = c.requestDate("Start Date", true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		endDate, err = c.requestDateAfter("End Date", startDate, true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		loanCurrency, err = c.requestCurrency()
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		loanAmount, err = c.requestPositiveDecimal("Loan Amount", "principal amount being loaned", loanCurrency.MinorUnits(), true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		baseInterestRate, err = c.requestPositiveDecimal("Base Interest Rate", "percentage", 2, true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		margin, err = c.requestPositiveDecimal("Margin", "percentage", 2, true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		floatingRate, err = c.requestFloatingRate()
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		method, err = requestOption(c, "Calculation Method", AllowedCalculationMethods, true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		dayCount, err = requestOption(c, "Day Count Convention", AllowedDayCountConventions, true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		roundingMode, err = requestOption(c, "Rounding Mode", AllowedRoundingModes, true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		roundingPoint, err = requestOption(c, "Rounding Point", AllowedRoundingPoints, true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		accrual, err = requestOption(c, "Accrual Boundaries", AllowedAccrualBoundaries, true)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		businessDay, err = c.requestBusinessDays(&calendar)
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	for {
+		repaymentProfile, err = c.requestRepaymentProfile(startDate, endDate, NewMoney(loanAmount, loanCurrency))
+		if err == nil {
+			break
+		}
+		printErr(err)
+	}
+
+	return LoanDetails{
+		ID:               id,
+		StartDate:        startDate,
+		EndDate:          endDate,
+		PrincipalAmount:  NewMoney(loanAmount, loanCurrency),
+		BaseInterestRate: baseInterestRate,
+		Margin:           margin,
+
+		FloatingRate:     floatingRate,
+		RepaymentProfile: repaymentProfile,
+
+		CalculationMethod:  method,
+		DayCountConvention: dayCount,
+		RoundingMode:       roundingMode,
+		RoundingPoint:      roundingPoint,
+
+		Calendar:              calendar,
+		BusinessDayConvention: businessDay,
+
+		AccrualBoundaries: accrual,
+	}, nil
+}
+
+// requestCurrency requests the loan currency, only listing the allowed currencies when there are few enough to read
+func (c *cli) requestCurrency() (Currency, error) {
+	if len(AllowedCurrencies) <= maxListedCurrencies {
+		return requestOption(c, "Loan Currency", AllowedCurrencies, true)
+	}
+
+	input, err := c.requestString("Loan Currency", "ISO 4217 code, e.g. GBP", true)
+	if err != nil {
+		return "", err
+	}
+
+	return parseOptionInput(input, AllowedCurrencies)
+}
+
+// requestFloatingRate requests an optional base rate fixings file and the floating rate terms, returning nil for a fixed rate loan
+func (c *cli) requestFloatingRate() (*FloatingRate, error) {
+	path, err := c.requestString("Base Rate Fixings", "CSV file of date,rate or blank for a fixed rate", false)
+	if err != nil || len(path) == 0 {
+		return nil, err
+	}
+
+	fixings, err := LoadRateFixingsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := c.requestString("Reference Rate", "e.g. SONIA, SOFR, EURIBOR", false)
+	if err != nil {
+		return nil, err
+	}
+
+	lookbackDays, err := c.requestNonNegativeInt("Lookback Days", "blank for none", false)
+	if err != nil {
+		return nil, err
+	}
+
+	observationShift := false
+	if lookbackDays > 0 {
+		observationShift = c.requestConfirmation("Apply observation shift?")
+	}
+
+	floor, err := c.requestOptionalDecimal("Floor", "all-in percentage or blank for none", 2)
+	if err != nil {
+		return nil, err
+	}
+
+	rateCap, err := c.requestOptionalDecimal("Cap", "all-in percentage or blank for none", 2)
+	if err != nil {
+		return nil, err
+	}
+
+	floatingRate := &FloatingRate{
+		Index:            index,
+		Fixings:          fixings,
+		LookbackDays:     lookbackDays,
+		ObservationShift: observationShift,
+		Floor:            floor,
+		Cap:              rateCap,
+	}
+
+	if err := floatingRate.Validate(); err != nil {
+		return nil, err
+	}
+
+	return floatingRate, nil
+}
+
+// requestBusinessDays requests an optional business day convention and the holiday calendar it applies, leaving dates unadjusted when blank
+func (c *cli) requestBusinessDays(calendar *Calendar) (BusinessDayConvention, error) {
+	businessDay, err := requestOption(c, "Business Day Convention", AllowedBusinessDayConventions, false)
+	if err != nil || businessDay == "" {
+		*calendar = ""
+		return businessDay, err
+	}
+
+	if *calendar, err = requestOption(c, "Holiday Calendar", AllowedCalendars, false); err != nil {
+		return "", err
+	}
+	if *calendar != "" {
+		if err := calendar.Validate(); err != nil {
+			return "", err
+		}
+	}
+
+	return businessDay, nil
+}
+
+// requestRepaymentProfile requests how the loan is repaid, including the instalments of a custom profile
+func (c *cli) requestRepaymentProfile(startDate, endDate Date, principal Money) (RepaymentProfile, error) {
+	repaymentType, err := requestOption(c, "Repayment Profile", AllowedRepaymentTypes, true)
+	if err != nil {
+		return RepaymentProfile{}, err
+	}
+
+	profile := RepaymentProfile{Type: repaymentType}
+
+	switch repaymentType {
+	case RepaymentCustom:
+		fmt.Println("\nInput the principal repaid by each instalment, leaving the due date blank to finish")
+		previousDate := startDate
+		for {
+			dueDate, err := c.requestString("Instalment Due Date", "YYYY-MM-DD or blank to finish", false)
+			if err != nil {
+				return RepaymentProfile{}, err
+			}
+			if len(dueDate) == 0 {
+				break
+			}
+
+			date, err := parseDateInput(dueDate)
+			if err != nil {
+				printErr(err)
+				continue
+			}
+			if !date.After(previousDate) || date.After(endDate) {
+				printErr(errors.Wrap(ErrInvalidInput, "due date needs to be after the previous due date and not after the end date"))
+				continue
+			}
+
+			amount, err := c.requestPositiveDecimal("Instalment Principal", principal.Currency.String(), principal.Currency.MinorUnits(), true)
+			if err != nil {
+				printErr(err)
+				continue
+			}
+
+			profile.CustomInstalments = append(profile.CustomInstalments, CustomInstalment{DueDate: date, Principal: NewMoney(amount, principal.Currency)})
+			previousDate = date
+		}
+	default:
+		profile.Frequency, err = requestOption(c, "Payment Frequency", AllowedPaymentFrequencies, repaymentType != RepaymentBullet)
+		if err != nil {
+			return RepaymentProfile{}, err
+		}
+	}
+
+	if err := profile.Validate(LoanDetails{StartDate: startDate, EndDate: endDate, PrincipalAmount: principal}); err != nil {
+		return RepaymentProfile{}, err
+	}
+
+	return profile, nil
+}
+
+// change returns a change to a loan made by the cli's user for a reason
+func (c *cli) change(reason string) LoanChange {
+	return LoanChange{ChangedBy: c.user, Reason: reason}
+}
+
+// requestString requests a string input from the user
+func (c *cli) requestString(name, hint string, required bool) (string, error) {
+	if len(hint) > 0 {
+		fmt.Printf("%s%s (%s): %s", Cyan, name, hint, Reset)
+	} else {
+		fmt.Printf("%s%s: %s", Cyan, name, Reset)
+	}
+
+	input, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	input = strings.TrimSuffix(input, "\n")
+
+	if required && len(input) == 0 {
+		return "", ErrInvalidInput
+	}
+
+	return input, nil
+}
+
+// requestDecimal requests an exact decimal input from the user with at most the given number of decimal places
+func (c *cli) requestDecimal(name, hint string, places int, required bool) (Decimal, error) {
+	val, err := c.requestString(name, hint, required)
+	if err != nil {
+		return Decimal{}, err
+	}
+
+	return parseDecimalInput(val, places)
+}
+
+// requestPositiveDecimal requests an exact decimal input from the user that is >= 0
+func (c *cli) requestPositiveDecimal(name, hint string, places int, required bool) (Decimal, error) {
+	val, err := c.requestString(name, hint, required)
+	if err != nil {
+		return Decimal{}, err
+	}
+
+	return parsePositiveDecimalInput(val, places)
+}
+
+// requestOptionalDecimal requests an exact decimal input from the user, returning nil when left blank
+func (c *cli) requestOptionalDecimal(name, hint string, places int) (*Decimal, error) {
+	val, err := c.requestString(name, hint, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseOptionalDecimalInput(val, places)
+}
+
+// requestNonNegativeInt requests a whole number input from the user that is >= 0, returning 0 when left blank and not required
+func (c *cli) requestNonNegativeInt(name, hint string, required bool) (int, error) {
+	val, err := c.requestString(name, hint, required)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseNonNegativeIntInput(val)
+}
+
+// requestDate requests a date input from the user in the format YYYY-MM-DD
+func (c *cli) requestDate(name string, required bool) (Date, error) {
+	val, err := c.requestString(name, "YYYY-MM-DD", required)
+	if err != nil {
+		return Date{}, err
+	}
+
+	return parseDateInput(val)
+}
+
+// requestDateAfter requests a date input from the user in the format YYYY-MM-DD after a specific date
+func (c *cli) requestDateAfter(name string, date Date, required bool) (Date, error) {
+	input, err := c.requestDate(name, required)
+	if err != nil {
+		return Date{}, err
+	}
+
+	if err := validateDateAfter(input, date); err != nil {
+		return Date{}, err
+	}
+
+	return input, nil
+}
+
+// requestDateWithin requests a date input from the user in the format YYYY-MM-DD on or after start and before end
+func (c *cli) requestDateWithin(name string, start, end Date, required bool) (Date, error) {
+	input, err := c.requestDate(name, required)
+	if err != nil {
+		return Date{}, err
+	}
+
+	if err := validateDateWithin(input, start, end); err != nil {
+		return Date{}, err
+	}
+
+	return input, nil
+}
+
+// requestHistoryFilter requests the date window and summary period of a loan's history from the user
+func (c *cli) requestHistoryFilter() (HistoryFilter, error) {
+	from, err := c.requestString("From", "YYYY-MM-DD or blank for the start", false)
+	if err != nil {
+		return HistoryFilter{}, err
+	}
+
+	to, err := c.requestString("To", "YYYY-MM-DD or blank for the end", false)
+	if err != nil {
+		return HistoryFilter{}, err
+	}
+
+	summary, err := c.requestString("Summary", "month, quarter, year or blank for every day", false)
+	if err != nil {
+		return HistoryFilter{}, err
+	}
+
+	return parseHistoryFilterInput(from, to, summary)
+}
+
+// requestLoanQuery requests the filters and sort of a list of loans, where every filter can be left blank
+func (c *cli) requestLoanQuery() (LoanQuery, error) {
+	var (
+		input loanQueryInput
+		err   error
+	)
+
+	if input.currency, err = c.requestString("Currency", "ISO 4217 code or blank for every currency", false); err != nil {
+		return LoanQuery{}, err
+	}
+	if input.activeOn, err = c.requestString("Active On", "YYYY-MM-DD or blank for any", false); err != nil {
+		return LoanQuery{}, err
+	}
+	if input.maturingBefore, err = c.requestString("Maturing Before", "YYYY-MM-DD or blank for any", false); err != nil {
+		return LoanQuery{}, err
+	}
+	if input.sort, err = c.requestString("Sort", "id, currency, principal, rate, start or maturity", false); err != nil {
+		return LoanQuery{}, err
+	}
+
+	return input.query()
+}
+
+// printPages prints items a page at a time, asking the user whether to show each following page
+func printPages[T any](c *cli, items []T, size int, printPage func([]T)) {
+	for page := 1; ; page++ {
+		pageItems, pages := paginate(items, page, size)
+		printPage(pageItems)
+
+		if page >= pages || !c.requestConfirmation(fmt.Sprintf("Show page %d of %d?", page+1, pages)) {
+			return
+		}
+	}
+}
+
+// option is a string based value with a fixed set of allowed values, such as a Currency
+type option interface {
+	~string
+	Validate() error
+}
+
+// requestOption requests one of the allowed options from the user, matching case-insensitively
+func requestOption[T option](c *cli, name string, allowed []T, required bool) (T, error) {
+	options := make([]string, len(allowed))
+	for i, o := range allowed {
+		options[i] = string(o)
+	}
+
+	input, err := c.requestString(name, strings.Join(options, ", "), required)
+	if err != nil {
+		return "", err
+	}
+
+	return parseOptionInput(input, allowed)
+}
+
+// requestConfirmation requests a yes/y/no/n confirmation
+func (c *cli) requestConfirmation(msg string) bool {
+	for {
+		val, err := c.requestString(msg, "", true)
+		if err != nil {
+			return false
+		}
+
+		val = strings.ToLower(val)
+
+		switch val {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		default:
+			continue
+		}
+	}
+}
+
+// parseDecimalInput parses an exact decimal input with at most the given number of decimal places
+func parseDecimalInput(input string, places int) (Decimal, error) {
+	if err := validateDecimalPlaces(input, places); err != nil {
+		return Decimal{}, err
+	}
+
+	return ParseDecimal(input)
+}
+
+// parsePositiveDecimalInput parses an exact decimal input that is >= 0
+func parsePositiveDecimalInput(input string, places int) (Decimal, error) {
+	val, err := parseDecimalInput(input, places)
+	if err != nil {
+		return Decimal{}, err
+	}
+
+	if val.Sign() < 0 {
+		return Decimal{}, errors.Wrap(ErrInvalidInput, "value must be greater than 0")
+	}
+
+	return val, nil
+}
+
+// parseOptionalDecimalInput parses an exact decimal input, returning nil when blank
+func parseOptionalDecimalInput(input string, places int) (*Decimal, error) {
+	if len(input) == 0 {
+		return nil, nil
+	}
+
+	decimal, err := parseDecimalInput(input, places)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decimal, nil
+}
+
+// parseOptionalBoolInput parses a true/false input, returning false when blank
+func parseOptionalBoolInput(input string) (bool, error) {
+	if len(input) == 0 {
+		return false, nil
+	}
+
+	boolVal, err := strconv.ParseBool(input)
+	if err != nil {
+		return false, errors.Wrap(ErrInvalidInput, "value must be true or false")
+	}
+
+	return boolVal, nil
+}
+
+// parseNonNegativeIntInput parses a whole number input that is >= 0, returning 0 when blank
+func parseNonNegativeIntInput(input string) (int, error) {
+	if len(input) == 0 {
+		return 0, nil
+	}
+
+	intVal, err := strconv.Atoi(input)
+	if err != nil {
+		return 0, errors.Wrap(ErrInvalidInput, "value must be a whole number")
+	}
+
+	if intVal < 0 {
+		return 0, errors.Wrap(ErrInvalidInput, "value must not be negative")
+	}
+
+	return intVal, nil
+}
+
+// parseVersionInput parses a loan version number input, which starts from 1
+func parseVersionInput(input string) (int, error) {
+	number, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || number < 1 {
+		return 0, errors.Wrapf(ErrInvalidInput, "version must be a whole number from 1 but got %q", input)
+	}
+
+	return number, nil
+}
+
+// parseVersionRangeInput parses the from and to loan version numbers of an input in the format from,to
+func parseVersionRangeInput(input string) (int, int, error) {
+	from, to, ok := strings.Cut(input, ",")
+	if !ok {
+		return 0, 0, errors.Wrapf(ErrInvalidInput, "versions must be given as from,to but got %q", input)
+	}
+
+	fromNumber, err := parseVersionInput(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	toNumber, err := parseVersionInput(to)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return fromNumber, toNumber, nil
+}
+
+// parseDateInput parses a date input in the format YYYY-MM-DD
+func parseDateInput(input string) (Date, error) {
+	date, err := ParseDate(input)
+	if err != nil {
+		return Date{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	return date, nil
+}
+
+// parseOptionalDateInput parses a date input in the format YYYY-MM-DD, returning the zero date when blank
+func parseOptionalDateInput(input string) (Date, error) {
+	if len(input) == 0 {
+		return Date{}, nil
+	}
+
+	return parseDateInput(input)
+}
+
+// parseHistoryFilterInput parses the optional from and to dates and summary period of a loan's history
+func parseHistoryFilterInput(from, to, summary string) (HistoryFilter, error) {
+	var (
+		filter HistoryFilter
+		err    error
+	)
+	if filter.From, err = parseOptionalDateInput(from); err != nil {
+		return HistoryFilter{}, errors.Wrap(err, "from")
+	}
+	if filter.To, err = parseOptionalDateInput(to); err != nil {
+		return HistoryFilter{}, errors.Wrap(err, "to")
+	}
+	if filter.Summary, err = parseOptionInput(summary, AllowedSummaryPeriods); err != nil {
+		return HistoryFilter{}, err
+	}
+
+	if err := filter.Validate(); err != nil {
+		return HistoryFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// validateDateAfter validates an end date is after the start date
+func validateDateAfter(date, start Date) error {
+	if !date.After(start) {
+		return errors.Wrap(ErrInvalidInput, "end date needs to be after start date")
+	}
+	return nil
+}
+
+// validateDateWithin validates a date is on or after start and before end
+func validateDateWithin(date, start, end Date) error {
+	if date.Before(start) || !date.Before(end) {
+		return errors.Wrapf(ErrInvalidInput, "date needs to be between %s and %s", start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	return nil
+}
+
+// parseOptionInput parses one of the allowed options, matching case-insensitively and returning "" when blank
+func parseOptionInput[T option](input string, allowed []T) (T, error) {
+	if len(input) == 0 {
+		return "", nil
+	}
+
+	for _, o := range allowed {
+		if strings.EqualFold(input, string(o)) {
+			return o, nil
+		}
+	}
+
+	if err := T(input).Validate(); err != nil {
+		return "", err
+	}
+
+	return "", ErrInvalidInput
+}
+
+// validateDecimalPlaces validates whether an input string's decimal places is within the allowed amount
+func validateDecimalPlaces(input string, places int) error {
+	decimalIndex := strings.Index(input, ".")
+	if decimalIndex == -1 {
+		return nil
+	}
+	decimalPlaces := len(input) - decimalIndex - 1
+	if decimalPlaces > places {
+		return errors.Wrapf(ErrInvalidDecimalPlaces, "max decimal places should not exceed %d", places)
+	}
+	return nil
+}
+
+// conflictHint adds advice to an ErrLoanConflict, returned when a loan was changed by someone else while the user was being prompted
+func conflictHint(err error) error {
+	if errors.Is(err, ErrLoanConflict) {
+		return errors.Wrap(err, "nothing was saved, so fetch the loan again and retry")
+	}
+	return err
+}
+
+// printErr prints an error with ANSI escape codes to colourise the output in red
+func printErr(err error) {
+	errStr := err.Error()
+	errStr = strings.ToUpper(string(errStr[0])) + errStr[1:]
+	fmt.Printf("\t%s\n", sprintColoured(errStr, Red))
+}
+
+// sprintColoured returns a string wrapped in colourised ANSI escape codes
+func sprintColoured(value, colour string) string {
+	return fmt.Sprintf("%s%s%s", colour, value, Reset)
+}
+
+// printColouredln returns prints a colourised string to the console with a new line
+func printColouredln(value, colour string) {
+	fmt.Println(sprintColoured(value, colour))
+}
+
+// printValf prints a value with a colourised name
+func printValf(prefix, name, fmtStr string, args ...any) {
+	fmt.Printf("%s%s: %s", prefix, sprintColoured(name, Cyan), fmt.Sprintf(fmtStr, args...))
+}
+
+// printLoan prints out the loan details and its daily interest in a stylised way
+func printLoan(loan Loan) {
+	printLoanDetails(loan)
+	printInterest(loan.DailyInterest)
+	printValf("\n", "Loan ID", "%s\n", loan.LoanDetails.ID)
+}
+
+// printLoanDetails prints out the loan details and transactions in a stylised way
+func printLoanDetails(loan Loan) {
+	printValf("", "Loan ID", "%s\n", loan.LoanDetails.ID)
+	if loan.Revision > 0 {
+		printValf("", "Revision", "%d\n", loan.Revision)
+	}
+	printValf("", "Start Date", "%s\n", loan.LoanDetails.StartDate.Format("2006-01-02"))
+	printValf("", "End Date", "%s\n", loan.LoanDetails.EndDate.Format("2006-01-02"))
+	printValf("", "Loan Amount", " %s\n", loan.LoanDetails.PrincipalAmount)
+	printValf("", "Loan Currency", "%s (%s)\n", loan.LoanDetails.Currency(), loan.LoanDetails.Currency().Name())
+	printValf("", "Base Interest Rate", " %s%%\n", loan.LoanDetails.BaseInterestRate)
+	printValf("", "Margin", "%s%%\n", loan.LoanDetails.Margin)
+	if floatingRate := loan.LoanDetails.FloatingRate; floatingRate != nil {
+		printValf("", "Reference Rate", "%s (%d fixings)\n", floatingRate.Index, len(floatingRate.Fixings))
+		printValf("", "Lookback Days", "%d (observation shift: %t)\n", floatingRate.LookbackDays, floatingRate.ObservationShift)
+		if floatingRate.Floor != nil {
+			printValf("", "Floor", "%s%%\n", floatingRate.Floor)
+		}
+		if floatingRate.Cap != nil {
+			printValf("", "Cap", "%s%%\n", floatingRate.Cap)
+		}
+	}
+	printValf("", "Calculation Method", "%s\n", loan.LoanDetails.CalculationMethod)
+	printValf("", "Repayment Profile", "%s %s\n", loan.LoanDetails.RepaymentProfile.repaymentType(), loan.LoanDetails.RepaymentProfile.Frequency)
+	printValf("", "Day Count Convention", "%s\n", loan.LoanDetails.DayCountConvention)
+	printValf("", "Rounding", "%s (%s)\n", loan.LoanDetails.RoundingMode, loan.LoanDetails.RoundingPoint)
+	printValf("", "Accrual Boundaries", "%s\n", loan.LoanDetails.AccrualBoundaries)
+	if businessDay := loan.LoanDetails.BusinessDayConvention; businessDay != "" {
+		printValf("", "Business Days", "%s (%s)\n", businessDay, calendarName(loan.LoanDetails.Calendar))
+		printValf("", "Maturity Date", "%s\n", loan.LoanDetails.MaturityDate().Format("2006-01-02"))
+	}
+
+	for _, transaction := range loan.Transactions {
+		printValf("\t- ", "Transaction", "%s (%s)\n", transaction.ID, transaction.Type)
+		printValf("\t  ", "Effective Date", "%s\n", transaction.EffectiveDate.Format("2006-01-02"))
+		printValf("\t  ", "Amount", " %s\n", transaction.Amount)
+	}
+
+}
+
+// printInterest prints out days of accrued interest in a stylised way
+func printInterest(dailyInterest []Interest) {
+	for _, interest := range dailyInterest {
+		printValf("\t- ", "Accrual Date", "%s\n", interest.AccrualDate.Format("2006-01-02"))
+		printValf("\t  ", "Days Elapsed", "%d\n", interest.DaysElapsed)
+		printValf("\t  ", "Balance", " %s\n", interest.Balance)
+		printValf("\t  ", "Interest Rate", " %s%%\n", interest.InterestRate)
+		printValf("\t  ", "Daily Interest Amount without Margin", " %s\n", interest.DailyInterestWithoutMargin)
+		printValf("\t  ", "Daily Interest Amount Accrued", " %s\n", interest.DailyInterestAccrued)
+		printValf("\t  ", "Total Interest", " %s\n", interest.TotalInterest)
+	}
+}
+
+// printSummaries prints out periodic summaries of accrued interest in a stylised way
+func printSummaries(summaries []InterestSummary) {
+	for _, summary := range summaries {
+		printValf("\t- ", "Period", "%s\n", summary.Period)
+		printValf("\t  ", "Accrual Dates", "%s to %s (%d days)\n", summary.FirstDate.Format("2006-01-02"), summary.LastDate.Format("2006-01-02"), summary.Days)
+		printValf("\t  ", "Interest Accrued", " %s\n", summary.InterestAccrued)
+		printValf("\t  ", "Total Interest", " %s\n", summary.TotalInterest)
+	}
+}
+
+// printAccrued prints out the interest accrued on a loan as of a valuation date in a stylised way
+func printAccrued(accrued AccruedInterest) {
+	printValf("", "Valuation Date", "%s\n", accrued.ValuationDate.Format("2006-01-02"))
+	printValf("", "Accrued to Date", " %s\n", accrued.AccruedToDate)
+	printValf("", "Accrued in Period", " %s (from %s)\n", accrued.AccruedInPeriod, accrued.PeriodStart.Format("2006-01-02"))
+	printValf("", "Remaining to Maturity", " %s (until %s)\n", accrued.RemainingToMaturity, accrued.MaturityDate.Format("2006-01-02"))
+	printValf("", "Total to Maturity", " %s\n", accrued.TotalToMaturity)
+}
+
+// printPortfolio prints out a portfolio report's loans, totals by currency and aggregate totals in a stylised way
+func printPortfolio(report PortfolioReport) {
+	for _, loan := range report.Loans {
+		printValf("\t- ", "Loan ID", "%s\n", loan.LoanID)
+		printValf("\t  ", "Principal", " %s (%s)\n", loan.Principal, loan.ReportingPrincipal)
+		printValf("\t  ", "Accrued Interest", " %s (%s)\n", loan.AccruedInterest, loan.ReportingAccruedInterest)
+		printValf("\t  ", "FX Rate", "%s\n", loan.FXRate)
+	}
+
+	for _, total := range report.Currencies {
+		printValf("\t- ", "Currency", "%s (loans: %d)\n", total.Currency, total.Loans)
+		printValf("\t  ", "Principal", " %s (%s)\n", total.Principal, total.ReportingPrincipal)
+		printValf("\t  ", "Accrued Interest", " %s (%s)\n", total.AccruedInterest, total.ReportingAccruedInterest)
+	}
+
+	printValf("\n", "Valuation Date", "%s\n", report.ValuationDate.Format("2006-01-02"))
+	printValf("", "Reporting Currency", "%s\n", report.ReportingCurrency)
+	printValf("", "Total Principal", " %s\n", report.TotalPrincipal)
+	printValf("", "Total Accrued Interest", " %s\n", report.TotalAccruedInterest)
+	printValf("", "Total Exposure", " %s\n", report.TotalExposure)
+}
+
+// printVersions prints out the versions of a loan's details and what changed in each in a stylised way
+func printVersions(versions []LoanVersion) {
+	for _, version := range versions {
+		printValf("\t- ", "Version", "%d\n", version.Version)
+		if !version.ChangedAt.IsZero() {
+			printValf("\t  ", "Changed At", "%s\n", version.ChangedAt.Format(time.RFC3339))
+		}
+		printValf("\t  ", "Changed By", "%s\n", valueOrNone(version.ChangedBy))
+		if len(version.Reason) > 0 {
+			printValf("\t  ", "Reason", "%s\n", version.Reason)
+		}
+		for _, change := range version.Changes {
+			printValf("\t  ", "Changed", "%s\n", change)
+		}
+	}
+}
+
+// printLoanTable prints out the key details of loans as a table with a row per loan
+func printLoanTable(loans []Loan) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tID\tCURRENCY\tPRINCIPAL\tRATE\tSTART\tMATURITY\tREPAYMENT")
+	for _, loan := range loans {
+		details := loan.LoanDetails
+		fmt.Fprintf(w, "\t%s\t%s\t%s\t%s%%\t%s\t%s\t%s\n", details.ID, details.Currency(), details.PrincipalAmount.Amount,
+			details.headlineRate(), details.StartDate, details.MaturityDate(), details.RepaymentProfile.repaymentType())
+	}
+	w.Flush()
+}
+
+// printDeletedLoans prints out deleted loans and when, by whom and why they were deleted in a stylised way
+func printDeletedLoans(deleted []DeletedLoan) {
+	for _, loan := range deleted {
+		printValf("\t- ", "Loan ID", "%s\n", loan.Loan.LoanDetails.ID)
+		printValf("\t  ", "Deleted At", "%s\n", loan.DeletedAt.Format(time.RFC3339))
+		printValf("\t  ", "Deleted By", "%s\n", valueOrNone(loan.DeletedBy))
+		if len(loan.Reason) > 0 {
+			printValf("\t  ", "Reason", "%s\n", loan.Reason)
+		}
+	}
+}
+
+// printChanges prints out the changes between two versions of a loan's details in a stylised way
+func printChanges(changes []FieldChange) {
+	if len(changes) == 0 {
+		fmt.Println("\tThere are no changes between the versions")
+		return
+	}
+
+	for _, change := range changes {
+		printValf("\t- ", "Changed", "%s\n", change)
+	}
+}
+
+// printSchedule prints out the instalments of an amortisation schedule in a stylised way
+func printSchedule(schedule []Instalment) {
+	for _, instalment := range schedule {
+		printValf("\t- ", "Instalment", "%d\n", instalment.Number)
+		printValf("\t  ", "Due Date", "%s\n", instalment.DueDate.Format("2006-01-02"))
+		printValf("\t  ", "Principal", " %s\n", instalment.Principal)
+		printValf("\t  ", "Interest", " %s\n", instalment.Interest)
+		printValf("\t  ", "Payment", " %s\n", instalment.Payment)
+		printValf("\t  ", "Remaining Balance", " %s\n", instalment.RemainingBalance)
 	}
-
-	printValf("\n", "Loan ID", "%s\n", loan.LoanDetails.ID)
 }
@@ -0,0 +1,883 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Exit codes returned by non-interactive commands
+const (
+	exitOK           = 0 // exitOK is returned when the command succeeded
+	exitFailure      = 1 // exitFailure is returned for unexpected failures, such as the storage being unavailable
+	exitInvalidInput = 2 // exitInvalidInput is returned when the arguments, flags or loan details are invalid
+	exitNotFound     = 3 // exitNotFound is returned when the loan or loan version does not exist
+	exitConflict     = 4 // exitConflict is returned when the loan already exists, is not deleted when it must be, or was changed since the revision expected
+)
+
+// commandUsage describes the non-interactive commands
+const commandUsage = `Usage: calc [-storage memory|file|sqlite] [-path path] [-user name] [-retention days] <command> [arguments] [flags]
+
+Commands:
+  create                   create a loan from flags, printing its ID
+  import <file>            create loans from a CSV or JSON lines file (-format, -mode), printing their IDs
+  history <id>             print a loan and its daily interest (-from, -to, -summary, -page, -page-size)
+  accrued <id>             print the interest accrued as of a valuation date (-date, -from)
+  schedule <id>            print the repayment schedule of a loan
+  export <id>              export a loan as JSON or CSV (-format, -delimiter, -precision, -from, -to, -summary, -output)
+  backup                   export every loan, including deleted loans, as a JSON array (-output)
+  restore <file>           restore loans from a JSON export or backup, verifying their interest (-replace)
+  portfolio                print the exposure of every loan converted to a reporting currency (-rates, -currency, -date, -format, -output)
+  list                     print a table of loans (-currency, -min-principal, -max-principal, -min-rate, -max-rate,
+                           -active-on, -maturing-before, -sort, -desc, -page, -page-size, -ids)
+  update <id>              update a loan, keeping any details not given as flags (-reason, -revision)
+  versions <id>            print the versions of a loan's details (-as-of, -diff)
+  payment <id>             add a repayment (-date, -amount), printing its ID
+  drawdown <id>            add a drawdown (-date, -amount), printing its ID
+  delete <id>              delete a loan, keeping it recoverable until it is purged (-reason, -revision)
+  undelete <id>            restore a deleted loan
+  deleted                  print every deleted loan that has not been purged
+  purge                    permanently remove the deleted loans kept for longer than the retention period
+  serve                    serve the JSON REST API until interrupted (-addr)
+
+Run calc <command> -h for the flags of a command.
+`
+
+// commands are the non-interactive commands, keyed by name
+var commands = map[string]func(c *cli, ctx context.Context, args []string) error{
+	"create":    (*cli).runCreate,
+	"import":    (*cli).runImport,
+	"history":   (*cli).runHistory,
+	"accrued":   (*cli).runAccrued,
+	"schedule":  (*cli).runSchedule,
+	"export":    (*cli).runExport,
+	"backup":    (*cli).runBackup,
+	"restore":   (*cli).runRestore,
+	"portfolio": (*cli).runPortfolio,
+	"list":      (*cli).runList,
+	"update":    (*cli).runUpdate,
+	"versions":  (*cli).runVersions,
+	"payment":   (*cli).runPayment,
+	"drawdown":  (*cli).runDrawdown,
+	"delete":    (*cli).runDelete,
+	"undelete":  (*cli).runUndelete,
+	"deleted":   (*cli).runDeleted,
+	"purge":     (*cli).runPurge,
+	"serve":     (*cli).runServe,
+}
+
+// RunCommand runs a non-interactive command from its arguments, returning the exit code for the process
+func (c *cli) RunCommand(ctx context.Context, args []string) int {
+	run, ok := commands[strings.ToLower(args[0])]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], commandUsage)
+		return exitInvalidInput
+	}
+
+	err := run(c, ctx, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	}
+
+	return exitCode(err)
+}
+
+// exitCode returns the process exit code for the error a command returned
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, ErrLoanDoesNotExists), errors.Is(err, ErrVersionDoesNotExist):
+		return exitNotFound
+	case errors.Is(err, ErrLoanAlreadyExists), errors.Is(err, ErrLoanNotDeleted), errors.Is(err, ErrLoanConflict):
+		return exitConflict
+	case isValidationError(err):
+		return exitInvalidInput
+	default:
+		return exitFailure
+	}
+}
+
+// runCreate runs the create command
+func (c *cli) runCreate(ctx context.Context, args []string) error {
+	flags := newCommandFlags("create", "[flags]")
+	loanFlags := newLoanFlags(flags, nil)
+	if _, err := parseCommandFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	loanDetails, err := loanFlags.loanDetails(randomString(8), nil)
+	if err != nil {
+		return err
+	}
+
+	loan, err := NewLoan(loanDetails, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := c.loanRepository.Create(ctx, loan, c.change("")); err != nil {
+		return err
+	}
+
+	fmt.Println(loan.LoanDetails.ID)
+
+	return nil
+}
+
+// runImport runs the import command, failing if any row could not be imported
+func (c *cli) runImport(ctx context.Context, args []string) error {
+	flags := newCommandFlags("import", "<file> [flags]")
+	format := flags.String("format", "", "file format: csv or jsonl, defaulting to the file extension")
+	mode := flags.String("mode", ImportAllOrNothing, "all-or-nothing or best-effort")
+
+	positional, err := parseCommandFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	importFormat, err := parseOptionInput(*format, AllowedImportFormats)
+	if err != nil {
+		return err
+	}
+	importMode, err := parseRequiredOption("mode", *mode, AllowedImportModes)
+	if err != nil {
+		return err
+	}
+
+	result, err := ImportLoansFile(ctx, c.loanRepository, positional[0], importFormat, importMode, c.change("imported from "+positional[0]))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range result.Created {
+		fmt.Println(id)
+	}
+	for _, rowErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "%v\n", rowErr)
+	}
+
+	if len(result.Errors) > 0 {
+		return errors.Wrapf(ErrInvalidInput, "%d of %d rows could not be imported", len(result.Errors), result.Rows)
+	}
+
+	return nil
+}
+
+// runHistory runs the history command
+func (c *cli) runHistory(ctx context.Context, args []string) error {
+	flags := newCommandFlags("history", "<id> [flags]")
+	history := newHistoryFlags(flags)
+	page := flags.Int("page", 1, "page of days or periods to print")
+	pageSize := flags.Int("page-size", 0, "days or periods per page, 0 for all")
+
+	loan, err := c.readCommandLoan(ctx, flags, args)
+	if err != nil {
+		return err
+	}
+
+	filter, err := history.filter()
+	if err != nil {
+		return err
+	}
+	if *page < 1 || *pageSize < 0 {
+		return errors.Wrap(ErrInvalidInput, "page must be at least 1 and page-size must not be negative")
+	}
+
+	printLoanDetails(loan)
+
+	pages := 0
+	dailyInterest := filter.Apply(loan.DailyInterest)
+	if filter.Summary != "" {
+		var summaries []InterestSummary
+		summaries, pages = paginate(SummariseInterest(dailyInterest, filter.Summary), *page, *pageSize)
+		printSummaries(summaries)
+	} else {
+		dailyInterest, pages = paginate(dailyInterest, *page, *pageSize)
+		printInterest(dailyInterest)
+	}
+
+	printValf("\n", "Loan ID", "%s\n", loan.LoanDetails.ID)
+	if *pageSize > 0 {
+		fmt.Fprintf(os.Stderr, "Page %d of %d\n", *page, pages)
+	}
+
+	return nil
+}
+
+// runAccrued runs the accrued command
+func (c *cli) runAccrued(ctx context.Context, args []string) error {
+	flags := newCommandFlags("accrued", "<id> [flags]")
+	date := flags.String("date", "", "valuation date (YYYY-MM-DD), counted as accrued")
+	from := flags.String("from", "", "period start (YYYY-MM-DD), defaulting to the start of the valuation date's month")
+
+	loan, err := c.readCommandLoan(ctx, flags, args)
+	if err != nil {
+		return err
+	}
+
+	if err := requireFlags(flags, "date"); err != nil {
+		return err
+	}
+
+	valuationDate, err := parseDateInput(*date)
+	if err != nil {
+		return errors.Wrap(err, "date")
+	}
+	periodStart, err := parseOptionalDateInput(*from)
+	if err != nil {
+		return errors.Wrap(err, "from")
+	}
+
+	accrued, err := loan.Accrued(valuationDate, periodStart)
+	if err != nil {
+		return err
+	}
+
+	printAccrued(accrued)
+
+	return nil
+}
+
+// runSchedule runs the schedule command
+func (c *cli) runSchedule(ctx context.Context, args []string) error {
+	loan, err := c.readCommandLoan(ctx, newCommandFlags("schedule", "<id>"), args)
+	if err != nil {
+		return err
+	}
+
+	printSchedule(loan.Schedule)
+
+	return nil
+}
+
+// runExport runs the export command
+func (c *cli) runExport(ctx context.Context, args []string) error {
+	flags := newCommandFlags("export", "<id> [flags]")
+	format := flags.String("format", ExportJSON, "export format: json or csv")
+	delimiter := flags.String("delimiter", ",", "CSV field delimiter, a single character or tab")
+	precision := flags.String("precision", "", "CSV decimal places of amounts, defaulting to the currency's minor units")
+	history := newHistoryFlags(flags)
+	output := flags.String("output", "", "file to write the export to, defaulting to stdout")
+
+	loan, err := c.readCommandLoan(ctx, flags, args)
+	if err != nil {
+		return err
+	}
+
+	exportFormat, err := parseRequiredOption("format", *format, AllowedExportFormats)
+	if err != nil {
+		return err
+	}
+
+	options := CSVOptions{Precision: loan.LoanDetails.Currency().MinorUnits()}
+	if options.Delimiter, err = parseDelimiterInput(*delimiter); err != nil {
+		return err
+	}
+	if len(*precision) > 0 {
+		if options.Precision, err = parseNonNegativeIntInput(*precision); err != nil {
+			return errors.Wrap(err, "precision")
+		}
+	}
+	if options.Filter, err = history.filter(); err != nil {
+		return err
+	}
+
+	return writeToPath(*output, func(w io.Writer) error {
+		if exportFormat == ExportCSV {
+			return WriteLoanCSV(w, loan, options)
+		}
+		return WriteLoanHistoryJSON(w, loan, options.Filter)
+	})
+}
+
+// runBackup runs the backup command
+func (c *cli) runBackup(ctx context.Context, args []string) error {
+	flags := newCommandFlags("backup", "[flags]")
+	output := flags.String("output", "", "file to write the backup to, defaulting to stdout")
+	if _, err := parseCommandFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	loans, err := BackupLoans(ctx, c.loanRepository)
+	if err != nil {
+		return err
+	}
+	return writeToPath(*output, func(w io.Writer) error { return WriteLoansJSON(w, loans) })
+}
+
+// runRestore runs the restore command, printing the ID of each loan restored
+func (c *cli) runRestore(ctx context.Context, args []string) error {
+	flags := newCommandFlags("restore", "<file> [flags]")
+	replace := flags.Bool("replace", false, "replace loans that already exist")
+
+	positional, err := parseCommandFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ids, err := RestoreLoansFile(ctx, c.loanRepository, positional[0], *replace, c.change("restored from "+positional[0]))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+
+	return nil
+}
+
+// runPortfolio runs the portfolio command
+func (c *cli) runPortfolio(ctx context.Context, args []string) error {
+	flags := newCommandFlags("portfolio", "[flags]")
+	rates := flags.String("rates", "", "CSV file of date,base,quote,rate FX rates")
+	currency := flags.String("currency", "", "reporting currency every loan is converted to")
+	date := flags.String("date", "", "valuation date (YYYY-MM-DD) exposure is measured and converted on")
+	format := flags.String("format", "", "write the report as json or csv instead of printing it")
+	delimiter := flags.String("delimiter", ",", "CSV field delimiter, a single character or tab")
+	output := flags.String("output", "", "file to write the json or csv report to, defaulting to stdout")
+	if _, err := parseCommandFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	if err := requireFlags(flags, "rates", "currency", "date"); err != nil {
+		return err
+	}
+
+	fxRates, err := LoadFXRatesFile(*rates)
+	if err != nil {
+		return err
+	}
+	valuationDate, err := parseDateInput(*date)
+	if err != nil {
+		return errors.Wrap(err, "date")
+	}
+	reportFormat, err := parseOptionInput(*format, AllowedExportFormats)
+	if err != nil {
+		return err
+	}
+	comma, err := parseDelimiterInput(*delimiter)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.loanRepository.List(ctx, LoanQuery{})
+	if err != nil {
+		return err
+	}
+
+	report, err := NewPortfolioReport(page.Loans, Currency(strings.ToUpper(*currency)), valuationDate, fxRates)
+	if err != nil {
+		return err
+	}
+
+	switch reportFormat {
+	case ExportJSON:
+		return writeToPath(*output, func(w io.Writer) error { return WritePortfolioJSON(w, report) })
+	case ExportCSV:
+		return writeToPath(*output, func(w io.Writer) error { return WritePortfolioCSV(w, report, comma) })
+	default:
+		printPortfolio(report)
+		return nil
+	}
+}
+
+// runList runs the list command, printing a table of the loans matching its filters
+func (c *cli) runList(ctx context.Context, args []string) error {
+	flags := newCommandFlags("list", "[flags]")
+	currency := flags.String("currency", "", "ISO 4217 currency code of the loans listed, or blank for every currency")
+	minPrincipal := flags.String("min-principal", "", "smallest principal amount listed")
+	maxPrincipal := flags.String("max-principal", "", "largest principal amount listed")
+	minRate := flags.String("min-rate", "", "lowest all-in rate percentage listed, as of each loan's start date")
+	maxRate := flags.String("max-rate", "", "highest all-in rate percentage listed, as of each loan's start date")
+	activeOn := flags.String("active-on", "", "only list loans running from their start date to their maturity date on the date (YYYY-MM-DD)")
+	maturingBefore := flags.String("maturing-before", "", "only list loans maturing before the date (YYYY-MM-DD)")
+	sort := flags.String("sort", LoanSortID, "field the loans are sorted by: id, currency, principal, rate, start or maturity")
+	descending := flags.Bool("desc", false, "sort the loans from the highest value")
+	page := flags.String("page", "1", "page of loans to print")
+	pageSize := flags.String("page-size", "0", "loans per page, 0 for all")
+	idsOnly := flags.Bool("ids", false, "print only the ID of each loan")
+
+	if _, err := parseCommandFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	query, err := loanQueryInput{
+		currency:       *currency,
+		minPrincipal:   *minPrincipal,
+		maxPrincipal:   *maxPrincipal,
+		minRate:        *minRate,
+		maxRate:        *maxRate,
+		activeOn:       *activeOn,
+		maturingBefore: *maturingBefore,
+		sort:           *sort,
+		descending:     *descending,
+		page:           *page,
+		pageSize:       *pageSize,
+	}.query()
+	if err != nil {
+		return err
+	}
+
+	loans, err := c.loanRepository.List(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if *idsOnly {
+		for _, loan := range loans.Loans {
+			fmt.Println(loan.LoanDetails.ID)
+		}
+	} else {
+		printLoanTable(loans.Loans)
+	}
+
+	if query.PageSize > 0 {
+		fmt.Fprintf(os.Stderr, "Page %d of %d\n", loans.Page, loans.Pages)
+	}
+
+	return nil
+}
+
+// runUpdate runs the update command
+func (c *cli) runUpdate(ctx context.Context, args []string) error {
+	// the flags are parsed once to find the loan, then again with their defaults taken from the existing loan
+	probe := newCommandFlags("update", "<id> [flags]")
+	newLoanFlags(probe, nil)
+	probe.String("reason", "", "")
+	probe.Int("revision", 0, "")
+	positional, err := parseCommandFlags(probe, args, 1)
+	if err != nil {
+		return err
+	}
+
+	loan, err := c.loanRepository.Read(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	flags := newCommandFlags("update", "<id> [flags]")
+	loanFlags := newLoanFlags(flags, &loan.LoanDetails)
+	reason := flags.String("reason", "", "why the loan is being updated, recorded against the new version of its details")
+	revision := flags.Int("revision", 0, "only update the loan if it is still at the revision printed by history, or 0 for the revision just read")
+	if _, err := parseCommandFlags(flags, args, 1); err != nil {
+		return err
+	}
+	if *revision < 0 {
+		return errors.Wrap(ErrInvalidInput, "revision must not be negative")
+	}
+
+	loanDetails, err := loanFlags.loanDetails(loan.LoanDetails.ID, &loan.LoanDetails)
+	if err != nil {
+		return err
+	}
+
+	updatedLoan, err := NewLoan(loanDetails, loan.Transactions)
+	if err != nil {
+		return err
+	}
+	updatedLoan.Revision = loan.Revision
+	if *revision != 0 {
+		updatedLoan.Revision = *revision
+	}
+
+	if err := c.loanRepository.Update(ctx, updatedLoan, c.change(*reason)); err != nil {
+		return err
+	}
+
+	fmt.Println(updatedLoan.LoanDetails.ID)
+
+	return nil
+}
+
+// runVersions runs the versions command, printing the loan as of a version or the changes between two versions when asked
+func (c *cli) runVersions(ctx context.Context, args []string) error {
+	flags := newCommandFlags("versions", "<id> [flags]")
+	asOf := flags.String("as-of", "", "print the loan as of a version, with its interest recalculated from the version's details")
+	diff := flags.String("diff", "", "print the changes between two versions, given as from,to")
+
+	loan, err := c.readCommandLoan(ctx, flags, args)
+	if err != nil {
+		return err
+	}
+
+	versions, err := c.loanRepository.Versions(ctx, loan.LoanDetails.ID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(*asOf) > 0 && len(*diff) > 0:
+		return errors.Wrap(ErrInvalidInput, "-as-of and -diff cannot be used together")
+	case len(*asOf) > 0:
+		number, err := parseVersionInput(*asOf)
+		if err != nil {
+			return errors.Wrap(err, "as-of")
+		}
+		asOfLoan, err := LoanAsOf(loan, versions, number)
+		if err != nil {
+			return err
+		}
+		printLoan(asOfLoan)
+	case len(*diff) > 0:
+		from, to, err := parseVersionRangeInput(*diff)
+		if err != nil {
+			return errors.Wrap(err, "diff")
+		}
+		changes, err := DiffVersions(versions, from, to)
+		if err != nil {
+			return err
+		}
+		printChanges(changes)
+	default:
+		printVersions(versions)
+	}
+
+	return nil
+}
+
+// runPayment runs the payment command
+func (c *cli) runPayment(ctx context.Context, args []string) error {
+	return c.runTransaction(ctx, TransactionRepayment, args)
+}
+
+// runDrawdown runs the drawdown command
+func (c *cli) runDrawdown(ctx context.Context, args []string) error {
+	return c.runTransaction(ctx, TransactionDrawdown, args)
+}
+
+// runTransaction runs the payment and drawdown commands
+func (c *cli) runTransaction(ctx context.Context, transactionType TransactionType, args []string) error {
+	name := "payment"
+	if transactionType == TransactionDrawdown {
+		name = "drawdown"
+	}
+
+	flags := newCommandFlags(name, "<id> [flags]")
+	date := flags.String("date", "", "effective date (YYYY-MM-DD)")
+	amount := flags.String("amount", "", "amount in the loan currency")
+
+	loan, err := c.readCommandLoan(ctx, flags, args)
+	if err != nil {
+		return err
+	}
+
+	if err := requireFlags(flags, "date", "amount"); err != nil {
+		return err
+	}
+
+	effectiveDate, err := parseDateInput(*date)
+	if err != nil {
+		return err
+	}
+	if err := validateDateWithin(effectiveDate, loan.LoanDetails.StartDate, loan.LoanDetails.MaturityDate()); err != nil {
+		return err
+	}
+
+	value, err := parsePositiveDecimalInput(*amount, loan.LoanDetails.Currency().MinorUnits())
+	if err != nil {
+		return err
+	}
+
+	transaction := Transaction{
+		ID:            randomString(8),
+		Type:          transactionType,
+		EffectiveDate: effectiveDate,
+		Amount:        NewMoney(value, loan.LoanDetails.Currency()),
+	}
+
+	updatedLoan, err := loan.AddTransaction(transaction)
+	if err != nil {
+		return err
+	}
+
+	if err := c.loanRepository.Update(ctx, updatedLoan, c.change("")); err != nil {
+		return err
+	}
+
+	fmt.Println(transaction.ID)
+
+	return nil
+}
+
+// runDelete runs the delete command
+func (c *cli) runDelete(ctx context.Context, args []string) error {
+	flags := newCommandFlags("delete", "<id> [flags]")
+	reason := flags.String("reason", "", "why the loan is being deleted")
+	revision := flags.Int("revision", 0, "only delete the loan if it is still at the revision printed by history, or 0 for the revision just read")
+	positional, err := parseCommandFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if *revision < 0 {
+		return errors.Wrap(ErrInvalidInput, "revision must not be negative")
+	}
+
+	if *revision == 0 {
+		loan, err := c.loanRepository.Read(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+		*revision = loan.Revision
+	}
+
+	return c.loanRepository.Delete(ctx, positional[0], *revision, c.change(*reason))
+}
+
+// runUndelete runs the undelete command
+func (c *cli) runUndelete(ctx context.Context, args []string) error {
+	positional, err := parseCommandFlags(newCommandFlags("undelete", "<id>"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	return c.loanRepository.Undelete(ctx, positional[0])
+}
+
+// runDeleted runs the deleted command
+func (c *cli) runDeleted(ctx context.Context, args []string) error {
+	if _, err := parseCommandFlags(newCommandFlags("deleted", ""), args, 0); err != nil {
+		return err
+	}
+
+	deleted, err := c.loanRepository.Deleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	printDeletedLoans(deleted)
+
+	return nil
+}
+
+// runPurge runs the purge command, printing the ID of each loan purged
+func (c *cli) runPurge(ctx context.Context, args []string) error {
+	if _, err := parseCommandFlags(newCommandFlags("purge", ""), args, 0); err != nil {
+		return err
+	}
+
+	ids, err := c.retention.Purge(ctx, c.loanRepository, time.Now().UTC())
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+
+	return err
+}
+
+// runServe runs the serve command, shutting the server down gracefully on an interrupt or termination signal
+func (c *cli) runServe(ctx context.Context, args []string) error {
+	flags := newCommandFlags("serve", "[flags]")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	if _, err := parseCommandFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Serving the loan API on %s\n", *addr)
+	if err := Serve(ctx, *addr, NewServer(c.loanRepository)); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Server shut down")
+
+	return nil
+}
+
+// historyFlags holds the flags filtering and summarising a loan's history
+type historyFlags struct {
+	from    *string
+	to      *string
+	summary *string
+}
+
+// newHistoryFlags registers the history filter flags on a command's flag set
+func newHistoryFlags(flags *flag.FlagSet) historyFlags {
+	return historyFlags{
+		from:    flags.String("from", "", "first accrual date to include, YYYY-MM-DD"),
+		to:      flags.String("to", "", "last accrual date to include, YYYY-MM-DD"),
+		summary: flags.String("summary", "", "summarise the days per month, quarter or year"),
+	}
+}
+
+// filter parses the history filter flags
+func (f historyFlags) filter() (HistoryFilter, error) {
+	return parseHistoryFilterInput(*f.from, *f.to, *f.summary)
+}
+
+// readCommandLoan parses the flags of a command taking a loan ID and reads the loan
+func (c *cli) readCommandLoan(ctx context.Context, flags *flag.FlagSet, args []string) (Loan, error) {
+	positional, err := parseCommandFlags(flags, args, 1)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	return c.loanRepository.Read(ctx, positional[0])
+}
+
+// newCommandFlags creates the flag set of a command with its usage line
+func newCommandFlags(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: calc %s %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parseCommandFlags parses a command's flags, allowing them before or after its positional arguments, and checks the number of positional arguments
+func parseCommandFlags(flags *flag.FlagSet, args []string, positionalArgs int) ([]string, error) {
+	output := flags.Output()
+	flags.SetOutput(io.Discard)
+	defer flags.SetOutput(output)
+
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				flags.SetOutput(output)
+				flags.Usage()
+				return nil, err
+			}
+			return nil, errors.Wrap(ErrInvalidInput, err.Error())
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) != positionalArgs {
+		return nil, errors.Wrapf(ErrInvalidInput, "%s expects %d argument(s) but got %d", flags.Name(), positionalArgs, len(positional))
+	}
+
+	return positional, nil
+}
+
+// requireFlags validates that each of the named flags was given
+func requireFlags(flags *flag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if !isFlagSet(flags, name) {
+			return errors.Wrapf(ErrInvalidInput, "-%s is required", name)
+		}
+	}
+	return nil
+}
+
+// isFlagSet returns whether the named flag was given
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// loanFlags holds the flags describing loan details, as the flag equivalent of the loan details input form
+type loanFlags struct {
+	loanInput
+	flags *flag.FlagSet
+}
+
+// newLoanFlags registers the loan detail flags, defaulting them to the details of an existing loan when given
+func newLoanFlags(flags *flag.FlagSet, existing *LoanDetails) *loanFlags {
+	f := &loanFlags{flags: flags}
+	input := newLoanInput()
+	defaults := map[string]string{
+		"method":         input.method,
+		"day-count":      input.dayCount,
+		"rounding":       input.roundingMode,
+		"rounding-point": input.roundingPoint,
+		"repayment":      input.repayment,
+		"accrual":        input.accrual,
+	}
+
+	if existing != nil {
+		// options left blank on older loans keep the defaults they were calculated with
+		for name, value := range map[string]string{
+			"start":          existing.StartDate.Format("2006-01-02"),
+			"end":            existing.EndDate.Format("2006-01-02"),
+			"currency":       existing.Currency().String(),
+			"principal":      existing.PrincipalAmount.Amount.String(),
+			"base":           existing.BaseInterestRate.String(),
+			"margin":         existing.Margin.String(),
+			"method":         existing.CalculationMethod.String(),
+			"day-count":      existing.DayCountConvention.String(),
+			"rounding":       existing.RoundingMode.String(),
+			"rounding-point": existing.RoundingPoint.String(),
+			"repayment":      existing.RepaymentProfile.repaymentType().String(),
+			"frequency":      existing.RepaymentProfile.Frequency.String(),
+			"calendar":       existing.Calendar.String(),
+			"business-day":   existing.BusinessDayConvention.String(),
+			"accrual":        existing.AccrualBoundaries.String(),
+		} {
+			if len(value) > 0 {
+				defaults[name] = value
+			}
+		}
+
+		if floatingRate := existing.FloatingRate; floatingRate != nil {
+			defaults["index"] = floatingRate.Index
+			defaults["lookback"] = fmt.Sprint(floatingRate.LookbackDays)
+			f.observationShift = floatingRate.ObservationShift
+			if floatingRate.Floor != nil {
+				defaults["floor"] = floatingRate.Floor.String()
+			}
+			if floatingRate.Cap != nil {
+				defaults["cap"] = floatingRate.Cap.String()
+			}
+		}
+	}
+
+	flags.StringVar(&f.start, "start", defaults["start"], "start date (YYYY-MM-DD)")
+	flags.StringVar(&f.end, "end", defaults["end"], "end date (YYYY-MM-DD)")
+	flags.StringVar(&f.currency, "currency", defaults["currency"], "loan currency")
+	flags.StringVar(&f.principal, "principal", defaults["principal"], "principal amount being loaned")
+	flags.StringVar(&f.base, "base", defaults["base"], "base interest rate percentage")
+	flags.StringVar(&f.margin, "margin", defaults["margin"], "margin percentage")
+	flags.StringVar(&f.fixings, "fixings", "", "CSV file of date,rate base rate fixings, or blank for a fixed rate")
+	flags.StringVar(&f.index, "index", defaults["index"], "reference rate of the fixings, e.g. SONIA, SOFR, EURIBOR")
+	flags.StringVar(&f.lookback, "lookback", defaults["lookback"], "lookback days applied to the fixings")
+	flags.BoolVar(&f.observationShift, "observation-shift", f.observationShift, "apply an observation shift with the lookback")
+	flags.StringVar(&f.floor, "floor", defaults["floor"], "all-in rate floor percentage")
+	flags.StringVar(&f.rateCap, "cap", defaults["cap"], "all-in rate cap percentage")
+	flags.StringVar(&f.method, "method", defaults["method"], "calculation method")
+	flags.StringVar(&f.dayCount, "day-count", defaults["day-count"], "day count convention")
+	flags.StringVar(&f.roundingMode, "rounding", defaults["rounding"], "rounding mode")
+	flags.StringVar(&f.roundingPoint, "rounding-point", defaults["rounding-point"], "rounding point")
+	flags.StringVar(&f.repayment, "repayment", defaults["repayment"], "repayment profile")
+	flags.StringVar(&f.frequency, "frequency", defaults["frequency"], "payment frequency")
+	flags.StringVar(&f.accrual, "accrual", defaults["accrual"], "whether the start date and end date accrue interest: start-inclusive, end-inclusive, both-inclusive or both-exclusive")
+	flags.StringVar(&f.businessDay, "business-day", defaults["business-day"], "business day convention adjusting the end date and payment dates: following, modified-following or preceding")
+	flags.StringVar(&f.calendar, "calendar", defaults["calendar"], "holiday calendar of the business day convention, or blank for weekends only")
+	flags.Func("instalment", "custom instalment as YYYY-MM-DD=principal, repeated for each instalment", func(value string) error {
+		f.instalments = append(f.instalments, value)
+		return nil
+	})
+
+	return f
+}
+
+// loanDetails validates the flags in the same way as the loan details input form, and outputs a LoanDetails struct
+func (f *loanFlags) loanDetails(id string, existing *LoanDetails) (LoanDetails, error) {
+	f.keepFixings = !isFlagSet(f.flags, "fixings")
+	return f.loanInput.loanDetails(id, existing)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRunCommand(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryLoanRepository()
+	c := NewCLI(repo, "tester", RetentionPolicy{})
+
+	create := []string{"create", "-start", "2024-01-01", "-end", "2024-03-01", "-principal", "1000", "-currency", "eur", "-base", "5", "-margin", "1"}
+	if code := c.RunCommand(ctx, create); code != exitOK {
+		t.Fatalf("Unexpected exit code creating loan. got %d, want %d", code, exitOK)
+	}
+
+	loans := listLoans(t, repo)
+	if len(loans) != 1 {
+		t.Fatalf("Expected one loan to be created. got %d", len(loans))
+	}
+	var loan Loan
+	for _, created := range loans {
+		loan = created
+	}
+	if loan.LoanDetails.Currency() != CurrencyEUR || loan.LoanDetails.CalculationMethod != CalculationDailySimple {
+		t.Errorf("Loan was not created from the flags. got %+v", loan.LoanDetails)
+	}
+
+	id := loan.LoanDetails.ID
+	if code := c.RunCommand(ctx, []string{"payment", id, "-date", "2024-01-15", "-amount", "250"}); code != exitOK {
+		t.Errorf("Unexpected exit code adding payment. got %d, want %d", code, exitOK)
+	}
+	if code := c.RunCommand(ctx, []string{"update", id, "-margin", "2", "-reason", "repriced"}); code != exitOK {
+		t.Errorf("Unexpected exit code updating loan. got %d, want %d", code, exitOK)
+	}
+
+	updated, _ := repo.Read(ctx, id)
+	if updated.LoanDetails.Margin.String() != "2" || updated.LoanDetails.PrincipalAmount.Amount.String() != "1000" || len(updated.Transactions) != 1 {
+		t.Errorf("Update did not keep the details and transactions not given as flags. got %+v", updated)
+	}
+
+	versions, _ := repo.Versions(ctx, id)
+	if len(versions) != 2 || versions[1].ChangedBy != "tester" || versions[1].Reason != "repriced" || len(versions[1].Changes) != 1 {
+		t.Errorf("Update did not record a version of the changed margin. got %+v", versions)
+	}
+
+	tests := map[string]struct {
+		args []string
+		code int
+	}{
+		"unknown command":     {args: []string{"bogus"}, code: exitInvalidInput},
+		"missing flags":       {args: []string{"create", "-start", "2024-01-01"}, code: exitInvalidInput},
+		"too many places":     {args: append(create[:len(create):len(create)], "-principal", "1000.001"), code: exitInvalidInput},
+		"unknown flag":        {args: []string{"history", id, "-bogus"}, code: exitInvalidInput},
+		"missing id":          {args: []string{"history"}, code: exitInvalidInput},
+		"unknown loan":        {args: []string{"history", "missing"}, code: exitNotFound},
+		"repayment too large": {args: []string{"payment", id, "-date", "2024-01-20", "-amount", "5000"}, code: exitInvalidInput},
+		"date out of range":   {args: []string{"drawdown", id, "-date", "2025-01-01", "-amount", "1"}, code: exitInvalidInput},
+		"unknown format":      {args: []string{"export", id, "-format", "xml"}, code: exitInvalidInput},
+		"versions":            {args: []string{"versions", id, "-diff", "1,2"}, code: exitOK},
+		"unknown version":     {args: []string{"versions", id, "-as-of", "3"}, code: exitNotFound},
+		"help":                {args: []string{"list", "-h"}, code: exitOK},
+	}
+
+	for name, test := range tests {
+		if code := c.RunCommand(ctx, test.args); code != test.code {
+			t.Errorf("Unexpected exit code for %s. got %d, want %d", name, code, test.code)
+		}
+	}
+
+	if code := c.RunCommand(ctx, []string{"delete", id}); code != exitOK {
+		t.Errorf("Unexpected exit code deleting loan. got %d, want %d", code, exitOK)
+	}
+	if code := c.RunCommand(ctx, []string{"delete", id}); code != exitNotFound {
+		t.Errorf("Unexpected exit code deleting a deleted loan. got %d, want %d", code, exitNotFound)
+	}
+	if code := c.RunCommand(ctx, []string{"purge"}); code != exitInvalidInput {
+		t.Errorf("Unexpected exit code purging without a retention period. got %d, want %d", code, exitInvalidInput)
+	}
+	if code := c.RunCommand(ctx, []string{"undelete", id}); code != exitOK {
+		t.Errorf("Unexpected exit code undeleting loan. got %d, want %d", code, exitOK)
+	}
+	if code := c.RunCommand(ctx, []string{"undelete", id}); code != exitConflict {
+		t.Errorf("Unexpected exit code undeleting a loan that is not deleted. got %d, want %d", code, exitConflict)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := map[error]int{
+		nil:                                      exitOK,
+		ErrLoanDoesNotExists:                     exitNotFound,
+		ErrLoanAlreadyExists:                     exitConflict,
+		ErrVersionDoesNotExist:                   exitNotFound,
+		ErrLoanNotDeleted:                        exitConflict,
+		errors.Wrap(ErrInvalidInput, "bad date"): exitInvalidInput,
+		errors.Wrap(ErrInvalidDecimal, "principal"): exitInvalidInput,
+		errors.New("disk full"):                     exitFailure,
+	}
+
+	for err, want := range tests {
+		if got := exitCode(err); got != want {
+			t.Errorf("Unexpected exit code for %v. got %d, want %d", err, got, want)
+		}
+	}
+}
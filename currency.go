@@ -0,0 +1,131 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	CurrencyCHF = "CHF"
+	CurrencyEUR = "EUR"
+	CurrencyGBP = "GBP"
+	CurrencyJPY = "JPY"
+	CurrencyKWD = "KWD"
+	CurrencySEK = "SEK"
+	CurrencyUSD = "USD"
+)
+
+var (
+	// currencies holds the details of each registered currency by its code
+	currencies = newCurrencyRegistry(iso4217Currencies)
+
+	// defaultCurrencies are the currencies loans can be made in unless others are allowed
+	defaultCurrencies = []Currency{CurrencyEUR, CurrencyGBP, CurrencyUSD}
+
+	// AllowedCurrencies are the currencies loans can be made in, out of every registered currency
+	AllowedCurrencies = slices.Clone(defaultCurrencies)
+)
+
+// CurrencyInfo holds the ISO 4217 details of a currency
+type CurrencyInfo struct {
+	Code       Currency // Code is the 3 letter ISO 4217 currency code
+	Name       string   // Name is the ISO 4217 name of the currency
+	Symbol     string   // Symbol is the symbol amounts are formatted with, or blank to format them with the code
+	MinorUnits int      // MinorUnits is the number of decimal places amounts are held to, e.g. 0 for JPY and 3 for KWD
+}
+
+// Currency holds a 3 letter ISO 4217 currency code
+type Currency string
+
+// String stringifies the currency
+func (c Currency) String() string {
+	return string(c)
+}
+
+// Name returns the ISO 4217 name of the currency, or blank when it is not registered
+func (c Currency) Name() string {
+	return currencies[c].Name
+}
+
+// Symbol returns the corresponding symbol to the currency, or blank when it has no symbol of its own or is not registered
+func (c Currency) Symbol() string {
+	return currencies[c].Symbol
+}
+
+// MinorUnits returns the number of decimal places used by the currency, defaulting to 2 when it is not registered
+func (c Currency) MinorUnits() int {
+	info, ok := currencies[c]
+	if !ok {
+		return 2
+	}
+
+	return info.MinorUnits
+}
+
+// Validate validates whether the ISO 4217 currency is registered and allowed
+func (c Currency) Validate() error {
+	if !c.registered() {
+		return ErrInvalidCurrency
+	}
+	if ok := slices.Contains(AllowedCurrencies, c); !ok {
+		return errors.Wrapf(ErrInvalidCurrency, "%s is not an allowed currency", c)
+	}
+
+	return nil
+}
+
+// registered returns whether the currency is registered, regardless of whether it is allowed
+func (c Currency) registered() bool {
+	_, ok := currencies[c]
+	return ok
+}
+
+// AllowCurrencies allows loans to be made in a comma separated list of registered currency codes, or in every registered
+// currency when the list is "all", leaving the default EUR, GBP and USD allowed when the list is blank
+func AllowCurrencies(list string) error {
+	switch strings.ToLower(strings.TrimSpace(list)) {
+	case "":
+		AllowedCurrencies = slices.Clone(defaultCurrencies)
+		return nil
+	case "all":
+		AllowedCurrencies = registeredCurrencies()
+		return nil
+	}
+
+	var allowed []Currency
+	for _, code := range strings.Split(list, ",") {
+		currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+		if !currency.registered() {
+			return errors.Wrapf(ErrInvalidCurrency, "unknown currency %q", code)
+		}
+		if !slices.Contains(allowed, currency) {
+			allowed = append(allowed, currency)
+		}
+	}
+	AllowedCurrencies = allowed
+
+	return nil
+}
+
+// newCurrencyRegistry creates a registry of the currencies keyed by their code
+func newCurrencyRegistry(infos []CurrencyInfo) map[Currency]CurrencyInfo {
+	registry := make(map[Currency]CurrencyInfo, len(infos))
+	for _, info := range infos {
+		registry[info.Code] = info
+	}
+
+	return registry
+}
+
+// registeredCurrencies returns the codes of every registered currency in alphabetical order
+func registeredCurrencies() []Currency {
+	codes := make([]Currency, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	return codes
+}
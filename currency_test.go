@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCurrencySymbol(t *testing.T) {
+	AllowCurrencies("all")
+	defer AllowCurrencies("")
+
+	currencies := map[Currency]string{
+		CurrencyEUR: "€",
+		CurrencyGBP: "£",
+		CurrencyUSD: "$",
+		CurrencyJPY: "¥",
+		CurrencyCHF: "",
+	}
+
+	for currency, symbol := range currencies {
+		if currency.Symbol() != symbol {
+			t.Errorf("Incorrect currency->symbol mapping for %s. Got %s, want %s", currency, currency.Symbol(), symbol)
+		}
+
+		if err := currency.Validate(); err != nil {
+			t.Errorf("Unexpected error while validating currency %s: %v", currency, err)
+		}
+	}
+
+	invalidCurrency := Currency("someRandomCurrency")
+	err := invalidCurrency.Validate()
+	if err == nil {
+		t.Errorf("Expected error while validating an invalid currency but got none")
+	}
+
+	if symbol := invalidCurrency.Symbol(); symbol != "" {
+		t.Errorf("Expected empty symbol from an invalid currency but got %s", symbol)
+	}
+}
+
+func TestCurrencyMinorUnits(t *testing.T) {
+	currencies := map[Currency]int{
+		CurrencyGBP: 2,
+		CurrencySEK: 2,
+		CurrencyJPY: 0,
+		CurrencyKWD: 3,
+		"XYZ":       2,
+	}
+
+	for currency, want := range currencies {
+		if got := currency.MinorUnits(); got != want {
+			t.Errorf("Unexpected minor units of %s. got %d, want %d", currency, got, want)
+		}
+	}
+
+	loan, err := NewLoan(LoanDetails{
+		StartDate:        NewDate(2024, 1, 1),
+		EndDate:          NewDate(2024, 1, 3),
+		PrincipalAmount:  NewMoney(NewDecimal(1000000, 0), CurrencyJPY),
+		BaseInterestRate: NewDecimal(5, 0),
+		Margin:           NewDecimal(1, 0),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating JPY loan: %v", err)
+	}
+	if got := loan.DailyInterest[1].TotalInterest.String(); got != "¥328" {
+		t.Errorf("Unexpected total interest of JPY loan. got %s, want ¥328", got)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := map[string]Money{
+		"£1000.00":    NewMoney(NewDecimal(100000, 2), CurrencyGBP),
+		"-$12.50":     NewMoney(NewDecimal(-1250, 2), CurrencyUSD),
+		"CHF 1000.00": NewMoney(NewDecimal(100000, 2), CurrencyCHF),
+		"-SEK 5.00":   NewMoney(NewDecimal(-500, 2), CurrencySEK),
+		"KWD 1.234":   NewMoney(NewDecimal(1234, 3), CurrencyKWD),
+	}
+
+	for want, money := range tests {
+		if got := money.String(); got != want {
+			t.Errorf("Unexpected formatted money. got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestAllowCurrencies(t *testing.T) {
+	defer AllowCurrencies("")
+
+	if err := AllowCurrencies("gbp, EUR,GBP"); err != nil {
+		t.Fatalf("Unexpected error restricting currencies: %v", err)
+	}
+	if len(AllowedCurrencies) != 2 {
+		t.Errorf("Unexpected allowed currencies. got %v, want [GBP EUR]", AllowedCurrencies)
+	}
+	if err := Currency(CurrencyGBP).Validate(); err != nil {
+		t.Errorf("Unexpected error validating allowed currency: %v", err)
+	}
+	if err := Currency(CurrencyJPY).Validate(); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("Unexpected error validating a currency that is not allowed. got %v, want %v", err, ErrInvalidCurrency)
+	}
+
+	if err := AllowCurrencies("GBP,XYZ"); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("Unexpected error restricting to an unknown currency. got %v, want %v", err, ErrInvalidCurrency)
+	}
+
+	if err := AllowCurrencies("all"); err != nil {
+		t.Fatalf("Unexpected error allowing every currency: %v", err)
+	}
+	if err := Currency(CurrencyJPY).Validate(); err != nil {
+		t.Errorf("Unexpected error validating currency after allowing every currency: %v", err)
+	}
+
+	if err := AllowCurrencies(""); err != nil {
+		t.Fatalf("Unexpected error resetting the allowed currencies: %v", err)
+	}
+	if !slices.Equal(AllowedCurrencies, []Currency{CurrencyEUR, CurrencyGBP, CurrencyUSD}) {
+		t.Errorf("Expected EUR, GBP and USD to be allowed by default. got %v", AllowedCurrencies)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// dateLayout is the layout dates are parsed, formatted and serialised with
+const dateLayout = "2006-01-02"
+
+// Date holds a calendar date without a time of day or time zone, so stepping and counting days is unaffected by daylight saving.
+// The zero Date is 0001-01-01, the date of the zero time.Time.
+type Date struct {
+	t time.Time // t is always midnight UTC, keeping dates comparable with ==
+}
+
+// NewDate creates a Date, normalising values outside their usual ranges in the same way as time.Date, e.g. 31 April becomes 1 May
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf returns the calendar date of a time in its own location
+func DateOf(t time.Time) Date {
+	return NewDate(t.Date())
+}
+
+// ParseDate parses a date in the format YYYY-MM-DD
+func ParseDate(input string) (Date, error) {
+	t, err := time.Parse(dateLayout, input)
+	if err != nil {
+		return Date{}, err
+	}
+
+	return DateOf(t), nil
+}
+
+// Year returns the year of the date
+func (d Date) Year() int {
+	return d.t.Year()
+}
+
+// Month returns the month of the date
+func (d Date) Month() time.Month {
+	return d.t.Month()
+}
+
+// Day returns the day of the month of the date
+func (d Date) Day() int {
+	return d.t.Day()
+}
+
+// Date returns the year, month and day of the date
+func (d Date) Date() (int, time.Month, int) {
+	return d.t.Date()
+}
+
+// Weekday returns the day of the week the date falls on
+func (d Date) Weekday() time.Weekday {
+	return d.t.Weekday()
+}
+
+// IsZero returns whether the date is unset
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+// Time returns midnight UTC at the start of the date
+func (d Date) Time() time.Time {
+	return d.t
+}
+
+// AddDate returns the date with the years, months and days added, normalised in the same way as time.Time.AddDate
+func (d Date) AddDate(years, months, days int) Date {
+	return Date{t: d.t.AddDate(years, months, days)}
+}
+
+// DaysUntil returns the number of calendar days from the date until another, which is negative when the other date is earlier
+func (d Date) DaysUntil(other Date) int {
+	return int(other.t.Sub(d.t) / (24 * time.Hour))
+}
+
+// Compare compares two dates, returning -1 when the date is before the other, +1 when after and 0 when they are the same
+func (d Date) Compare(other Date) int {
+	return d.t.Compare(other.t)
+}
+
+// Before returns whether the date is before another
+func (d Date) Before(other Date) bool {
+	return d.t.Before(other.t)
+}
+
+// After returns whether the date is after another
+func (d Date) After(other Date) bool {
+	return d.t.After(other.t)
+}
+
+// Equal returns whether two dates are the same
+func (d Date) Equal(other Date) bool {
+	return d == other
+}
+
+// Format formats the date with a time.Time layout
+func (d Date) Format(layout string) string {
+	return d.t.Format(layout)
+}
+
+// String stringifies the date as YYYY-MM-DD
+func (d Date) String() string {
+	return d.Format(dateLayout)
+}
+
+// MarshalJSON serialises the date as a YYYY-MM-DD string
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON deserialises a YYYY-MM-DD string, also accepting the RFC 3339 timestamps dates were serialised as by earlier versions
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+
+	var input string
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+
+	if date, err := ParseDate(input); err == nil {
+		*d = date
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, input)
+	if err != nil {
+		return errors.Errorf("invalid date %q, expected YYYY-MM-DD", input)
+	}
+	*d = DateOf(t)
+
+	return nil
+}
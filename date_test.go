@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDateJSON(t *testing.T) {
+	data, err := json.Marshal(NewDate(2024, time.February, 29))
+	if err != nil || string(data) != `"2024-02-29"` {
+		t.Errorf("Unexpected serialised date. got %s (%v)", data, err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "date", input: `"2024-02-29"`, want: "2024-02-29"},
+		{name: "utc timestamp", input: `"2024-02-29T00:00:00Z"`, want: "2024-02-29"},
+		{name: "offset timestamp", input: `"2024-03-10T00:00:00-05:00"`, want: "2024-03-10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var date Date
+			if err := json.Unmarshal([]byte(tt.input), &date); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if date.String() != tt.want {
+				t.Errorf("Unexpected date. got %s, want %s", date, tt.want)
+			}
+		})
+	}
+
+	var date Date
+	if err := json.Unmarshal([]byte(`"29/02/2024"`), &date); err == nil {
+		t.Errorf("Expected an error for an invalid date")
+	}
+}
+
+func TestDateDaysUntil(t *testing.T) {
+	// a day is 23 hours long when clocks go forward, which must not lose a calendar day
+	newYork := time.FixedZone("EST", -5*60*60)
+	start := DateOf(time.Date(2024, time.March, 9, 23, 0, 0, 0, newYork))
+	end := DateOf(time.Date(2024, time.March, 11, 0, 0, 0, 0, time.FixedZone("EDT", -4*60*60)))
+
+	if days := start.DaysUntil(end); days != 2 {
+		t.Errorf("Unexpected days between %s and %s. got %d, want 2", start, end, days)
+	}
+	if days := end.DaysUntil(start); days != -2 {
+		t.Errorf("Unexpected days back from %s to %s. got %d, want -2", end, start, days)
+	}
+	if next := NewDate(2024, time.January, 31).AddDate(0, 0, 30); next != NewDate(2024, time.March, 1) {
+		t.Errorf("Unexpected date after adding days. got %s", next)
+	}
+}
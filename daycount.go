@@ -0,0 +1,175 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	DayCountACT365F    = "ACT/365F"
+	DayCountACT360     = "ACT/360"
+	DayCountACTACTISDA = "ACT/ACT ISDA"
+	DayCount30360US    = "30/360 US"
+	DayCount30E360     = "30E/360"
+)
+
+var (
+	AllowedDayCountConventions = []DayCountConvention{
+		DayCountACT365F,
+		DayCountACT360,
+		DayCountACTACTISDA,
+		DayCount30360US,
+		DayCount30E360,
+	}
+
+	dayCounters = map[DayCountConvention]DayCounter{
+		DayCountACT365F:    actualFixedDayCounter{daysInYear: 365},
+		DayCountACT360:     actualFixedDayCounter{daysInYear: 360},
+		DayCountACTACTISDA: actualActualISDADayCounter{},
+		DayCount30360US:    thirty360USDayCounter{},
+		DayCount30E360:     thirtyE360DayCounter{},
+	}
+)
+
+// DayCounter counts the days accrued between two dates and the length of the year they accrue against
+type DayCounter interface {
+	// DayCount returns the number of days accrued between start and end
+	DayCount(start, end Date) int
+	// DaysInYear returns the year basis used for a day accruing on the given date
+	DaysInYear(date Date) int
+}
+
+// DayCountConvention holds the name of the day count convention used to accrue interest
+type DayCountConvention string
+
+// String stringifies the day count convention
+func (d DayCountConvention) String() string {
+	return string(d)
+}
+
+// Validate validates whether the day count convention is supported
+func (d DayCountConvention) Validate() error {
+	if _, ok := dayCounters[d]; !ok {
+		return ErrInvalidDayCountConvention
+	}
+
+	return nil
+}
+
+// DayCounter returns the DayCounter for the convention, defaulting to ACT/365F when unset or unknown
+func (d DayCountConvention) DayCounter() DayCounter {
+	if dayCounter, ok := dayCounters[d]; ok {
+		return dayCounter
+	}
+
+	return dayCounters[DayCountACT365F]
+}
+
+// actualFixedDayCounter counts actual days against a fixed length year (ACT/365F, ACT/360)
+type actualFixedDayCounter struct {
+	daysInYear int
+}
+
+// DayCount implements DayCounter
+func (a actualFixedDayCounter) DayCount(start, end Date) int {
+	return daysBetween(start, end)
+}
+
+// DaysInYear implements DayCounter
+func (a actualFixedDayCounter) DaysInYear(date Date) int {
+	return a.daysInYear
+}
+
+// actualActualISDADayCounter counts actual days against the actual length of the year each day falls in
+type actualActualISDADayCounter struct{}
+
+// DayCount implements DayCounter
+func (a actualActualISDADayCounter) DayCount(start, end Date) int {
+	return daysBetween(start, end)
+}
+
+// DaysInYear implements DayCounter
+func (a actualActualISDADayCounter) DaysInYear(date Date) int {
+	if isLeapYear(date.Year()) {
+		return 366
+	}
+	return 365
+}
+
+// thirty360USDayCounter counts days assuming 30 day months, using the US (NASD) end of month rules
+type thirty360USDayCounter struct{}
+
+// DayCount implements DayCounter
+func (t thirty360USDayCounter) DayCount(start, end Date) int {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+
+	if isLastDayOfFebruary(start) && isLastDayOfFebruary(end) {
+		d2 = 30
+	}
+	if isLastDayOfFebruary(start) {
+		d1 = 30
+	}
+	if d2 == 31 && d1 >= 30 {
+		d2 = 30
+	}
+	if d1 == 31 {
+		d1 = 30
+	}
+
+	return thirty360Days(y1, int(m1), d1, y2, int(m2), d2)
+}
+
+// DaysInYear implements DayCounter
+func (t thirty360USDayCounter) DaysInYear(date Date) int {
+	return 360
+}
+
+// thirtyE360DayCounter counts days assuming 30 day months, using the Eurobond end of month rules
+type thirtyE360DayCounter struct{}
+
+// DayCount implements DayCounter
+func (t thirtyE360DayCounter) DayCount(start, end Date) int {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 {
+		d2 = 30
+	}
+
+	return thirty360Days(y1, int(m1), d1, y2, int(m2), d2)
+}
+
+// DaysInYear implements DayCounter
+func (t thirtyE360DayCounter) DaysInYear(date Date) int {
+	return 360
+}
+
+// accrualDays returns the days accrued on a single day and the year basis they accrue against, using the cumulative day count since start so 30/360 totals stay exact
+func accrualDays(dayCounter DayCounter, start, date Date) (int, int) {
+	next := date.AddDate(0, 0, 1)
+	days := dayCounter.DayCount(start, next) - dayCounter.DayCount(start, date)
+	return days, dayCounter.DaysInYear(date)
+}
+
+// thirty360Days returns the number of days between two already adjusted dates assuming 30 day months
+func thirty360Days(y1, m1, d1, y2, m2, d2 int) int {
+	return 360*(y2-y1) + 30*(m2-m1) + (d2 - d1)
+}
+
+// daysBetween returns the number of calendar days between two dates
+func daysBetween(start, end Date) int {
+	return start.DaysUntil(end)
+}
+
+// isLeapYear returns whether the given year is a leap year in the Gregorian calendar
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// isLastDayOfFebruary returns whether the date falls on the last day of February
+func isLastDayOfFebruary(date Date) bool {
+	return date.Month() == time.February && date.AddDate(0, 0, 1).Month() == time.March
+}
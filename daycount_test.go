@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDayCountConventions(t *testing.T) {
+	tests := []struct {
+		convention DayCountConvention
+		start      string
+		end        string
+		days       int
+		daysInYear int
+	}{
+		{DayCountACT365F, "2024-02-28", "2024-03-01", 2, 365},
+		{DayCountACT360, "2024-01-01", "2024-12-31", 365, 360},
+		{DayCountACTACTISDA, "2024-02-28", "2024-03-01", 2, 366},
+		{DayCountACTACTISDA, "2023-02-28", "2023-03-01", 1, 365},
+		{DayCount30360US, "2024-01-31", "2024-03-31", 60, 360},
+		{DayCount30360US, "2023-02-28", "2023-03-31", 30, 360},
+		{DayCount30360US, "2024-02-29", "2025-02-28", 360, 360},
+		{DayCount30E360, "2024-01-31", "2024-03-31", 60, 360},
+		{DayCount30E360, "2024-02-29", "2024-03-31", 31, 360},
+	}
+
+	for _, test := range tests {
+		start, _ := ParseDate(test.start)
+		end, _ := ParseDate(test.end)
+
+		if err := test.convention.Validate(); err != nil {
+			t.Errorf("Unexpected error while validating day count convention %s: %v", test.convention, err)
+		}
+
+		dayCounter := test.convention.DayCounter()
+		if days := dayCounter.DayCount(start, end); days != test.days {
+			t.Errorf("Incorrect day count for %s between %s and %s. got %d, want %d", test.convention, test.start, test.end, days, test.days)
+		}
+		if daysInYear := dayCounter.DaysInYear(start); daysInYear != test.daysInYear {
+			t.Errorf("Incorrect days in year for %s on %s. got %d, want %d", test.convention, test.start, daysInYear, test.daysInYear)
+		}
+	}
+
+	if err := DayCountConvention("ACT/999").Validate(); err == nil {
+		t.Errorf("Expected error while validating an invalid day count convention but got none")
+	}
+}
+
+func TestCalculateDailySimpleInterestDayCount(t *testing.T) {
+	tests := []struct {
+		convention    DayCountConvention
+		start         string
+		end           string
+		totalInterest string
+	}{
+		{DayCountACT365F, "2023-12-30", "2024-01-03", "1.10"},    // 1000 * 10% * 4 / 365
+		{DayCountACT360, "2023-12-30", "2024-01-03", "1.11"},     // 1000 * 10% * 4 / 360
+		{DayCountACTACTISDA, "2023-12-30", "2024-01-03", "1.09"}, // 1000 * 10% * (2 / 365 + 2 / 366)
+		{DayCount30360US, "2024-01-15", "2024-03-15", "16.67"},   // 1000 * 10% * 60 / 360
+		{DayCount30E360, "2024-02-15", "2024-03-31", "12.50"},    // 1000 * 10% * 45 / 360
+	}
+
+	for _, test := range tests {
+		start, _ := ParseDate(test.start)
+		end, _ := ParseDate(test.end)
+
+		dailyInterest := CalculateDailySimpleInterest(LoanDetails{
+			StartDate:          start,
+			EndDate:            end,
+			PrincipalAmount:    NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+			BaseInterestRate:   NewDecimal(9, 0),
+			Margin:             NewDecimal(1, 0),
+			DayCountConvention: test.convention,
+			RoundingPoint:      RoundOnTotal,
+		}, nil)
+
+		if len(dailyInterest) != daysBetween(start, end) {
+			t.Errorf("Unexpected number of daily interest entries for %s. got %d, want %d", test.convention, len(dailyInterest), daysBetween(start, end))
+			continue
+		}
+
+		totalInterest := dailyInterest[len(dailyInterest)-1].TotalInterest.Amount.String()
+		if totalInterest != test.totalInterest {
+			t.Errorf("Unexpected total interest for %s. got %s, want %s", test.convention, totalInterest, test.totalInterest)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+const (
+	RoundHalfEven = "half-even"
+	RoundHalfUp   = "half-up"
+	RoundTruncate = "truncate"
+)
+
+var (
+	AllowedRoundingModes = []RoundingMode{
+		RoundHalfEven,
+		RoundHalfUp,
+		RoundTruncate,
+	}
+)
+
+// RoundingMode holds how a decimal is rounded when digits are dropped
+type RoundingMode string
+
+// String stringifies the rounding mode
+func (r RoundingMode) String() string {
+	return string(r)
+}
+
+// Validate validates whether the rounding mode is supported
+func (r RoundingMode) Validate() error {
+	if ok := slices.Contains(AllowedRoundingModes, r); !ok {
+		return ErrInvalidRoundingMode
+	}
+
+	return nil
+}
+
+// Decimal is an exact fixed-point decimal number made of an unscaled integer and a number of decimal places
+type Decimal struct {
+	value *big.Int // value is the unscaled integer value, where nil represents zero
+	scale int      // scale is the number of digits after the decimal point
+}
+
+// NewDecimal creates a new Decimal representing value * 10^-scale
+func NewDecimal(value int64, scale int) Decimal {
+	return Decimal{value: big.NewInt(value), scale: scale}
+}
+
+// ParseDecimal parses a plain decimal string such as "-1234.56" into a Decimal
+func ParseDecimal(input string) (Decimal, error) {
+	input = strings.TrimSpace(input)
+	digits, fraction, hasPoint := strings.Cut(input, ".")
+
+	negative := false
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		negative = digits[0] == '-'
+		digits = digits[1:]
+	}
+
+	if len(digits)+len(fraction) == 0 || (hasPoint && len(fraction) == 0) {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	for _, r := range digits + fraction {
+		if r < '0' || r > '9' {
+			return Decimal{}, ErrInvalidDecimal
+		}
+	}
+
+	value, ok := new(big.Int).SetString("0"+digits+fraction, 10)
+	if !ok {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	if negative {
+		value.Neg(value)
+	}
+
+	return Decimal{value: value, scale: len(fraction)}, nil
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of the decimal
+func (d Decimal) Sign() int {
+	return d.unscaled().Sign()
+}
+
+// IsZero returns whether the decimal is zero
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares two decimals, returning -1, 0 or 1
+func (d Decimal) Cmp(other Decimal) int {
+	a, b := align(d, other)
+	return a.Cmp(b)
+}
+
+// Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b := align(d, other)
+	return Decimal{value: new(big.Int).Add(a, b), scale: max(d.scale, other.scale)}
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b := align(d, other)
+	return Decimal{value: new(big.Int).Sub(a, b), scale: max(d.scale, other.scale)}
+}
+
+// Mul returns d * other without any loss of precision
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.unscaled(), other.unscaled()), scale: d.scale + other.scale}
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.unscaled()), scale: d.scale}
+}
+
+// Div returns d / other rounded to the given number of decimal places
+func (d Decimal) Div(other Decimal, places int, mode RoundingMode) Decimal {
+	numerator := new(big.Int).Set(d.unscaled())
+	denominator := new(big.Int).Set(other.unscaled())
+
+	if shift := places + other.scale - d.scale; shift >= 0 {
+		numerator.Mul(numerator, pow10(shift))
+	} else {
+		denominator.Mul(denominator, pow10(-shift))
+	}
+
+	return Decimal{value: roundQuotient(numerator, denominator, mode), scale: places}
+}
+
+// Round returns d rounded to the given number of decimal places, padding with zeros when it has fewer
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+	if d.scale <= places {
+		return Decimal{value: new(big.Int).Mul(d.unscaled(), pow10(places-d.scale)), scale: places}
+	}
+
+	return Decimal{value: roundQuotient(d.unscaled(), pow10(d.scale-places), mode), scale: places}
+}
+
+// Float64 returns the nearest float64 to the decimal, for display and non-monetary calculations only
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(d.unscaled(), pow10(d.scale)).Float64()
+	return f
+}
+
+// String stringifies the decimal in plain notation with all of its decimal places
+func (d Decimal) String() string {
+	value := d.unscaled()
+	str := new(big.Int).Abs(value).String()
+
+	if d.scale > 0 {
+		if len(str) <= d.scale {
+			str = strings.Repeat("0", d.scale-len(str)+1) + str
+		}
+		str = str[:len(str)-d.scale] + "." + str[len(str)-d.scale:]
+	}
+
+	if value.Sign() < 0 {
+		str = "-" + str
+	}
+
+	return str
+}
+
+// MarshalJSON implements json.Marshaler, serialising the decimal as a string to avoid precision loss
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting both strings and plain JSON numbers
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var str string
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+	} else {
+		str = string(data)
+	}
+
+	decimal, err := ParseDecimal(str)
+	if err != nil {
+		return err
+	}
+
+	*d = decimal
+	return nil
+}
+
+// unscaled returns the unscaled integer value of the decimal
+func (d Decimal) unscaled() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+	return d.value
+}
+
+// align returns the unscaled values of two decimals brought to a common scale
+func align(a, b Decimal) (*big.Int, *big.Int) {
+	scale := max(a.scale, b.scale)
+	return new(big.Int).Mul(a.unscaled(), pow10(scale-a.scale)), new(big.Int).Mul(b.unscaled(), pow10(scale-b.scale))
+}
+
+// roundQuotient divides numerator by denominator, rounding the result using the given rounding mode
+func roundQuotient(numerator, denominator *big.Int, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 || mode == RoundTruncate {
+		return quotient
+	}
+
+	// compare twice the remainder against the denominator to find which side of the halfway point we are on
+	half := new(big.Int).Abs(remainder)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(new(big.Int).Abs(denominator))
+
+	if cmp > 0 || (cmp == 0 && (mode == RoundHalfUp || quotient.Bit(0) == 1)) {
+		if numerator.Sign()*denominator.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	return quotient
+}
+
+// pow10 returns 10^n as a big.Int
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
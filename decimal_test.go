@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	valid := map[string]string{
+		"0":         "0",
+		"1000":      "1000",
+		"1000.50":   "1000.50",
+		"-0.005":    "-0.005",
+		"+12.3":     "12.3",
+		".25":       "0.25",
+		" 42.00 ":   "42.00",
+		"000123.40": "123.40",
+	}
+
+	for input, want := range valid {
+		decimal, err := ParseDecimal(input)
+		if err != nil {
+			t.Errorf("Unexpected error parsing decimal %q: %v", input, err)
+			continue
+		}
+		if decimal.String() != want {
+			t.Errorf("Incorrect decimal parsed from %q. got %s, want %s", input, decimal, want)
+		}
+	}
+
+	for _, input := range []string{"", "-", "1.", "1.2.3", "1e5", "abc", "12,50"} {
+		if _, err := ParseDecimal(input); err == nil {
+			t.Errorf("Expected error parsing invalid decimal %q but got none", input)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := NewDecimal(10050, 2) // 100.50
+	b := NewDecimal(25, 3)    // 0.025
+
+	if got := a.Add(b).String(); got != "100.525" {
+		t.Errorf("Incorrect addition. got %s, want %s", got, "100.525")
+	}
+	if got := b.Sub(a).String(); got != "-100.475" {
+		t.Errorf("Incorrect subtraction. got %s, want %s", got, "-100.475")
+	}
+	if got := a.Mul(b).String(); got != "2.51250" {
+		t.Errorf("Incorrect multiplication. got %s, want %s", got, "2.51250")
+	}
+	if got := a.Div(NewDecimal(3, 0), 4, RoundHalfEven).String(); got != "33.5000" {
+		t.Errorf("Incorrect division. got %s, want %s", got, "33.5000")
+	}
+	if got := NewDecimal(1, 0).Div(NewDecimal(3, 0), 10, RoundHalfEven).String(); got != "0.3333333333" {
+		t.Errorf("Incorrect division. got %s, want %s", got, "0.3333333333")
+	}
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(NewDecimal(1005, 1)) != 0 {
+		t.Errorf("Incorrect comparison between %s and %s", a, b)
+	}
+
+	var zero Decimal
+	if !zero.IsZero() || zero.String() != "0" || zero.Add(a).Cmp(a) != 0 {
+		t.Errorf("Zero value decimal does not behave as zero")
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		input string
+		mode  RoundingMode
+		want  string
+	}{
+		{"2.345", RoundHalfEven, "2.34"},
+		{"2.355", RoundHalfEven, "2.36"},
+		{"2.3451", RoundHalfEven, "2.35"},
+		{"-2.345", RoundHalfEven, "-2.34"},
+		{"2.345", RoundHalfUp, "2.35"},
+		{"-2.345", RoundHalfUp, "-2.35"},
+		{"2.344", RoundHalfUp, "2.34"},
+		{"2.349", RoundTruncate, "2.34"},
+		{"-2.349", RoundTruncate, "-2.34"},
+		{"2.3", RoundHalfEven, "2.30"},
+	}
+
+	for _, test := range tests {
+		decimal, _ := ParseDecimal(test.input)
+		if got := decimal.Round(2, test.mode).String(); got != test.want {
+			t.Errorf("Incorrect rounding of %s using %s. got %s, want %s", test.input, test.mode, got, test.want)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	money := NewMoney(NewDecimal(123456789012345678, 2), CurrencyGBP)
+
+	data, err := json.Marshal(money)
+	if err != nil {
+		t.Errorf("Unexpected error marshalling money: %v", err)
+	}
+	if string(data) != `{"amount":"1234567890123456.78","currency":"GBP"}` {
+		t.Errorf("Unexpected JSON for money. got %s", data)
+	}
+
+	var unmarshalled Money
+	if err := json.Unmarshal(data, &unmarshalled); err != nil {
+		t.Errorf("Unexpected error unmarshalling money: %v", err)
+	}
+	if unmarshalled.Amount.Cmp(money.Amount) != 0 || unmarshalled.Currency != money.Currency {
+		t.Errorf("Money did not survive a JSON round trip. got %v, want %v", unmarshalled, money)
+	}
+
+	var number Decimal
+	if err := json.Unmarshal([]byte(`12.5`), &number); err != nil || number.String() != "12.5" {
+		t.Errorf("Expected JSON numbers to unmarshal into decimals. got %s, err %v", number, err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LoanDeletion holds when a loan was deleted, by whom and why
+type LoanDeletion struct {
+	DeletedAt time.Time `json:"deleted_at"`       // DeletedAt is when the loan was deleted
+	DeletedBy string    `json:"deleted_by"`       // DeletedBy is the user who deleted the loan
+	Reason    string    `json:"reason,omitempty"` // Reason is why the loan was deleted
+}
+
+// DeletedLoan is a loan that has been deleted but can still be undeleted until it is purged
+type DeletedLoan struct {
+	Loan Loan `json:"loan"` // Loan is the loan as it was when it was deleted
+	LoanDeletion
+}
+
+// RetentionPolicy decides how long deleted loans are kept before they are purged
+type RetentionPolicy struct {
+	Days int // Days is the number of days a deleted loan is kept, where 0 keeps deleted loans forever
+}
+
+// Expired returns whether a deleted loan has been kept for longer than the retention period
+func (r RetentionPolicy) Expired(deleted DeletedLoan, now time.Time) bool {
+	return r.Days > 0 && deleted.DeletedAt.Before(now.AddDate(0, 0, -r.Days))
+}
+
+// Purge permanently removes every deleted loan kept for longer than the retention period, returning their IDs
+func (r RetentionPolicy) Purge(ctx context.Context, loanRepository LoanRepository, now time.Time) ([]string, error) {
+	if r.Days <= 0 {
+		return nil, errors.Wrap(ErrInvalidInput, "no retention period is configured, so deleted loans are kept forever")
+	}
+
+	deletedLoans, err := loanRepository.Deleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []string
+	for _, deleted := range deletedLoans {
+		if !r.Expired(deleted, now) {
+			continue
+		}
+
+		if err := loanRepository.Purge(ctx, deleted.Loan.LoanDetails.ID); err != nil {
+			return purged, errors.Wrapf(err, "loan %s", deleted.Loan.LoanDetails.ID)
+		}
+		purged = append(purged, deleted.Loan.LoanDetails.ID)
+	}
+
+	return purged, nil
+}
+
+// newLoanDeletion returns the deletion of a loan by a change made now
+func newLoanDeletion(change LoanChange, now time.Time) LoanDeletion {
+	return LoanDeletion{DeletedAt: now, DeletedBy: change.ChangedBy, Reason: change.Reason}
+}
+
+// discardLoan deletes and purges a loan at any revision, undoing its creation by a bulk write that failed
+func discardLoan(ctx context.Context, loanRepository LoanRepository, id string, change LoanChange) error {
+	if err := loanRepository.Delete(ctx, id, 0, change); err != nil {
+		return err
+	}
+
+	return loanRepository.Purge(ctx, id)
+}
+
+// sortDeletedLoans sorts deleted loans by ID
+func sortDeletedLoans(deleted []DeletedLoan) []DeletedLoan {
+	slices.SortFunc(deleted, func(a, b DeletedLoan) int {
+		return strings.Compare(a.Loan.LoanDetails.ID, b.Loan.LoanDetails.ID)
+	})
+
+	return deleted
+}
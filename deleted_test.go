@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicyPurge(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryLoanRepository()
+	for _, id := range []string{"1", "2", "3"} {
+		if err := repo.Create(ctx, Loan{LoanDetails: LoanDetails{ID: id}}, LoanChange{}); err != nil {
+			t.Fatalf("Unexpected error in Create: %v", err)
+		}
+	}
+	repo.Delete(ctx, "1", 0, LoanChange{ChangedBy: "tester", Reason: "duplicate"})
+	repo.Delete(ctx, "2", 0, LoanChange{ChangedBy: "tester"})
+
+	// loan 1 was deleted long enough ago to be purged, whereas loan 2 was deleted today
+	store := repo.store.(*inMemoryLoanStore)
+	store.deleted["1"] = DeletedLoan{Loan: store.deleted["1"].Loan, LoanDeletion: LoanDeletion{DeletedAt: time.Now().UTC().AddDate(0, 0, -31)}}
+
+	if _, err := (RetentionPolicy{}).Purge(ctx, repo, time.Now().UTC()); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput purging without a retention period, got %v", err)
+	}
+
+	purged, err := RetentionPolicy{Days: 30}.Purge(ctx, repo, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("Unexpected error in Purge: %v", err)
+	}
+	if len(purged) != 1 || purged[0] != "1" {
+		t.Errorf("Purge removed the wrong loans. got %v, want [1]", purged)
+	}
+
+	deleted, err := repo.Deleted(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error in Deleted: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Loan.LoanDetails.ID != "2" || deleted[0].DeletedBy != "tester" {
+		t.Errorf("Expected loan 2 to be kept until the retention period has passed. got %+v", deleted)
+	}
+	if err := repo.Undelete(ctx, "1"); !errors.Is(err, ErrLoanDoesNotExists) {
+		t.Errorf("Expected ErrLoanDoesNotExists undeleting a purged loan, got %v", err)
+	}
+	if err := repo.Create(ctx, Loan{LoanDetails: LoanDetails{ID: "1"}}, LoanChange{}); err != nil {
+		t.Errorf("Expected the ID of a purged loan to be reusable: %v", err)
+	}
+}
@@ -3,9 +3,74 @@ package main
 import "errors"
 
 var (
-	ErrInvalidCurrency      = errors.New("invalid currency")
-	ErrLoanAlreadyExists    = errors.New("loan already exists")
-	ErrLoanDoesNotExists    = errors.New("loan does not exists")
-	ErrInvalidInput         = errors.New("invalid input")
-	ErrInvalidDecimalPlaces = errors.New("invalid decimal places")
+	ErrInvalidCurrency              = errors.New("invalid currency")
+	ErrLoanAlreadyExists            = errors.New("loan already exists")
+	ErrLoanDoesNotExists            = errors.New("loan does not exists")
+	ErrInvalidInput                 = errors.New("invalid input")
+	ErrInvalidDecimalPlaces         = errors.New("invalid decimal places")
+	ErrInvalidDayCountConvention    = errors.New("invalid day count convention")
+	ErrInvalidDecimal               = errors.New("invalid decimal")
+	ErrInvalidRoundingMode          = errors.New("invalid rounding mode")
+	ErrInvalidRoundingPoint         = errors.New("invalid rounding point")
+	ErrInvalidTransactionType       = errors.New("invalid transaction type")
+	ErrRepaymentExceedsBalance      = errors.New("repayment exceeds outstanding balance")
+	ErrInvalidRateFixing            = errors.New("invalid rate fixing")
+	ErrInvalidCalculationMethod     = errors.New("invalid calculation method")
+	ErrInvalidRepaymentProfile      = errors.New("invalid repayment profile")
+	ErrInvalidPaymentFrequency      = errors.New("invalid payment frequency")
+	ErrCorruptJournal               = errors.New("corrupt loan journal")
+	ErrInvalidStorage               = errors.New("invalid storage backend")
+	ErrInvalidExportFormat          = errors.New("invalid export format")
+	ErrInvalidImportFormat          = errors.New("invalid import format")
+	ErrInvalidImportMode            = errors.New("invalid import mode")
+	ErrExportMismatch               = errors.New("exported loan does not match its recalculation")
+	ErrInvalidSummaryPeriod         = errors.New("invalid summary period")
+	ErrInvalidCalendar              = errors.New("invalid holiday calendar")
+	ErrInvalidBusinessDayConvention = errors.New("invalid business day convention")
+	ErrInvalidAccrualBoundaries     = errors.New("invalid accrual boundaries")
+	ErrInvalidFXRate                = errors.New("invalid fx rate")
+	ErrMissingFXRate                = errors.New("missing fx rate")
+	ErrVersionDoesNotExist          = errors.New("loan version does not exist")
+	ErrLoanNotDeleted               = errors.New("loan is not deleted")
+	ErrInvalidLoanSort              = errors.New("invalid loan sort")
+	ErrLoanConflict                 = errors.New("loan has been changed since it was read")
 )
+
+// validationErrors are the errors caused by invalid input rather than a failure of the calculator
+var validationErrors = []error{
+	ErrInvalidCurrency,
+	ErrInvalidInput,
+	ErrInvalidDecimalPlaces,
+	ErrInvalidDayCountConvention,
+	ErrInvalidDecimal,
+	ErrInvalidRoundingMode,
+	ErrInvalidRoundingPoint,
+	ErrInvalidTransactionType,
+	ErrRepaymentExceedsBalance,
+	ErrInvalidRateFixing,
+	ErrInvalidCalculationMethod,
+	ErrInvalidRepaymentProfile,
+	ErrInvalidPaymentFrequency,
+	ErrInvalidStorage,
+	ErrInvalidExportFormat,
+	ErrInvalidImportFormat,
+	ErrInvalidImportMode,
+	ErrExportMismatch,
+	ErrInvalidSummaryPeriod,
+	ErrInvalidCalendar,
+	ErrInvalidBusinessDayConvention,
+	ErrInvalidAccrualBoundaries,
+	ErrInvalidFXRate,
+	ErrMissingFXRate,
+	ErrInvalidLoanSort,
+}
+
+// isValidationError returns whether an error was caused by invalid input
+func isValidationError(err error) bool {
+	for _, validationErr := range validationErrors {
+		if errors.Is(err, validationErr) {
+			return true
+		}
+	}
+	return false
+}
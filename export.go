@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ExportJSON = "json"
+	ExportCSV  = "csv"
+)
+
+var AllowedExportFormats = []ExportFormat{
+	ExportJSON,
+	ExportCSV,
+}
+
+// ExportFormat holds the file format a loan is exported as
+type ExportFormat string
+
+// String stringifies the export format
+func (e ExportFormat) String() string {
+	return string(e)
+}
+
+// Validate validates whether the export format is supported
+func (e ExportFormat) Validate() error {
+	if ok := slices.Contains(AllowedExportFormats, e); !ok {
+		return ErrInvalidExportFormat
+	}
+
+	return nil
+}
+
+// CSVOptions configures how a loan is exported as CSV
+type CSVOptions struct {
+	Delimiter rune          // Delimiter separates the fields of each row, defaulting to a comma
+	Precision int           // Precision is the number of decimal places amounts are written with, rounded using the loan's rounding mode
+	Filter    HistoryFilter // Filter limits the days written, writing one row per period instead of per day when it has a summary period
+}
+
+// loanHistoryExport is the JSON export of a loan with its history filtered, and summarised when a summary period is given
+type loanHistoryExport struct {
+	Loan
+	Summary []InterestSummary `json:"summary,omitempty"` // Summary holds the interest accrued per period, replacing the daily interest
+}
+
+// WriteLoanJSON writes a loan as indented JSON
+func WriteLoanJSON(w io.Writer, loan Loan) error {
+	return WriteLoanHistoryJSON(w, loan, HistoryFilter{})
+}
+
+// WriteLoanHistoryJSON writes a loan as indented JSON with its daily interest limited by the filter.
+// Only exports of the full daily interest can be restored.
+func WriteLoanHistoryJSON(w io.Writer, loan Loan, filter HistoryFilter) error {
+	export := loanHistoryExport{Loan: loan}
+	export.DailyInterest = filter.Apply(loan.DailyInterest)
+	if filter.Summary != "" {
+		export.Summary = SummariseInterest(export.DailyInterest, filter.Summary)
+		export.DailyInterest = []Interest{}
+	}
+
+	data, err := json.MarshalIndent(export, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteLoansJSON writes loans, or a backup of loans, as an indented JSON array, which can be restored with RestoreLoans
+func WriteLoansJSON[L Loan | BackupLoan](w io.Writer, loans []L) error {
+	data, err := json.MarshalIndent(loans, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteLoanCSV writes a header block of the loan details followed by one row per day of accrued interest, or per period when summarised
+func WriteLoanCSV(w io.Writer, loan Loan, options CSVOptions) error {
+	writer := csv.NewWriter(w)
+	if options.Delimiter != 0 {
+		writer.Comma = options.Delimiter
+	}
+
+	details := loan.LoanDetails
+	amount := func(money Money) string {
+		return money.Amount.Round(options.Precision, details.roundingMode()).String()
+	}
+
+	header := [][]string{
+		{"Loan ID", details.ID},
+		{"Start Date", details.StartDate.Format("2006-01-02")},
+		{"End Date", details.EndDate.Format("2006-01-02")},
+		{"Loan Currency", details.Currency().String()},
+		{"Loan Amount", amount(details.PrincipalAmount)},
+		{"Base Interest Rate", details.BaseInterestRate.String()},
+		{"Margin", details.Margin.String()},
+	}
+	if floatingRate := details.FloatingRate; floatingRate != nil {
+		header = append(header, []string{"Reference Rate", floatingRate.Index})
+	}
+	header = append(header,
+		[]string{"Calculation Method", details.CalculationMethod.String()},
+		[]string{"Repayment Profile", details.RepaymentProfile.repaymentType().String()},
+		[]string{"Payment Frequency", details.RepaymentProfile.Frequency.String()},
+		[]string{"Day Count Convention", details.DayCountConvention.String()},
+		[]string{"Rounding Mode", details.RoundingMode.String()},
+		[]string{"Rounding Point", details.RoundingPoint.String()},
+		[]string{"Accrual Boundaries", details.AccrualBoundaries.String()},
+	)
+	if businessDay := details.BusinessDayConvention; businessDay != "" {
+		header = append(header,
+			[]string{"Business Day Convention", businessDay.String()},
+			[]string{"Holiday Calendar", calendarName(details.Calendar)},
+			[]string{"Maturity Date", details.MaturityDate().Format("2006-01-02")},
+		)
+	}
+	header = append(header, []string{})
+
+	dailyInterest := options.Filter.Apply(loan.DailyInterest)
+	if options.Filter.Summary != "" {
+		header = append(header, []string{
+			"Period",
+			"First Accrual Date",
+			"Last Accrual Date",
+			"Days",
+			"Interest Accrued",
+			"Total Interest",
+		})
+		if err := writer.WriteAll(header); err != nil {
+			return err
+		}
+
+		for _, summary := range SummariseInterest(dailyInterest, options.Filter.Summary) {
+			writer.Write([]string{
+				summary.Period,
+				summary.FirstDate.Format("2006-01-02"),
+				summary.LastDate.Format("2006-01-02"),
+				strconv.Itoa(summary.Days),
+				amount(summary.InterestAccrued),
+				amount(summary.TotalInterest),
+			})
+		}
+
+		writer.Flush()
+		return writer.Error()
+	}
+
+	header = append(header,
+		[]string{
+			"Accrual Date",
+			"Days Elapsed",
+			"Balance",
+			"Base Interest Rate",
+			"Interest Rate",
+			"Daily Interest Amount without Margin",
+			"Daily Interest Amount Accrued",
+			"Total Interest",
+		},
+	)
+
+	if err := writer.WriteAll(header); err != nil {
+		return err
+	}
+
+	for _, interest := range dailyInterest {
+		writer.Write([]string{
+			interest.AccrualDate.Format("2006-01-02"),
+			strconv.Itoa(interest.DaysElapsed),
+			amount(interest.Balance),
+			interest.BaseInterestRate.String(),
+			interest.InterestRate.String(),
+			amount(interest.DailyInterestWithoutMargin),
+			amount(interest.DailyInterestAccrued),
+			amount(interest.TotalInterest),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeToPath calls write with a temporary file renamed into place at path once written, so a failed write leaves any existing
+// file untouched, or with stdout when path is blank
+func writeToPath(path string, write func(w io.Writer) error) error {
+	if len(path) == 0 {
+		return write(os.Stdout)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// parseDelimiterInput parses a CSV delimiter input of a single character or "tab", returning a comma when blank
+func parseDelimiterInput(input string) (rune, error) {
+	switch input {
+	case "":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	delimiter, size := utf8.DecodeRuneInString(input)
+	if size != len(input) || delimiter == utf8.RuneError || delimiter == '"' || delimiter == '\r' || delimiter == '\n' {
+		return 0, errors.Wrapf(ErrInvalidInput, "delimiter %q should be a single character other than a quote or new line", input)
+	}
+
+	return delimiter, nil
+}
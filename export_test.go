@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteLoanCSV(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-01-11")
+
+	loan, err := NewLoan(LoanDetails{
+		ID:               "loan1",
+		StartDate:        startDate,
+		EndDate:          endDate,
+		PrincipalAmount:  NewMoney(NewDecimal(36500, 0), CurrencyEUR),
+		BaseInterestRate: NewDecimal(5, 0),
+		Margin:           NewDecimal(1, 0),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLoanCSV(&buf, loan, CSVOptions{Delimiter: ';', Precision: 4}); err != nil {
+		t.Fatalf("Unexpected error writing CSV: %v", err)
+	}
+
+	reader := csv.NewReader(&buf)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV back: %v", err)
+	}
+
+	header := map[string]string{}
+	var rows [][]string
+	for i, record := range records {
+		if record[0] == "Accrual Date" {
+			rows = records[i+1:]
+			break
+		}
+		header[record[0]] = record[1]
+	}
+
+	if header["Loan ID"] != "loan1" || header["Loan Amount"] != "36500.0000" || header["Loan Currency"] != "EUR" {
+		t.Errorf("Unexpected loan details header. got %v", header)
+	}
+	if len(rows) != len(loan.DailyInterest) {
+		t.Fatalf("Unexpected number of interest rows. got %d, want %d", len(rows), len(loan.DailyInterest))
+	}
+
+	// 36500 * 6% / 365 = 6 per day
+	last := rows[len(rows)-1]
+	if last[0] != "2024-01-10" || last[1] != "10" || last[6] != "6.0000" || last[7] != "60.0000" {
+		t.Errorf("Unexpected final interest row. got %v", last)
+	}
+
+	closed, writer := io.Pipe()
+	closed.Close()
+	if err := WriteLoanCSV(writer, loan, CSVOptions{}); !errors.Is(err, io.ErrClosedPipe) || errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected a write failure to be returned as it is. got %v", err)
+	}
+}
+
+func TestWriteLoanJSONToPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loan.json")
+	loan := Loan{LoanDetails: LoanDetails{ID: "loan1"}}
+
+	if err := writeToPath(path, func(w io.Writer) error { return WriteLoanJSON(w, loan) }); err != nil {
+		t.Fatalf("Unexpected error exporting to file: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reading export: %v", err)
+	}
+	if !strings.Contains(string(data), `"id": "loan1"`) {
+		t.Errorf("Export was not written to the file. got %s", data)
+	}
+
+	failure := errors.New("disk full")
+	if err := writeToPath(path, func(w io.Writer) error { return failure }); !errors.Is(err, failure) {
+		t.Errorf("Expected the write error exporting to file. got %v", err)
+	}
+	if unchanged, _ := os.ReadFile(path); string(unchanged) != string(data) {
+		t.Errorf("Expected a failed export to leave the existing file untouched. got %s", unchanged)
+	}
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) != 0 {
+		t.Errorf("Expected a failed export to remove its temporary file. got %v", matches)
+	}
+}
+
+func TestParseDelimiterInput(t *testing.T) {
+	valid := map[string]rune{"": ',', ";": ';', "tab": '\t', "|": '|'}
+	for input, want := range valid {
+		if got, err := parseDelimiterInput(input); err != nil || got != want {
+			t.Errorf("Unexpected delimiter for %q. got %q (%v), want %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{";;", `"`, "\n"} {
+		if _, err := parseDelimiterInput(input); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for delimiter %q. got %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// fxPrecision is the number of decimal places an inverted FX rate is held to
+const fxPrecision = 10
+
+// FXRate holds the rate one unit of a base currency converts to a quote currency at from a date
+type FXRate struct {
+	EffectiveDate Date     `json:"effective_date"` // EffectiveDate is the date the rate applies from, until the next rate of the currency pair
+	Base          Currency `json:"base"`           // Base is the currency converted from
+	Quote         Currency `json:"quote"`          // Quote is the currency converted to
+	Rate          Decimal  `json:"rate"`           // Rate is the amount of the quote currency one unit of the base currency converts to
+}
+
+// currencyPair holds the base and quote currency of an FX rate
+type currencyPair struct {
+	base, quote Currency
+}
+
+// FXRates holds FX rates by currency pair, each in date order
+type FXRates struct {
+	pairs map[currencyPair][]FXRate
+}
+
+// NewFXRates creates FXRates from rates in any order
+func NewFXRates(rates []FXRate) *FXRates {
+	f := &FXRates{pairs: map[currencyPair][]FXRate{}}
+	for _, rate := range rates {
+		pair := currencyPair{rate.Base, rate.Quote}
+		f.pairs[pair] = append(f.pairs[pair], rate)
+	}
+
+	for _, pairRates := range f.pairs {
+		slices.SortStableFunc(pairRates, func(a, b FXRate) int {
+			return a.EffectiveDate.Compare(b.EffectiveDate)
+		})
+	}
+
+	return f
+}
+
+// Rate returns the rate converting one currency to another on a date, using the latest rate of the pair on or before the date,
+// or inverting the latest rate of the opposite pair when only that is given
+func (f *FXRates) Rate(from, to Currency, date Date) (Decimal, error) {
+	if from == to {
+		return NewDecimal(1, 0), nil
+	}
+
+	if rate, ok := f.rateOn(currencyPair{from, to}, date); ok {
+		return rate, nil
+	}
+	if rate, ok := f.rateOn(currencyPair{to, from}, date); ok {
+		return NewDecimal(1, 0).Div(rate, fxPrecision, RoundHalfEven), nil
+	}
+
+	return Decimal{}, errors.Wrapf(ErrMissingFXRate, "no %s/%s rate on or before %s", from, to, date)
+}
+
+// rateOn returns the latest rate of a currency pair on or before a date
+func (f *FXRates) rateOn(pair currencyPair, date Date) (Decimal, bool) {
+	var (
+		rate  Decimal
+		found bool
+	)
+	for _, fxRate := range f.pairs[pair] {
+		if fxRate.EffectiveDate.After(date) {
+			break
+		}
+		rate, found = fxRate.Rate, true
+	}
+
+	return rate, found
+}
+
+// LoadFXRatesFile loads FX rates from a local CSV file
+func LoadFXRatesFile(path string) (*FXRates, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadFXRatesCSV(file)
+}
+
+// LoadFXRatesCSV loads FX rates from CSV rows of date (YYYY-MM-DD), base currency, quote currency and rate, with an optional header
+func LoadFXRatesCSV(r io.Reader) (*FXRates, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []FXRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidFXRate, "line %d: %v", line, err)
+		}
+
+		date, err := ParseDate(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, errors.Wrapf(ErrInvalidFXRate, "line %d: invalid date %q", line, record[0])
+		}
+
+		base := Currency(strings.ToUpper(strings.TrimSpace(record[1])))
+		quote := Currency(strings.ToUpper(strings.TrimSpace(record[2])))
+		if !base.registered() || !quote.registered() {
+			return nil, errors.Wrapf(ErrInvalidFXRate, "line %d: unknown currency pair %s/%s", line, record[1], record[2])
+		}
+
+		rate, err := ParseDecimal(strings.TrimSpace(record[3]))
+		if err != nil || rate.Sign() <= 0 {
+			return nil, errors.Wrapf(ErrInvalidFXRate, "line %d: invalid rate %q", line, record[3])
+		}
+
+		rates = append(rates, FXRate{EffectiveDate: date, Base: base, Quote: quote, Rate: rate})
+	}
+
+	return NewFXRates(rates), nil
+}
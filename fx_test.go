@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadFXRatesCSV(t *testing.T) {
+	rates, err := LoadFXRatesCSV(strings.NewReader("date,base,quote,rate\n2024-06-01,USD,GBP,0.78\n2024-01-01,usd,gbp,0.79\n2024-01-01,GBP,JPY,200\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error loading FX rates: %v", err)
+	}
+
+	tests := []struct {
+		from, to Currency
+		date     string
+		want     string
+	}{
+		{from: CurrencyUSD, to: CurrencyGBP, date: "2024-01-01", want: "0.79"},
+		{from: CurrencyUSD, to: CurrencyGBP, date: "2024-05-31", want: "0.79"},
+		{from: CurrencyUSD, to: CurrencyGBP, date: "2024-06-01", want: "0.78"},
+		{from: CurrencyJPY, to: CurrencyGBP, date: "2024-06-01", want: "0.0050000000"},
+		{from: CurrencyEUR, to: CurrencyEUR, date: "2023-01-01", want: "1"},
+	}
+
+	for _, test := range tests {
+		date, _ := ParseDate(test.date)
+		rate, err := rates.Rate(test.from, test.to, date)
+		if err != nil {
+			t.Errorf("Unexpected error getting %s/%s rate on %s: %v", test.from, test.to, test.date, err)
+			continue
+		}
+		if got := rate.String(); got != test.want {
+			t.Errorf("Unexpected %s/%s rate on %s. got %s, want %s", test.from, test.to, test.date, got, test.want)
+		}
+	}
+
+	missing := []struct {
+		from, to Currency
+		date     string
+	}{
+		{from: CurrencyUSD, to: CurrencyGBP, date: "2023-12-31"},
+		{from: CurrencyUSD, to: CurrencyEUR, date: "2024-06-01"},
+	}
+	for _, test := range missing {
+		date, _ := ParseDate(test.date)
+		if _, err := rates.Rate(test.from, test.to, date); !errors.Is(err, ErrMissingFXRate) {
+			t.Errorf("Unexpected error getting %s/%s rate on %s. got %v, want %v", test.from, test.to, test.date, err, ErrMissingFXRate)
+		}
+	}
+
+	invalid := []string{
+		"2024-01-01,USD,GBP\n",
+		"2024-01-01,USD,XYZ,0.79\n",
+		"2024-01-01,USD,GBP,-0.79\n",
+		"2024-01-01,USD,GBP,0.79\n01/02/2024,USD,GBP,0.79\n",
+	}
+	for _, input := range invalid {
+		if _, err := LoadFXRatesCSV(strings.NewReader(input)); !errors.Is(err, ErrInvalidFXRate) {
+			t.Errorf("Unexpected error loading FX rates %q. got %v, want %v", input, err, ErrInvalidFXRate)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	SummaryMonthly   = "month"
+	SummaryQuarterly = "quarter"
+	SummaryYearly    = "year"
+)
+
+var AllowedSummaryPeriods = []SummaryPeriod{
+	SummaryMonthly,
+	SummaryQuarterly,
+	SummaryYearly,
+}
+
+// SummaryPeriod holds the period daily interest is summarised over
+type SummaryPeriod string
+
+// String stringifies the summary period
+func (s SummaryPeriod) String() string {
+	return string(s)
+}
+
+// Validate validates whether the summary period is supported
+func (s SummaryPeriod) Validate() error {
+	if ok := slices.Contains(AllowedSummaryPeriods, s); !ok {
+		return ErrInvalidSummaryPeriod
+	}
+
+	return nil
+}
+
+// label returns the name of the period a date falls in, e.g. 2024-01, 2024-Q1 or 2024
+func (s SummaryPeriod) label(date Date) string {
+	switch s {
+	case SummaryQuarterly:
+		return fmt.Sprintf("%d-Q%d", date.Year(), (int(date.Month())+2)/3)
+	case SummaryYearly:
+		return fmt.Sprintf("%d", date.Year())
+	default:
+		return date.Format("2006-01")
+	}
+}
+
+// HistoryFilter selects the part of a loan's daily interest history to show
+type HistoryFilter struct {
+	From    Date          // From is the first accrual date shown, or the zero date to start from the beginning of the loan
+	To      Date          // To is the last accrual date shown, or the zero date to run to the end of the loan
+	Summary SummaryPeriod // Summary is the period days are summarised over, or blank to show every day
+}
+
+// Validate validates the date window and summary period of the filter
+func (f HistoryFilter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return errors.Wrap(ErrInvalidInput, "to needs to be on or after from")
+	}
+	if f.Summary != "" {
+		return f.Summary.Validate()
+	}
+
+	return nil
+}
+
+// Apply returns the days of interest accrued within the filter's date window
+func (f HistoryFilter) Apply(interest []Interest) []Interest {
+	filtered := []Interest{}
+	for _, day := range interest {
+		if (!f.From.IsZero() && day.AccrualDate.Before(f.From)) || (!f.To.IsZero() && day.AccrualDate.After(f.To)) {
+			continue
+		}
+		filtered = append(filtered, day)
+	}
+
+	return filtered
+}
+
+// InterestSummary holds the interest accrued over a month, quarter or year
+type InterestSummary struct {
+	Period          string `json:"period"`           // Period names the month, quarter or year, e.g. 2024-01, 2024-Q1 or 2024
+	FirstDate       Date   `json:"first_date"`       // FirstDate is the first accrual date summarised in the period
+	LastDate        Date   `json:"last_date"`        // LastDate is the last accrual date summarised in the period
+	Days            int    `json:"days"`             // Days is the number of days summarised
+	InterestAccrued Money  `json:"interest_accrued"` // InterestAccrued is the sum of the daily interest accrued over the days
+	TotalInterest   Money  `json:"total_interest"`   // TotalInterest is the running total of interest at the end of the period
+}
+
+// SummariseInterest groups days of interest into consecutive periods, summing the daily interest and keeping the closing running total
+func SummariseInterest(interest []Interest, period SummaryPeriod) []InterestSummary {
+	summaries := []InterestSummary{}
+	for _, day := range interest {
+		label := period.label(day.AccrualDate)
+
+		if len(summaries) == 0 || summaries[len(summaries)-1].Period != label {
+			summaries = append(summaries, InterestSummary{
+				Period:          label,
+				FirstDate:       day.AccrualDate,
+				InterestAccrued: NewMoney(Decimal{}, day.DailyInterestAccrued.Currency),
+			})
+		}
+
+		summary := &summaries[len(summaries)-1]
+		summary.LastDate = day.AccrualDate
+		summary.Days++
+		summary.InterestAccrued = summary.InterestAccrued.Add(day.DailyInterestAccrued)
+		summary.TotalInterest = day.TotalInterest
+	}
+
+	return summaries
+}
+
+// paginate returns the 1-based page of items and the number of pages, where a size of 0 puts every item on one page
+func paginate[T any](items []T, page, size int) ([]T, int) {
+	if size <= 0 {
+		return items, 1
+	}
+
+	pages := max((len(items)+size-1)/size, 1)
+	if page < 1 || page > pages {
+		return []T{}, pages
+	}
+
+	start := (page - 1) * size
+	return items[start:min(start+size, len(items))], pages
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHistoryFilter(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-07-01")
+
+	// 36600 * 6% / 366 days in 2024 = 6 per day
+	loan, err := NewLoan(LoanDetails{
+		ID:                 "loan1",
+		StartDate:          startDate,
+		EndDate:            endDate,
+		PrincipalAmount:    NewMoney(NewDecimal(36600, 0), CurrencyEUR),
+		BaseInterestRate:   NewDecimal(5, 0),
+		Margin:             NewDecimal(1, 0),
+		DayCountConvention: DayCountACTACTISDA,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	filter, err := parseHistoryFilterInput("2024-02-15", "2024-05-10", "quarter")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing filter: %v", err)
+	}
+
+	days := filter.Apply(loan.DailyInterest)
+	if len(days) != 86 || days[0].AccrualDate.Format("2006-01-02") != "2024-02-15" || days[len(days)-1].AccrualDate.Format("2006-01-02") != "2024-05-10" {
+		t.Fatalf("Unexpected filtered days. got %d days", len(days))
+	}
+
+	summaries := SummariseInterest(days, filter.Summary)
+	if len(summaries) != 2 {
+		t.Fatalf("Unexpected number of summaries. got %d, want 2", len(summaries))
+	}
+
+	q1, q2 := summaries[0], summaries[1]
+	if q1.Period != "2024-Q1" || q1.Days != 46 || q1.InterestAccrued.Amount.String() != "276.00" || q1.TotalInterest.Amount.String() != "546.00" {
+		t.Errorf("Unexpected first quarter. got %+v", q1)
+	}
+	if q2.Period != "2024-Q2" || q2.FirstDate.Format("2006-01-02") != "2024-04-01" || q2.Days != 40 || q2.TotalInterest.Amount.String() != "786.00" {
+		t.Errorf("Unexpected second quarter. got %+v", q2)
+	}
+}
+
+func TestParseHistoryFilterInputErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		summary string
+		err     error
+	}{
+		{name: "to before from", from: "2024-02-01", to: "2024-01-01", err: ErrInvalidInput},
+		{name: "invalid date", from: "yesterday", err: ErrInvalidInput},
+		{name: "invalid summary", summary: "week", err: ErrInvalidSummaryPeriod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseHistoryFilterInput(tt.from, tt.to, tt.summary); !errors.Is(err, tt.err) {
+				t.Errorf("Unexpected error. got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name  string
+		page  int
+		size  int
+		want  []int
+		pages int
+	}{
+		{name: "all", page: 1, size: 0, want: items, pages: 1},
+		{name: "first page", page: 1, size: 2, want: []int{1, 2}, pages: 3},
+		{name: "last page", page: 3, size: 2, want: []int{5}, pages: 3},
+		{name: "past the end", page: 4, size: 2, want: []int{}, pages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, pages := paginate(items, tt.page, tt.size)
+			if pages != tt.pages || len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("Unexpected page. got %v of %d, want %v of %d", got, pages, tt.want, tt.pages)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ImportAllOrNothing = "all-or-nothing"
+	ImportBestEffort   = "best-effort"
+)
+
+const (
+	ImportCSV   = "csv"
+	ImportJSONL = "jsonl"
+)
+
+var (
+	AllowedImportModes = []ImportMode{
+		ImportAllOrNothing,
+		ImportBestEffort,
+	}
+
+	AllowedImportFormats = []ImportFormat{
+		ImportCSV,
+		ImportJSONL,
+	}
+)
+
+// ImportMode holds whether an import creates every loan or none, or as many loans as are valid
+type ImportMode string
+
+// String stringifies the import mode
+func (i ImportMode) String() string {
+	return string(i)
+}
+
+// Validate validates whether the import mode is supported
+func (i ImportMode) Validate() error {
+	if ok := slices.Contains(AllowedImportModes, i); !ok {
+		return ErrInvalidImportMode
+	}
+
+	return nil
+}
+
+// ImportFormat holds the file format loans are imported from
+type ImportFormat string
+
+// String stringifies the import format
+func (i ImportFormat) String() string {
+	return string(i)
+}
+
+// Validate validates whether the import format is supported
+func (i ImportFormat) Validate() error {
+	if ok := slices.Contains(AllowedImportFormats, i); !ok {
+		return ErrInvalidImportFormat
+	}
+
+	return nil
+}
+
+// importFormatFromPath returns the import format of a file from its extension, defaulting to JSON lines
+func importFormatFromPath(path string) ImportFormat {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return ImportCSV
+	}
+	return ImportJSONL
+}
+
+// ImportRowError holds why a row of an imported file could not be imported
+type ImportRowError struct {
+	Line int   // Line is the line number the row starts on
+	Err  error // Err is the reason the row could not be imported
+}
+
+// Error implements error
+func (e ImportRowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the reason the row could not be imported
+func (e ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportResult holds the outcome of importing loans
+type ImportResult struct {
+	Rows    int              // Rows is the number of rows read
+	Created []string         // Created are the IDs of the loans created, in file order
+	Errors  []ImportRowError // Errors are the rows that could not be imported, in file order
+}
+
+// importedLoan holds a valid loan read from a row of an imported file
+type importedLoan struct {
+	line int
+	loan Loan
+}
+
+// ImportLoansFile imports loans from the file at path, taking the format from its extension when not given
+func ImportLoansFile(ctx context.Context, loanRepository LoanRepository, path string, format ImportFormat, mode ImportMode, change LoanChange) (ImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	defer file.Close()
+
+	if format == "" {
+		format = importFormatFromPath(path)
+	}
+
+	return ImportLoans(ctx, loanRepository, file, format, mode, change)
+}
+
+// ImportLoans validates every row of a CSV or JSON lines file of loan details and creates the loans.
+// All-or-nothing imports create no loans unless every row is valid, whereas best-effort imports create every valid loan.
+func ImportLoans(ctx context.Context, loanRepository LoanRepository, r io.Reader, format ImportFormat, mode ImportMode, change LoanChange) (ImportResult, error) {
+	if err := format.Validate(); err != nil {
+		return ImportResult{}, err
+	}
+	if err := mode.Validate(); err != nil {
+		return ImportResult{}, err
+	}
+
+	var (
+		loans  []importedLoan
+		result ImportResult
+		err    error
+	)
+	switch format {
+	case ImportCSV:
+		loans, result, err = readImportCSV(r)
+	case ImportJSONL:
+		loans, result, err = readImportJSONL(r)
+	}
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	// loans must be new, both to the repository, including its deleted loans, and within the file
+	deletedLoans, err := loanRepository.Deleted(ctx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	deleted := make(map[string]bool, len(deletedLoans))
+	for _, loan := range deletedLoans {
+		deleted[loan.Loan.LoanDetails.ID] = true
+	}
+
+	lines := map[string]int{}
+	valid := loans[:0]
+	for _, imported := range loans {
+		id := imported.loan.LoanDetails.ID
+		if line, ok := lines[id]; ok {
+			result.Errors = append(result.Errors, ImportRowError{imported.line, errors.Wrapf(ErrLoanAlreadyExists, "%s is also on line %d", id, line)})
+			continue
+		}
+		lines[id] = imported.line
+
+		if deleted[id] {
+			result.Errors = append(result.Errors, ImportRowError{imported.line, errors.Wrapf(ErrLoanAlreadyExists, "%s is the ID of a deleted loan", id)})
+			continue
+		}
+		_, err := loanRepository.Read(ctx, id)
+		if err == nil {
+			result.Errors = append(result.Errors, ImportRowError{imported.line, errors.Wrap(ErrLoanAlreadyExists, id)})
+			continue
+		}
+		if !errors.Is(err, ErrLoanDoesNotExists) {
+			return ImportResult{}, err
+		}
+		valid = append(valid, imported)
+	}
+
+	if mode == ImportAllOrNothing {
+		sortImportErrors(result.Errors)
+		if len(result.Errors) > 0 {
+			return result, nil
+		}
+
+		// the loans are created as one batch so the import leaves the repository as it found it if any cannot be created
+		loans := make([]Loan, len(valid))
+		for i, imported := range valid {
+			loans[i] = imported.loan
+		}
+		if err := loanRepository.CreateBatch(ctx, loans, change); err != nil {
+			return result, err
+		}
+
+		for _, loan := range loans {
+			result.Created = append(result.Created, loan.LoanDetails.ID)
+		}
+		return result, nil
+	}
+
+	for _, imported := range valid {
+		if err := loanRepository.Create(ctx, imported.loan, change); err != nil {
+			result.Errors = append(result.Errors, ImportRowError{imported.line, err})
+			continue
+		}
+		result.Created = append(result.Created, imported.loan.LoanDetails.ID)
+	}
+
+	sortImportErrors(result.Errors)
+	return result, nil
+}
+
+// readImportCSV reads loans from a CSV file with a header row naming its columns after the create command's flags
+func readImportCSV(r io.Reader) ([]importedLoan, ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ImportResult{}, nil
+	}
+	if err != nil {
+		return nil, ImportResult{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	input := newLoanInput()
+	fields := input.fields()
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+		if _, ok := fields[columns[i]]; !ok && columns[i] != "id" && columns[i] != "observation-shift" && columns[i] != "instalments" {
+			return nil, ImportResult{}, errors.Wrapf(ErrInvalidInput, "unknown column %q", name)
+		}
+	}
+
+	var (
+		loans  []importedLoan
+		result ImportResult
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			result.Rows++
+			result.Errors = append(result.Errors, ImportRowError{parseErr.StartLine, errors.Wrap(ErrInvalidInput, parseErr.Err.Error())})
+			continue
+		}
+		if err != nil {
+			return nil, ImportResult{}, err
+		}
+
+		result.Rows++
+		line, _ := reader.FieldPos(0)
+
+		loan, err := csvRowLoan(columns, record)
+		if err != nil {
+			result.Errors = append(result.Errors, ImportRowError{line, err})
+			continue
+		}
+		loans = append(loans, importedLoan{line: line, loan: loan})
+	}
+
+	return loans, result, nil
+}
+
+// csvRowLoan validates a row of an imported CSV file in the same way as the loan details input form and creates the loan
+func csvRowLoan(columns, record []string) (Loan, error) {
+	input := newLoanInput()
+	fields := input.fields()
+	id := ""
+
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			continue
+		}
+
+		switch columns[i] {
+		case "id":
+			id = value
+		case "observation-shift":
+			shift, err := strconv.ParseBool(value)
+			if err != nil {
+				return Loan{}, errors.Wrap(ErrInvalidInput, "observation-shift must be true or false")
+			}
+			input.observationShift = shift
+		case "instalments":
+			input.instalments = strings.Fields(value)
+		default:
+			*fields[columns[i]] = value
+		}
+	}
+
+	if id == "" {
+		id = randomString(8)
+	}
+
+	details, err := input.loanDetails(id, nil)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	return NewLoan(details, nil)
+}
+
+// readImportJSONL reads loans from a JSON lines file with the loan details of one loan per line
+func readImportJSONL(r io.Reader) ([]importedLoan, ImportResult, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRequestBytes)
+
+	var (
+		loans  []importedLoan
+		result ImportResult
+	)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		result.Rows++
+
+		loan, err := jsonRowLoan(data)
+		if err != nil {
+			result.Errors = append(result.Errors, ImportRowError{line, err})
+			continue
+		}
+		loans = append(loans, importedLoan{line: line, loan: loan})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, ImportResult{}, err
+	}
+
+	return loans, result, nil
+}
+
+// jsonRowLoan validates the loan details of a line of an imported JSON lines file and creates the loan
+func jsonRowLoan(data []byte) (Loan, error) {
+	var details LoanDetails
+	if err := json.Unmarshal(data, &details); err != nil {
+		return Loan{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	if details.ID == "" {
+		details.ID = randomString(8)
+	}
+
+	if err := details.Validate(); err != nil {
+		return Loan{}, err
+	}
+
+	return NewLoan(details, nil)
+}
+
+// sortImportErrors sorts import errors into file order
+func sortImportErrors(errs []ImportRowError) {
+	slices.SortStableFunc(errs, func(a, b ImportRowError) int {
+		return a.Line - b.Line
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testImportCSV = `id,start,end,currency,principal,base,margin,repayment,frequency
+loan1,2024-01-01,2025-01-01,EUR,1000,5,1,,
+loan2,2024-01-01,2025-01-01,XYZ,1000,5,1,,
+loan3,2024-01-01,2025-01-01,GBP,1000.001,5,1,,
+loan4,2024-01-01,2023-01-01,USD,1000,5,1,,
+loan5,2024-01-01,2025-01-01,usd,2500.50,4.25,0.75,equal-principal,quarterly
+loan1,2024-01-01,2025-01-01,EUR,1000,5,1,,
+`
+
+func TestImportLoansCSV(t *testing.T) {
+	ctx := context.Background()
+	wantErrs := map[int]error{
+		3: ErrInvalidCurrency,
+		4: ErrInvalidDecimalPlaces,
+		5: ErrInvalidInput,
+		7: ErrLoanAlreadyExists,
+	}
+
+	repo := NewInMemoryLoanRepository()
+	result, err := ImportLoans(ctx, repo, strings.NewReader(testImportCSV), ImportCSV, ImportAllOrNothing, LoanChange{})
+	if err != nil {
+		t.Fatalf("Unexpected error importing: %v", err)
+	}
+	if len(result.Created) != 0 || len(listLoans(t, repo)) != 0 {
+		t.Errorf("Expected an all-or-nothing import with invalid rows to create nothing. got %v", result.Created)
+	}
+	checkImportErrors(t, result, wantErrs)
+
+	result, err = ImportLoans(ctx, repo, strings.NewReader(testImportCSV), ImportCSV, ImportBestEffort, LoanChange{})
+	if err != nil {
+		t.Fatalf("Unexpected error importing: %v", err)
+	}
+	if result.Rows != 6 || len(result.Created) != 2 || result.Created[0] != "loan1" || result.Created[1] != "loan5" {
+		t.Errorf("Expected a best-effort import to create the valid rows. got %+v", result)
+	}
+	checkImportErrors(t, result, wantErrs)
+
+	loan, err := repo.Read(ctx, "loan5")
+	if err != nil {
+		t.Fatalf("Unexpected error reading imported loan: %v", err)
+	}
+	if loan.LoanDetails.Currency() != CurrencyUSD || loan.LoanDetails.Margin.String() != "0.75" || len(loan.Schedule) != 4 {
+		t.Errorf("Imported loan does not match its row. got %+v", loan.LoanDetails)
+	}
+
+	// the loans now exist, so importing them again is a conflict
+	result, _ = ImportLoans(ctx, repo, strings.NewReader(testImportCSV), ImportCSV, ImportBestEffort, LoanChange{})
+	if len(result.Created) != 0 || !errors.Is(result.Errors[0], ErrLoanAlreadyExists) {
+		t.Errorf("Expected existing loans not to be imported again. got %+v", result)
+	}
+
+	// a deleted loan keeps its ID reserved, so is caught before anything is written
+	repo.Delete(ctx, "loan5", 0, LoanChange{})
+	result, err = ImportLoans(ctx, repo, strings.NewReader(testImportCSV), ImportCSV, ImportAllOrNothing, LoanChange{})
+	if err != nil {
+		t.Fatalf("Unexpected error importing: %v", err)
+	}
+	reported := false
+	for _, rowErr := range result.Errors {
+		reported = reported || (errors.Is(rowErr, ErrLoanAlreadyExists) && strings.Contains(rowErr.Error(), "loan5 is the ID of a deleted loan"))
+	}
+	if len(result.Created) != 0 || !reported {
+		t.Errorf("Expected the ID of a deleted loan to be reported as a row error. got %+v", result)
+	}
+}
+
+func TestImportLoansJSONL(t *testing.T) {
+	ctx := context.Background()
+	jsonl := `{"id":"a","start_date":"2024-01-01","end_date":"2024-07-01","principal_amount":{"amount":"1000","currency":"GBP"},"base_interest_rate":"5","margin":"1"}
+
+{"id":"b","start_date":"2024-01-01","end_date":"2024-07-01","principal_amount":{"amount":"1000","currency":"GBP"},"base_interest_rate":"5.125","margin":"1"}
+{"id":
+`
+
+	repo := NewInMemoryLoanRepository()
+	result, err := ImportLoans(ctx, repo, strings.NewReader(jsonl), ImportJSONL, ImportBestEffort, LoanChange{})
+	if err != nil {
+		t.Fatalf("Unexpected error importing: %v", err)
+	}
+
+	if result.Rows != 3 || len(result.Created) != 1 || result.Created[0] != "a" {
+		t.Errorf("Expected only the valid line to be imported. got %+v", result)
+	}
+	checkImportErrors(t, result, map[int]error{3: ErrInvalidDecimalPlaces, 4: ErrInvalidInput})
+}
+
+func TestImportFormatFromPath(t *testing.T) {
+	if got := importFormatFromPath("loans.CSV"); got != ImportCSV {
+		t.Errorf("Unexpected import format. got %s, want %s", got, ImportCSV)
+	}
+	if got := importFormatFromPath("loans.jsonl"); got != ImportJSONL {
+		t.Errorf("Unexpected import format. got %s, want %s", got, ImportJSONL)
+	}
+}
+
+// checkImportErrors checks an import failed on exactly the expected lines with the expected errors
+func checkImportErrors(t *testing.T, result ImportResult, want map[int]error) {
+	t.Helper()
+
+	if len(result.Errors) != len(want) {
+		t.Errorf("Unexpected number of import errors. got %v, want errors on lines %v", result.Errors, want)
+		return
+	}
+
+	for _, rowErr := range result.Errors {
+		if !errors.Is(rowErr, want[rowErr.Line]) {
+			t.Errorf("Unexpected import error on line %d. got %v, want %v", rowErr.Line, rowErr.Err, want[rowErr.Line])
+		}
+	}
+}
@@ -1,79 +1,225 @@
 package main
 
-import "sync"
+import (
+	"sync"
+	"time"
 
-var _ (LoanRepository) = (*inMemoryLoanRepository)(nil)
+	"github.com/pkg/errors"
+)
 
-// inMemoryLoanRepository is an in-memory implementation of LoanRepository
-type inMemoryLoanRepository struct {
-	loans map[string]Loan
-	mx    sync.RWMutex
+var _ (loanStore) = (*inMemoryLoanStore)(nil)
+
+// inMemoryLoanStore is an in-memory store of loans, adapted into a LoanRepository by NewInMemoryLoanRepository
+type inMemoryLoanStore struct {
+	loans    map[string]Loan
+	versions map[string][]LoanVersion
+	deleted  map[string]DeletedLoan
+	mx       sync.RWMutex
 }
 
 // NewInMemoryLoanRepository creates a new in-memory LoanRepository
-func NewInMemoryLoanRepository() *inMemoryLoanRepository {
-	return &inMemoryLoanRepository{
-		loans: map[string]Loan{},
-		mx:    sync.RWMutex{},
-	}
+func NewInMemoryLoanRepository() *storeLoanRepository {
+	return newStoreLoanRepository(&inMemoryLoanStore{
+		loans:    map[string]Loan{},
+		versions: map[string][]LoanVersion{},
+		deleted:  map[string]DeletedLoan{},
+		mx:       sync.RWMutex{},
+	})
 }
 
-// Create implements LoanRepository
-func (i *inMemoryLoanRepository) Create(loan Loan) error {
+// Create implements loanStore
+func (i *inMemoryLoanStore) Create(loan Loan, change LoanChange) error {
+	return i.CreateBatch([]Loan{loan}, change)
+}
+
+// CreateBatch implements loanStore
+func (i *inMemoryLoanStore) CreateBatch(loans []Loan, change LoanChange) error {
+	if err := validateBatchIDs(loans); err != nil {
+		return err
+	}
+
 	i.mx.Lock()
 	defer i.mx.Unlock()
 
-	if _, ok := i.loans[loan.LoanDetails.ID]; ok {
-		return ErrLoanAlreadyExists
+	for _, loan := range loans {
+		if _, ok := i.loans[loan.LoanDetails.ID]; ok {
+			return batchError(len(loans), loan.LoanDetails.ID, ErrLoanAlreadyExists)
+		}
+		if _, ok := i.deleted[loan.LoanDetails.ID]; ok {
+			return batchError(len(loans), loan.LoanDetails.ID, errors.Wrap(ErrLoanAlreadyExists, "a deleted loan has the same ID"))
+		}
 	}
 
-	i.loans[loan.LoanDetails.ID] = loan
+	now := time.Now().UTC()
+	for _, loan := range loans {
+		loan.Revision = 1
+		i.loans[loan.LoanDetails.ID] = loan
+		i.versions[loan.LoanDetails.ID] = nextVersions(nil, nil, loan.LoanDetails, change, now)
+	}
 	return nil
 }
 
-// Read implements LoanRepository
-func (i *inMemoryLoanRepository) Read(id string) (Loan, error) {
+// Read implements loanStore
+func (i *inMemoryLoanStore) Read(id string) (Loan, error) {
+	loans, err := i.ReadBatch([]string{id})
+	if err != nil {
+		return Loan{}, err
+	}
+
+	return loans[0], nil
+}
+
+// ReadBatch implements loanStore
+func (i *inMemoryLoanStore) ReadBatch(ids []string) ([]Loan, error) {
 	i.mx.RLock()
 	defer i.mx.RUnlock()
 
-	loan, ok := i.loans[id]
-	if !ok {
-		return Loan{}, ErrLoanDoesNotExists
+	loans := make([]Loan, len(ids))
+	for n, id := range ids {
+		loan, ok := i.loans[id]
+		if !ok {
+			return nil, batchError(len(ids), id, ErrLoanDoesNotExists)
+		}
+		loans[n] = loan
 	}
 
-	return loan, nil
+	return loans, nil
 }
 
-// List implements LoanRepository
-func (i *inMemoryLoanRepository) List() map[string]Loan {
+// List implements loanStore
+func (i *inMemoryLoanStore) List(query LoanQuery) (LoanPage, error) {
+	if err := query.Validate(); err != nil {
+		return LoanPage{}, err
+	}
+
 	i.mx.RLock()
 	defer i.mx.RUnlock()
 
-	return i.loans
+	loans := make([]Loan, 0, len(i.loans))
+	for _, loan := range i.loans {
+		loans = append(loans, loan)
+	}
+
+	return query.Apply(loans), nil
 }
 
-// Update implements LoanRepository
-func (i *inMemoryLoanRepository) Update(loan Loan) error {
+// Update implements loanStore
+func (i *inMemoryLoanStore) Update(loan Loan, change LoanChange) error {
 	i.mx.Lock()
 	defer i.mx.Unlock()
 
-	if _, ok := i.loans[loan.LoanDetails.ID]; !ok {
+	id := loan.LoanDetails.ID
+	previous, ok := i.loans[id]
+	if !ok {
 		return ErrLoanDoesNotExists
 	}
+	if err := checkRevision(previous.Revision, loan.Revision); err != nil {
+		return err
+	}
 
-	i.loans[loan.LoanDetails.ID] = loan
+	loan.Revision = previous.Revision + 1
+	i.loans[id] = loan
+	i.versions[id] = append(i.versions[id], nextVersions(i.versions[id], &previous.LoanDetails, loan.LoanDetails, change, time.Now().UTC())...)
 	return nil
 }
 
-// Delete implements LoanRepository
-func (i *inMemoryLoanRepository) Delete(id string) error {
+// Delete implements loanStore
+func (i *inMemoryLoanStore) Delete(id string, revision int, change LoanChange) error {
 	i.mx.Lock()
 	defer i.mx.Unlock()
 
-	if _, ok := i.loans[id]; !ok {
+	loan, ok := i.loans[id]
+	if !ok {
 		return ErrLoanDoesNotExists
 	}
+	if err := checkRevision(loan.Revision, revision); err != nil {
+		return err
+	}
 
 	delete(i.loans, id)
+	i.deleted[id] = DeletedLoan{Loan: loan, LoanDeletion: newLoanDeletion(change, time.Now().UTC())}
 	return nil
 }
+
+// Versions implements loanStore
+func (i *inMemoryLoanStore) Versions(id string) ([]LoanVersion, error) {
+	i.mx.RLock()
+	defer i.mx.RUnlock()
+
+	loan, ok := i.loans[id]
+	if !ok {
+		return nil, ErrLoanDoesNotExists
+	}
+
+	return versionsOf(loan, i.versions[id]), nil
+}
+
+// Undelete implements loanStore
+func (i *inMemoryLoanStore) Undelete(id string) error {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	deleted, ok := i.deleted[id]
+	if !ok {
+		return i.notDeleted(id)
+	}
+
+	delete(i.deleted, id)
+	deleted.Loan.Revision++
+	i.loans[id] = deleted.Loan
+	return nil
+}
+
+// CreateDeleted implements loanStore
+func (i *inMemoryLoanStore) CreateDeleted(deleted DeletedLoan, change LoanChange) error {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	id := deleted.Loan.LoanDetails.ID
+	if _, ok := i.loans[id]; ok {
+		return ErrLoanAlreadyExists
+	}
+	if _, ok := i.deleted[id]; ok {
+		return errors.Wrap(ErrLoanAlreadyExists, "a deleted loan has the same ID")
+	}
+
+	deleted.Loan.Revision = 1
+	i.deleted[id] = deleted
+	i.versions[id] = nextVersions(nil, nil, deleted.Loan.LoanDetails, change, time.Now().UTC())
+	return nil
+}
+
+// Deleted implements loanStore
+func (i *inMemoryLoanStore) Deleted() ([]DeletedLoan, error) {
+	i.mx.RLock()
+	defer i.mx.RUnlock()
+
+	deleted := make([]DeletedLoan, 0, len(i.deleted))
+	for _, loan := range i.deleted {
+		deleted = append(deleted, loan)
+	}
+
+	return sortDeletedLoans(deleted), nil
+}
+
+// Purge implements loanStore
+func (i *inMemoryLoanStore) Purge(id string) error {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	if _, ok := i.deleted[id]; !ok {
+		return i.notDeleted(id)
+	}
+
+	delete(i.deleted, id)
+	delete(i.versions, id)
+	return nil
+}
+
+// notDeleted returns the error for a loan that is expected to be deleted but is not
+func (i *inMemoryLoanStore) notDeleted(id string) error {
+	if _, ok := i.loans[id]; ok {
+		return ErrLoanNotDeleted
+	}
+	return ErrLoanDoesNotExists
+}
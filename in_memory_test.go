@@ -1,16 +1,18 @@
 package main
 
 import (
+	"context"
 	"testing"
 )
 
 func TestInMemoryLoanRepository(t *testing.T) {
+	ctx := context.Background()
 	repo := NewInMemoryLoanRepository()
 
 	loan1 := Loan{
 		LoanDetails: LoanDetails{
-			ID:       "1",
-			Currency: CurrencyEUR,
+			ID:              "1",
+			PrincipalAmount: NewMoney(NewDecimal(1000, 0), CurrencyEUR),
 		},
 		DailyInterest: []Interest{},
 	}
@@ -26,25 +28,25 @@ func TestInMemoryLoanRepository(t *testing.T) {
 	}
 
 	// create
-	err := repo.Create(loan1)
+	err := repo.Create(ctx, loan1, LoanChange{})
 	if err != nil {
 		t.Errorf("Unexpected error in Create: %v", err)
 	}
 
 	// create (duplicate)
-	err = repo.Create(loan1)
+	err = repo.Create(ctx, loan1, LoanChange{})
 	if err == nil {
 		t.Errorf("Expected an error in Create when creating duplicate entry, but got none")
 	}
 
 	// create (another)
-	err = repo.Create(loan2)
+	err = repo.Create(ctx, loan2, LoanChange{})
 	if err != nil {
 		t.Errorf("Unexpected error in Create: %v", err)
 	}
 
 	// read
-	readLoan, err := repo.Read(loan1.LoanDetails.ID)
+	readLoan, err := repo.Read(ctx, loan1.LoanDetails.ID)
 	if err != nil {
 		t.Errorf("Unexpected error in Read: %v", err)
 	}
@@ -53,51 +55,84 @@ func TestInMemoryLoanRepository(t *testing.T) {
 	}
 
 	// list
-	loans := repo.List()
+	loans := listLoans(t, repo)
 	if len(loans) != 2 {
 		t.Errorf("List got wrong number of loans. Got %v, want %v", len(loans), 1)
 	}
 
 	// update
-	loan1.LoanDetails.Currency = CurrencyUSD
-	err = repo.Update(loan1)
+	loan1.LoanDetails.PrincipalAmount.Currency = CurrencyUSD
+	err = repo.Update(ctx, loan1, LoanChange{})
 	if err != nil {
 		t.Errorf("Unexpected error in Update: %v", err)
 	}
 
 	// read updated loan
-	updatedLoan, err := repo.Read(loan1.LoanDetails.ID)
+	updatedLoan, err := repo.Read(ctx, loan1.LoanDetails.ID)
 	if err != nil {
 		t.Errorf("Unexpected error in Read: %v", err)
 	}
 	if updatedLoan.LoanDetails.ID != loan1.LoanDetails.ID {
 		t.Errorf("Read got wrong loan. Got %v, want %v", updatedLoan.LoanDetails.ID, loan1.LoanDetails.ID)
 	}
-	if updatedLoan.LoanDetails.Currency != loan1.LoanDetails.Currency {
-		t.Errorf("Updated loan details were not saved. Got %v, want %v", updatedLoan.LoanDetails.Currency, loan1.LoanDetails.Currency)
+	if updatedLoan.LoanDetails.Currency() != loan1.LoanDetails.Currency() {
+		t.Errorf("Updated loan details were not saved. Got %v, want %v", updatedLoan.LoanDetails.Currency(), loan1.LoanDetails.Currency())
+	}
+
+	// versions
+	versions, err := repo.Versions(ctx, loan1.LoanDetails.ID)
+	if err != nil {
+		t.Errorf("Unexpected error in Versions: %v", err)
+	}
+	if len(versions) != 2 || versions[1].Changes[0].Field != "currency" {
+		t.Errorf("Versions got wrong history. Got %+v", versions)
+	}
+
+	// update without changing the details
+	err = repo.Update(ctx, loan1, LoanChange{})
+	if err != nil {
+		t.Errorf("Unexpected error in Update: %v", err)
+	}
+	if versions, _ := repo.Versions(ctx, loan1.LoanDetails.ID); len(versions) != 2 {
+		t.Errorf("Expected an unchanged update to record no version. Got %v versions", len(versions))
 	}
 
 	// update a non-existing loan
-	err = repo.Update(loan3)
+	err = repo.Update(ctx, loan3, LoanChange{})
 	if err == nil {
 		t.Errorf("Expected an error when updating a non-existing loan but got none")
 	}
 
 	// delete
-	err = repo.Delete(loan1.LoanDetails.ID)
+	err = repo.Delete(ctx, loan1.LoanDetails.ID, 0, LoanChange{})
 	if err != nil {
 		t.Errorf("Unexpected error in Delete: %v", err)
 	}
 
 	// delete (again)
-	err = repo.Delete(loan1.LoanDetails.ID)
+	err = repo.Delete(ctx, loan1.LoanDetails.ID, 0, LoanChange{})
 	if err == nil {
 		t.Errorf("Expected an error when deleting a non-existing loan but got none")
 	}
 
 	// read deleted loan
-	_, err = repo.Read(loan1.LoanDetails.ID)
+	_, err = repo.Read(ctx, loan1.LoanDetails.ID)
 	if err == nil {
 		t.Errorf("Expected an error when reading a deleted loan but got none")
 	}
+
+	// undelete
+	err = repo.Undelete(ctx, loan1.LoanDetails.ID)
+	if err != nil {
+		t.Errorf("Unexpected error in Undelete: %v", err)
+	}
+	if _, err := repo.Read(ctx, loan1.LoanDetails.ID); err != nil {
+		t.Errorf("Expected an undeleted loan to be readable: %v", err)
+	}
+
+	// purge a loan that is not deleted
+	err = repo.Purge(ctx, loan1.LoanDetails.ID)
+	if err == nil {
+		t.Errorf("Expected an error when purging a loan that is not deleted but got none")
+	}
 }
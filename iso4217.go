@@ -0,0 +1,162 @@
+package main
+
+// iso4217Currencies are the active ISO 4217 currencies, leaving out funds, precious metals and other codes that are not lent in
+var iso4217Currencies = []CurrencyInfo{
+	{Code: "AED", Name: "UAE Dirham", Symbol: "", MinorUnits: 2},
+	{Code: "AFN", Name: "Afghani", Symbol: "", MinorUnits: 2},
+	{Code: "ALL", Name: "Lek", Symbol: "", MinorUnits: 2},
+	{Code: "AMD", Name: "Armenian Dram", Symbol: "֏", MinorUnits: 2},
+	{Code: "ANG", Name: "Netherlands Antillean Guilder", Symbol: "", MinorUnits: 2},
+	{Code: "AOA", Name: "Kwanza", Symbol: "", MinorUnits: 2},
+	{Code: "ARS", Name: "Argentine Peso", Symbol: "", MinorUnits: 2},
+	{Code: "AUD", Name: "Australian Dollar", Symbol: "A$", MinorUnits: 2},
+	{Code: "AWG", Name: "Aruban Florin", Symbol: "", MinorUnits: 2},
+	{Code: "AZN", Name: "Azerbaijan Manat", Symbol: "₼", MinorUnits: 2},
+	{Code: "BAM", Name: "Convertible Mark", Symbol: "", MinorUnits: 2},
+	{Code: "BBD", Name: "Barbados Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "BDT", Name: "Taka", Symbol: "৳", MinorUnits: 2},
+	{Code: "BGN", Name: "Bulgarian Lev", Symbol: "", MinorUnits: 2},
+	{Code: "BHD", Name: "Bahraini Dinar", Symbol: "", MinorUnits: 3},
+	{Code: "BIF", Name: "Burundi Franc", Symbol: "", MinorUnits: 0},
+	{Code: "BMD", Name: "Bermudian Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "BND", Name: "Brunei Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "BOB", Name: "Boliviano", Symbol: "", MinorUnits: 2},
+	{Code: "BRL", Name: "Brazilian Real", Symbol: "R$", MinorUnits: 2},
+	{Code: "BSD", Name: "Bahamian Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "BTN", Name: "Ngultrum", Symbol: "", MinorUnits: 2},
+	{Code: "BWP", Name: "Pula", Symbol: "", MinorUnits: 2},
+	{Code: "BYN", Name: "Belarusian Ruble", Symbol: "", MinorUnits: 2},
+	{Code: "BZD", Name: "Belize Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "CAD", Name: "Canadian Dollar", Symbol: "CA$", MinorUnits: 2},
+	{Code: "CDF", Name: "Congolese Franc", Symbol: "", MinorUnits: 2},
+	{Code: "CHF", Name: "Swiss Franc", Symbol: "", MinorUnits: 2},
+	{Code: "CLP", Name: "Chilean Peso", Symbol: "", MinorUnits: 0},
+	{Code: "CNY", Name: "Yuan Renminbi", Symbol: "CN¥", MinorUnits: 2},
+	{Code: "COP", Name: "Colombian Peso", Symbol: "", MinorUnits: 2},
+	{Code: "CRC", Name: "Costa Rican Colon", Symbol: "₡", MinorUnits: 2},
+	{Code: "CUP", Name: "Cuban Peso", Symbol: "", MinorUnits: 2},
+	{Code: "CVE", Name: "Cabo Verde Escudo", Symbol: "", MinorUnits: 2},
+	{Code: "CZK", Name: "Czech Koruna", Symbol: "Kč", MinorUnits: 2},
+	{Code: "DJF", Name: "Djibouti Franc", Symbol: "", MinorUnits: 0},
+	{Code: "DKK", Name: "Danish Krone", Symbol: "", MinorUnits: 2},
+	{Code: "DOP", Name: "Dominican Peso", Symbol: "", MinorUnits: 2},
+	{Code: "DZD", Name: "Algerian Dinar", Symbol: "", MinorUnits: 2},
+	{Code: "EGP", Name: "Egyptian Pound", Symbol: "", MinorUnits: 2},
+	{Code: "ERN", Name: "Nakfa", Symbol: "", MinorUnits: 2},
+	{Code: "ETB", Name: "Ethiopian Birr", Symbol: "", MinorUnits: 2},
+	{Code: "EUR", Name: "Euro", Symbol: "€", MinorUnits: 2},
+	{Code: "FJD", Name: "Fiji Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "FKP", Name: "Falkland Islands Pound", Symbol: "", MinorUnits: 2},
+	{Code: "GBP", Name: "Pound Sterling", Symbol: "£", MinorUnits: 2},
+	{Code: "GEL", Name: "Lari", Symbol: "₾", MinorUnits: 2},
+	{Code: "GHS", Name: "Ghana Cedi", Symbol: "₵", MinorUnits: 2},
+	{Code: "GIP", Name: "Gibraltar Pound", Symbol: "", MinorUnits: 2},
+	{Code: "GMD", Name: "Dalasi", Symbol: "", MinorUnits: 2},
+	{Code: "GNF", Name: "Guinean Franc", Symbol: "", MinorUnits: 0},
+	{Code: "GTQ", Name: "Quetzal", Symbol: "", MinorUnits: 2},
+	{Code: "GYD", Name: "Guyana Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "HKD", Name: "Hong Kong Dollar", Symbol: "HK$", MinorUnits: 2},
+	{Code: "HNL", Name: "Lempira", Symbol: "", MinorUnits: 2},
+	{Code: "HTG", Name: "Gourde", Symbol: "", MinorUnits: 2},
+	{Code: "HUF", Name: "Forint", Symbol: "Ft", MinorUnits: 2},
+	{Code: "IDR", Name: "Rupiah", Symbol: "Rp", MinorUnits: 2},
+	{Code: "ILS", Name: "New Israeli Sheqel", Symbol: "₪", MinorUnits: 2},
+	{Code: "INR", Name: "Indian Rupee", Symbol: "₹", MinorUnits: 2},
+	{Code: "IQD", Name: "Iraqi Dinar", Symbol: "", MinorUnits: 3},
+	{Code: "IRR", Name: "Iranian Rial", Symbol: "", MinorUnits: 2},
+	{Code: "ISK", Name: "Iceland Krona", Symbol: "", MinorUnits: 0},
+	{Code: "JMD", Name: "Jamaican Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "JOD", Name: "Jordanian Dinar", Symbol: "", MinorUnits: 3},
+	{Code: "JPY", Name: "Yen", Symbol: "¥", MinorUnits: 0},
+	{Code: "KES", Name: "Kenyan Shilling", Symbol: "", MinorUnits: 2},
+	{Code: "KGS", Name: "Som", Symbol: "", MinorUnits: 2},
+	{Code: "KHR", Name: "Riel", Symbol: "៛", MinorUnits: 2},
+	{Code: "KMF", Name: "Comorian Franc", Symbol: "", MinorUnits: 0},
+	{Code: "KPW", Name: "North Korean Won", Symbol: "", MinorUnits: 2},
+	{Code: "KRW", Name: "Won", Symbol: "₩", MinorUnits: 0},
+	{Code: "KWD", Name: "Kuwaiti Dinar", Symbol: "", MinorUnits: 3},
+	{Code: "KYD", Name: "Cayman Islands Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "KZT", Name: "Tenge", Symbol: "₸", MinorUnits: 2},
+	{Code: "LAK", Name: "Lao Kip", Symbol: "₭", MinorUnits: 2},
+	{Code: "LBP", Name: "Lebanese Pound", Symbol: "", MinorUnits: 2},
+	{Code: "LKR", Name: "Sri Lanka Rupee", Symbol: "", MinorUnits: 2},
+	{Code: "LRD", Name: "Liberian Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "LSL", Name: "Loti", Symbol: "", MinorUnits: 2},
+	{Code: "LYD", Name: "Libyan Dinar", Symbol: "", MinorUnits: 3},
+	{Code: "MAD", Name: "Moroccan Dirham", Symbol: "", MinorUnits: 2},
+	{Code: "MDL", Name: "Moldovan Leu", Symbol: "", MinorUnits: 2},
+	{Code: "MGA", Name: "Malagasy Ariary", Symbol: "", MinorUnits: 2},
+	{Code: "MKD", Name: "Denar", Symbol: "", MinorUnits: 2},
+	{Code: "MMK", Name: "Kyat", Symbol: "", MinorUnits: 2},
+	{Code: "MNT", Name: "Tugrik", Symbol: "₮", MinorUnits: 2},
+	{Code: "MOP", Name: "Pataca", Symbol: "", MinorUnits: 2},
+	{Code: "MRU", Name: "Ouguiya", Symbol: "", MinorUnits: 2},
+	{Code: "MUR", Name: "Mauritius Rupee", Symbol: "", MinorUnits: 2},
+	{Code: "MVR", Name: "Rufiyaa", Symbol: "", MinorUnits: 2},
+	{Code: "MWK", Name: "Malawi Kwacha", Symbol: "", MinorUnits: 2},
+	{Code: "MXN", Name: "Mexican Peso", Symbol: "MX$", MinorUnits: 2},
+	{Code: "MYR", Name: "Malaysian Ringgit", Symbol: "RM", MinorUnits: 2},
+	{Code: "MZN", Name: "Mozambique Metical", Symbol: "", MinorUnits: 2},
+	{Code: "NAD", Name: "Namibia Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "NGN", Name: "Naira", Symbol: "₦", MinorUnits: 2},
+	{Code: "NIO", Name: "Cordoba Oro", Symbol: "", MinorUnits: 2},
+	{Code: "NOK", Name: "Norwegian Krone", Symbol: "", MinorUnits: 2},
+	{Code: "NPR", Name: "Nepalese Rupee", Symbol: "", MinorUnits: 2},
+	{Code: "NZD", Name: "New Zealand Dollar", Symbol: "NZ$", MinorUnits: 2},
+	{Code: "OMR", Name: "Rial Omani", Symbol: "", MinorUnits: 3},
+	{Code: "PAB", Name: "Balboa", Symbol: "", MinorUnits: 2},
+	{Code: "PEN", Name: "Sol", Symbol: "", MinorUnits: 2},
+	{Code: "PGK", Name: "Kina", Symbol: "", MinorUnits: 2},
+	{Code: "PHP", Name: "Philippine Peso", Symbol: "₱", MinorUnits: 2},
+	{Code: "PKR", Name: "Pakistan Rupee", Symbol: "", MinorUnits: 2},
+	{Code: "PLN", Name: "Zloty", Symbol: "zł", MinorUnits: 2},
+	{Code: "PYG", Name: "Guarani", Symbol: "₲", MinorUnits: 0},
+	{Code: "QAR", Name: "Qatari Rial", Symbol: "", MinorUnits: 2},
+	{Code: "RON", Name: "Romanian Leu", Symbol: "", MinorUnits: 2},
+	{Code: "RSD", Name: "Serbian Dinar", Symbol: "", MinorUnits: 2},
+	{Code: "RUB", Name: "Russian Ruble", Symbol: "₽", MinorUnits: 2},
+	{Code: "RWF", Name: "Rwanda Franc", Symbol: "", MinorUnits: 0},
+	{Code: "SAR", Name: "Saudi Riyal", Symbol: "", MinorUnits: 2},
+	{Code: "SBD", Name: "Solomon Islands Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "SCR", Name: "Seychelles Rupee", Symbol: "", MinorUnits: 2},
+	{Code: "SDG", Name: "Sudanese Pound", Symbol: "", MinorUnits: 2},
+	{Code: "SEK", Name: "Swedish Krona", Symbol: "", MinorUnits: 2},
+	{Code: "SGD", Name: "Singapore Dollar", Symbol: "S$", MinorUnits: 2},
+	{Code: "SHP", Name: "Saint Helena Pound", Symbol: "", MinorUnits: 2},
+	{Code: "SLE", Name: "Leone", Symbol: "", MinorUnits: 2},
+	{Code: "SOS", Name: "Somali Shilling", Symbol: "", MinorUnits: 2},
+	{Code: "SRD", Name: "Surinam Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "SSP", Name: "South Sudanese Pound", Symbol: "", MinorUnits: 2},
+	{Code: "STN", Name: "Dobra", Symbol: "", MinorUnits: 2},
+	{Code: "SVC", Name: "El Salvador Colon", Symbol: "", MinorUnits: 2},
+	{Code: "SYP", Name: "Syrian Pound", Symbol: "", MinorUnits: 2},
+	{Code: "SZL", Name: "Lilangeni", Symbol: "", MinorUnits: 2},
+	{Code: "THB", Name: "Baht", Symbol: "฿", MinorUnits: 2},
+	{Code: "TJS", Name: "Somoni", Symbol: "", MinorUnits: 2},
+	{Code: "TMT", Name: "Turkmenistan New Manat", Symbol: "", MinorUnits: 2},
+	{Code: "TND", Name: "Tunisian Dinar", Symbol: "", MinorUnits: 3},
+	{Code: "TOP", Name: "Pa'anga", Symbol: "", MinorUnits: 2},
+	{Code: "TRY", Name: "Turkish Lira", Symbol: "₺", MinorUnits: 2},
+	{Code: "TTD", Name: "Trinidad and Tobago Dollar", Symbol: "", MinorUnits: 2},
+	{Code: "TWD", Name: "New Taiwan Dollar", Symbol: "NT$", MinorUnits: 2},
+	{Code: "TZS", Name: "Tanzanian Shilling", Symbol: "", MinorUnits: 2},
+	{Code: "UAH", Name: "Hryvnia", Symbol: "₴", MinorUnits: 2},
+	{Code: "UGX", Name: "Uganda Shilling", Symbol: "", MinorUnits: 0},
+	{Code: "USD", Name: "US Dollar", Symbol: "$", MinorUnits: 2},
+	{Code: "UYU", Name: "Peso Uruguayo", Symbol: "", MinorUnits: 2},
+	{Code: "UZS", Name: "Uzbekistan Sum", Symbol: "", MinorUnits: 2},
+	{Code: "VED", Name: "Bolívar Soberano", Symbol: "", MinorUnits: 2},
+	{Code: "VES", Name: "Bolívar Soberano", Symbol: "", MinorUnits: 2},
+	{Code: "VND", Name: "Dong", Symbol: "₫", MinorUnits: 0},
+	{Code: "VUV", Name: "Vatu", Symbol: "", MinorUnits: 0},
+	{Code: "WST", Name: "Tala", Symbol: "", MinorUnits: 2},
+	{Code: "XAF", Name: "CFA Franc BEAC", Symbol: "", MinorUnits: 0},
+	{Code: "XCD", Name: "East Caribbean Dollar", Symbol: "EC$", MinorUnits: 2},
+	{Code: "XCG", Name: "Caribbean Guilder", Symbol: "", MinorUnits: 2},
+	{Code: "XOF", Name: "CFA Franc BCEAO", Symbol: "", MinorUnits: 0},
+	{Code: "XPF", Name: "CFP Franc", Symbol: "", MinorUnits: 0},
+	{Code: "YER", Name: "Yemeni Rial", Symbol: "", MinorUnits: 2},
+	{Code: "ZAR", Name: "Rand", Symbol: "R", MinorUnits: 2},
+	{Code: "ZMW", Name: "Zambian Kwacha", Symbol: "", MinorUnits: 2},
+	{Code: "ZWG", Name: "Zimbabwe Gold", Symbol: "", MinorUnits: 2},
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	journalPut        = "put"
+	journalSoftDelete = "soft-delete"
+	journalUndelete   = "undelete"
+	journalDelete     = "delete"
+)
+
+// journalCompactionThreshold is the minimum number of journal entries before the journal is considered for compaction
+const journalCompactionThreshold = 100
+
+var _ (LoanRepository) = (*journalLoanRepository)(nil)
+
+// journalEntry is a single line of the loan journal
+type journalEntry struct {
+	Op   string `json:"op"`             // Op is the operation recorded, either put, soft-delete, undelete or delete, which permanently removes the loan
+	ID   string `json:"id"`             // ID is the ID of the loan the operation applies to
+	Loan *Loan  `json:"loan,omitempty"` // Loan is the full loan written by a put, or by a soft-delete written by compaction
+
+	Versions []LoanVersion `json:"versions,omitempty"` // Versions are the versions of the loan's details recorded by a put
+	Deletion *LoanDeletion `json:"deletion,omitempty"` // Deletion is when, by whom and why the loan was deleted by a soft-delete
+}
+
+// journalLoanRepository is a LoanRepository backed by an append-only JSON-lines journal, guarded by a lock file so multiple processes can share it
+type journalLoanRepository struct {
+	path     string
+	lockFile *os.File
+	mx       sync.Mutex
+
+	loans    map[string]Loan
+	versions map[string][]LoanVersion
+	deleted  map[string]DeletedLoan
+	file     os.FileInfo // file is the journal file that was last read, used to detect compaction by another process
+	offset   int64       // offset is the position after the last complete journal line that was read
+	entries  int         // entries is the number of entries in the journal
+}
+
+// NewJournalLoanRepository creates a new LoanRepository journaled to the file at path, creating it if needed
+func NewJournalLoanRepository(path string) (*journalLoanRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	lockFile, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &journalLoanRepository{
+		path:     path,
+		lockFile: lockFile,
+		loans:    map[string]Loan{},
+		versions: map[string][]LoanVersion{},
+		deleted:  map[string]DeletedLoan{},
+	}
+
+	if err := j.withLock(context.Background(), false, func() error { return nil }); err != nil {
+		lockFile.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Close releases the journal's lock file
+func (j *journalLoanRepository) Close() error {
+	return j.lockFile.Close()
+}
+
+// Create implements LoanRepository
+func (j *journalLoanRepository) Create(ctx context.Context, loan Loan, change LoanChange) error {
+	return j.CreateBatch(ctx, []Loan{loan}, change)
+}
+
+// CreateBatch implements LoanRepository
+func (j *journalLoanRepository) CreateBatch(ctx context.Context, loans []Loan, change LoanChange) error {
+	if err := validateBatchIDs(loans); err != nil {
+		return err
+	}
+
+	return j.withLock(ctx, true, func() error {
+		now := time.Now().UTC()
+		entries := make([]journalEntry, len(loans))
+		for n, loan := range loans {
+			if _, ok := j.loans[loan.LoanDetails.ID]; ok {
+				return batchError(len(loans), loan.LoanDetails.ID, ErrLoanAlreadyExists)
+			}
+			if _, ok := j.deleted[loan.LoanDetails.ID]; ok {
+				return batchError(len(loans), loan.LoanDetails.ID, errors.Wrap(ErrLoanAlreadyExists, "a deleted loan has the same ID"))
+			}
+
+			loan.Revision = 1
+			versions := nextVersions(nil, nil, loan.LoanDetails, change, now)
+			entries[n] = journalEntry{Op: journalPut, ID: loan.LoanDetails.ID, Loan: &loan, Versions: versions}
+		}
+
+		return j.append(entries...)
+	})
+}
+
+// Read implements LoanRepository
+func (j *journalLoanRepository) Read(ctx context.Context, id string) (Loan, error) {
+	loans, err := j.ReadBatch(ctx, []string{id})
+	if err != nil {
+		return Loan{}, err
+	}
+
+	return loans[0], nil
+}
+
+// ReadBatch implements LoanRepository
+func (j *journalLoanRepository) ReadBatch(ctx context.Context, ids []string) ([]Loan, error) {
+	loans := make([]Loan, len(ids))
+	err := j.withLock(ctx, false, func() error {
+		for n, id := range ids {
+			loan, ok := j.loans[id]
+			if !ok {
+				return batchError(len(ids), id, ErrLoanDoesNotExists)
+			}
+			loans[n] = loan
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loans, nil
+}
+
+// List implements LoanRepository
+func (j *journalLoanRepository) List(ctx context.Context, query LoanQuery) (LoanPage, error) {
+	if err := query.Validate(); err != nil {
+		return LoanPage{}, err
+	}
+
+	var loans []Loan
+	err := j.withLock(ctx, false, func() error {
+		for _, loan := range j.loans {
+			loans = append(loans, loan)
+		}
+		return nil
+	})
+	if err != nil {
+		return LoanPage{}, err
+	}
+
+	return query.Apply(loans), nil
+}
+
+// Update implements LoanRepository
+func (j *journalLoanRepository) Update(ctx context.Context, loan Loan, change LoanChange) error {
+	return j.withLock(ctx, true, func() error {
+		id := loan.LoanDetails.ID
+		previous, ok := j.loans[id]
+		if !ok {
+			return ErrLoanDoesNotExists
+		}
+		if err := checkRevision(previous.Revision, loan.Revision); err != nil {
+			return err
+		}
+
+		loan.Revision = previous.Revision + 1
+		versions := nextVersions(j.versions[id], &previous.LoanDetails, loan.LoanDetails, change, time.Now().UTC())
+		return j.append(journalEntry{Op: journalPut, ID: id, Loan: &loan, Versions: versions})
+	})
+}
+
+// Delete implements LoanRepository
+func (j *journalLoanRepository) Delete(ctx context.Context, id string, revision int, change LoanChange) error {
+	return j.withLock(ctx, true, func() error {
+		loan, ok := j.loans[id]
+		if !ok {
+			return ErrLoanDoesNotExists
+		}
+		if err := checkRevision(loan.Revision, revision); err != nil {
+			return err
+		}
+
+		deletion := newLoanDeletion(change, time.Now().UTC())
+		return j.append(journalEntry{Op: journalSoftDelete, ID: id, Deletion: &deletion})
+	})
+}
+
+// Versions implements LoanRepository
+func (j *journalLoanRepository) Versions(ctx context.Context, id string) ([]LoanVersion, error) {
+	var versions []LoanVersion
+	err := j.withLock(ctx, false, func() error {
+		loan, ok := j.loans[id]
+		if !ok {
+			return ErrLoanDoesNotExists
+		}
+		versions = versionsOf(loan, j.versions[id])
+		return nil
+	})
+
+	return versions, err
+}
+
+// Undelete implements LoanRepository
+func (j *journalLoanRepository) Undelete(ctx context.Context, id string) error {
+	return j.withLock(ctx, true, func() error {
+		if _, ok := j.deleted[id]; !ok {
+			return j.notDeleted(id)
+		}
+
+		return j.append(journalEntry{Op: journalUndelete, ID: id})
+	})
+}
+
+// CreateDeleted implements LoanRepository
+func (j *journalLoanRepository) CreateDeleted(ctx context.Context, deleted DeletedLoan, change LoanChange) error {
+	return j.withLock(ctx, true, func() error {
+		loan := deleted.Loan
+		id := loan.LoanDetails.ID
+		if _, ok := j.loans[id]; ok {
+			return ErrLoanAlreadyExists
+		}
+		if _, ok := j.deleted[id]; ok {
+			return errors.Wrap(ErrLoanAlreadyExists, "a deleted loan has the same ID")
+		}
+
+		loan.Revision = 1
+		versions := nextVersions(nil, nil, loan.LoanDetails, change, time.Now().UTC())
+		return j.append(
+			journalEntry{Op: journalPut, ID: id, Loan: &loan, Versions: versions},
+			journalEntry{Op: journalSoftDelete, ID: id, Deletion: &deleted.LoanDeletion},
+		)
+	})
+}
+
+// Deleted implements LoanRepository
+func (j *journalLoanRepository) Deleted(ctx context.Context) ([]DeletedLoan, error) {
+	var deleted []DeletedLoan
+	err := j.withLock(ctx, false, func() error {
+		for _, loan := range j.deleted {
+			deleted = append(deleted, loan)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sortDeletedLoans(deleted), nil
+}
+
+// Purge implements LoanRepository
+func (j *journalLoanRepository) Purge(ctx context.Context, id string) error {
+	return j.withLock(ctx, true, func() error {
+		if _, ok := j.deleted[id]; !ok {
+			return j.notDeleted(id)
+		}
+
+		return j.append(journalEntry{Op: journalDelete, ID: id})
+	})
+}
+
+// notDeleted returns the error for a loan that is expected to be deleted but is not
+func (j *journalLoanRepository) notDeleted(id string) error {
+	if _, ok := j.loans[id]; ok {
+		return ErrLoanNotDeleted
+	}
+	return ErrLoanDoesNotExists
+}
+
+// withLock runs fn holding the journal lock, after catching up with any entries written by other processes, unless the context is done
+// before the lock is held
+func (j *journalLoanRepository) withLock(ctx context.Context, exclusive bool, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	j.mx.Lock()
+	defer j.mx.Unlock()
+
+	if err := lockFile(ctx, j.lockFile, exclusive); err != nil {
+		return errors.Wrap(err, "failed to lock journal")
+	}
+	defer unlockFile(j.lockFile)
+
+	if err := j.refresh(exclusive); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	// the change is already durable, so compaction is best-effort and a failed one is retried by the next write
+	if exclusive && j.entries >= journalCompactionThreshold && j.entries > 2*(len(j.loans)+len(j.deleted)) {
+		_ = j.compact()
+	}
+
+	return nil
+}
+
+// refresh reads any journal entries appended since the last read, reloading from scratch if the journal was replaced
+func (j *journalLoanRepository) refresh(exclusive bool) error {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if j.file == nil || !os.SameFile(j.file, info) || info.Size() < j.offset {
+		j.loans = map[string]Loan{}
+		j.versions = map[string][]LoanVersion{}
+		j.deleted = map[string]DeletedLoan{}
+		j.offset = 0
+		j.entries = 0
+	}
+	j.file = info
+
+	if _, err := file.Seek(j.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break // an incomplete final line is a write that never finished, so is ignored
+		}
+		if err != nil {
+			return err
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(bytes.TrimSpace(line), &entry); err != nil {
+			return errors.Wrapf(ErrCorruptJournal, "entry at offset %d: %v", j.offset, err)
+		}
+		j.apply(entry)
+		j.offset += int64(len(line))
+	}
+
+	// drop the remains of an interrupted write so the next entry starts on a fresh line
+	if exclusive && info.Size() > j.offset {
+		if err := file.Truncate(j.offset); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply applies a journal entry to the in-memory loans
+func (j *journalLoanRepository) apply(entry journalEntry) {
+	switch entry.Op {
+	case journalPut:
+		if entry.Loan != nil {
+			loan := *entry.Loan
+			// loans journaled before revisions were kept start at revision 1
+			loan.Revision = max(loan.Revision, 1)
+			j.loans[entry.ID] = loan
+		}
+		j.versions[entry.ID] = append(j.versions[entry.ID], entry.Versions...)
+	case journalSoftDelete:
+		loan, ok := j.loans[entry.ID]
+		if entry.Loan != nil {
+			loan, ok = *entry.Loan, true
+			loan.Revision = max(loan.Revision, 1)
+		}
+		if ok && entry.Deletion != nil {
+			j.versions[entry.ID] = append(j.versions[entry.ID], entry.Versions...)
+			j.deleted[entry.ID] = DeletedLoan{Loan: loan, LoanDeletion: *entry.Deletion}
+			delete(j.loans, entry.ID)
+		}
+	case journalUndelete:
+		if deleted, ok := j.deleted[entry.ID]; ok {
+			deleted.Loan.Revision++
+			j.loans[entry.ID] = deleted.Loan
+			delete(j.deleted, entry.ID)
+		}
+	case journalDelete:
+		// loans deleted before soft deletes were journaled are removed by the same operation as a purge
+		delete(j.loans, entry.ID)
+		delete(j.deleted, entry.ID)
+		delete(j.versions, entry.ID)
+	}
+	j.entries++
+}
+
+// append appends entries to the journal in a single write, syncing them to disk before applying them
+func (j *journalLoanRepository) append(entries ...journalEntry) error {
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		j.apply(entry)
+	}
+	j.offset += int64(len(data))
+
+	return nil
+}
+
+// compact atomically replaces the journal with one put entry per live loan and one soft-delete entry per deleted loan,
+// each holding every version of the loan's details
+func (j *journalLoanRepository) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	writer := bufio.NewWriter(tmp)
+	entries := make([]journalEntry, 0, len(j.loans)+len(j.deleted))
+	for id, loan := range j.loans {
+		entries = append(entries, journalEntry{Op: journalPut, ID: id, Loan: &loan, Versions: j.versions[id]})
+	}
+	for id, deleted := range j.deleted {
+		entries = append(entries, journalEntry{Op: journalSoftDelete, ID: id, Loan: &deleted.Loan, Versions: j.versions[id], Deletion: &deleted.LoanDeletion})
+	}
+
+	var offset int64
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		n, err := writer.Write(append(data, '\n'))
+		if err != nil {
+			return err
+		}
+		offset += int64(n)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(j.path))
+
+	info, err := os.Stat(j.path)
+	if err != nil {
+		return err
+	}
+
+	j.file = info
+	j.offset = offset
+	j.entries = len(entries)
+
+	return nil
+}
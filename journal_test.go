@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalLoanRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "loans.jsonl")
+
+	repo, err := NewJournalLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error creating journal: %v", err)
+	}
+	defer repo.Close()
+
+	loan1 := Loan{LoanDetails: LoanDetails{ID: "1", PrincipalAmount: NewMoney(NewDecimal(1000, 0), CurrencyEUR)}}
+	loan2 := Loan{LoanDetails: LoanDetails{ID: "2"}}
+
+	if err := repo.Create(ctx, loan1, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Create: %v", err)
+	}
+	if err := repo.Create(ctx, loan1, LoanChange{}); err == nil {
+		t.Errorf("Expected an error in Create when creating duplicate entry, but got none")
+	}
+	if err := repo.Create(ctx, loan2, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Create: %v", err)
+	}
+
+	loan1.LoanDetails.PrincipalAmount.Currency = CurrencyUSD
+	if err := repo.Update(ctx, loan1, LoanChange{ChangedBy: "tester", Reason: "redenominated"}); err != nil {
+		t.Errorf("Unexpected error in Update: %v", err)
+	}
+	if err := repo.Update(ctx, Loan{LoanDetails: LoanDetails{ID: "3"}}, LoanChange{}); err == nil {
+		t.Errorf("Expected an error when updating a non-existing loan but got none")
+	}
+	if err := repo.Delete(ctx, loan2.LoanDetails.ID, 0, LoanChange{ChangedBy: "tester", Reason: "duplicate"}); err != nil {
+		t.Errorf("Unexpected error in Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, loan2.LoanDetails.ID, 0, LoanChange{}); err == nil {
+		t.Errorf("Expected an error when deleting a non-existing loan but got none")
+	}
+
+	// a second repository on the same file sees everything written by the first, as another process would
+	reopened, err := NewJournalLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening journal: %v", err)
+	}
+	defer reopened.Close()
+
+	readLoan, err := reopened.Read(ctx, loan1.LoanDetails.ID)
+	if err != nil {
+		t.Errorf("Unexpected error in Read: %v", err)
+	}
+	if readLoan.LoanDetails.Currency() != CurrencyUSD || readLoan.LoanDetails.PrincipalAmount.Amount.Cmp(loan1.LoanDetails.PrincipalAmount.Amount) != 0 {
+		t.Errorf("Updated loan was not persisted. got %v, want %v", readLoan.LoanDetails.PrincipalAmount, loan1.LoanDetails.PrincipalAmount)
+	}
+	if _, err := reopened.Read(ctx, loan2.LoanDetails.ID); err == nil {
+		t.Errorf("Expected an error when reading a deleted loan but got none")
+	}
+	if loans := listLoans(t, reopened); len(loans) != 1 {
+		t.Errorf("List got wrong number of loans. Got %v, want %v", len(loans), 1)
+	}
+
+	versions, err := reopened.Versions(ctx, loan1.LoanDetails.ID)
+	if err != nil {
+		t.Errorf("Unexpected error in Versions: %v", err)
+	}
+	if len(versions) != 2 || versions[1].ChangedBy != "tester" || versions[1].Reason != "redenominated" || len(versions[1].Changes) != 1 {
+		t.Errorf("Versions were not persisted. got %+v", versions)
+	}
+	if _, err := reopened.Versions(ctx, loan2.LoanDetails.ID); err == nil {
+		t.Errorf("Expected an error when listing the versions of a deleted loan but got none")
+	}
+
+	if deleted, err := reopened.Deleted(ctx); err != nil || len(deleted) != 1 || deleted[0].Loan.LoanDetails.ID != loan2.LoanDetails.ID || deleted[0].Reason != "duplicate" {
+		t.Errorf("Deleted loan was not kept. got %+v", deleted)
+	}
+	if err := reopened.Create(ctx, loan2, LoanChange{}); !errors.Is(err, ErrLoanAlreadyExists) {
+		t.Errorf("Expected ErrLoanAlreadyExists when creating a loan with the ID of a deleted loan, got %v", err)
+	}
+
+	// writes from the second repository are picked up by the first
+	if err := reopened.Undelete(ctx, loan2.LoanDetails.ID); err != nil {
+		t.Errorf("Unexpected error in Undelete: %v", err)
+	}
+	if _, err := repo.Read(ctx, loan2.LoanDetails.ID); err != nil {
+		t.Errorf("Expected loan undeleted by another repository to be readable: %v", err)
+	}
+	if err := repo.Undelete(ctx, loan2.LoanDetails.ID); !errors.Is(err, ErrLoanNotDeleted) {
+		t.Errorf("Expected ErrLoanNotDeleted when undeleting a loan that is not deleted, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, loan2.LoanDetails.ID, 0, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Delete: %v", err)
+	}
+	if err := repo.Purge(ctx, loan2.LoanDetails.ID); err != nil {
+		t.Errorf("Unexpected error in Purge: %v", err)
+	}
+	if deleted, err := reopened.Deleted(ctx); err != nil || len(deleted) != 0 {
+		t.Errorf("Expected a purged loan to be removed. got %+v", deleted)
+	}
+	if err := reopened.Create(ctx, loan2, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Create after Purge: %v", err)
+	}
+}
+
+func TestJournalLoanRepositoryInterruptedWrite(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "loans.jsonl")
+
+	repo, err := NewJournalLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error creating journal: %v", err)
+	}
+	if err := repo.Create(ctx, Loan{LoanDetails: LoanDetails{ID: "1"}}, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Create: %v", err)
+	}
+	repo.Close()
+
+	// simulate a crash part way through writing an entry
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Unexpected error opening journal: %v", err)
+	}
+	file.WriteString(`{"op":"put","id":"2","lo`)
+	file.Close()
+
+	repo, err = NewJournalLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening journal after interrupted write: %v", err)
+	}
+	defer repo.Close()
+
+	if err := repo.Create(ctx, Loan{LoanDetails: LoanDetails{ID: "3"}}, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Create after interrupted write: %v", err)
+	}
+
+	reopened, err := NewJournalLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening journal: %v", err)
+	}
+	defer reopened.Close()
+
+	loans := listLoans(t, reopened)
+	if len(loans) != 2 || loans[0].LoanDetails.ID != "1" || loans[1].LoanDetails.ID != "3" {
+		t.Errorf("Expected only the complete entries to be loaded. got %v", loans)
+	}
+}
+
+func TestJournalLoanRepositoryCompaction(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "loans.jsonl")
+
+	repo, err := NewJournalLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error creating journal: %v", err)
+	}
+	defer repo.Close()
+
+	other, err := NewJournalLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error creating journal: %v", err)
+	}
+	defer other.Close()
+
+	loan := Loan{LoanDetails: LoanDetails{ID: "1"}}
+	if err := repo.Create(ctx, loan, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Create: %v", err)
+	}
+	for i := 0; i < journalCompactionThreshold; i++ {
+		if err := repo.Update(ctx, loan, LoanChange{}); err != nil {
+			t.Errorf("Unexpected error in Update: %v", err)
+		}
+	}
+
+	if repo.entries >= journalCompactionThreshold {
+		t.Errorf("Expected journal to be compacted. got %d entries", repo.entries)
+	}
+
+	if _, err := other.Read(ctx, loan.LoanDetails.ID); err != nil {
+		t.Errorf("Expected loan to be readable by another repository after compaction: %v", err)
+	}
+	if loans := listLoans(t, other); len(loans) != 1 {
+		t.Errorf("List got wrong number of loans after compaction. Got %v, want %v", len(loans), 1)
+	}
+}
+
+func TestJournalLoanRepositoryLockDeadline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loans.jsonl")
+	repo, err := NewJournalLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening journal: %v", err)
+	}
+	defer repo.Close()
+
+	// another process holding the lock is stood in for by a second handle on the lock file
+	other, err := os.OpenFile(path+".lock", os.O_RDWR, 0o644)
+	if err != nil {
+		t.Fatalf("Unexpected error opening lock file: %v", err)
+	}
+	defer other.Close()
+	if err := lockFile(context.Background(), other, true); err != nil {
+		t.Fatalf("Unexpected error locking journal: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := repo.Read(ctx, "1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded while the journal is locked elsewhere, got %v", err)
+	}
+
+	unlockFile(other)
+	if _, err := repo.Read(context.Background(), "1"); !errors.Is(err, ErrLoanDoesNotExists) {
+		t.Errorf("Expected the journal to be readable once the lock is released, got %v", err)
+	}
+}
@@ -1,122 +1,300 @@
 package main
 
 import (
+	"context"
 	"slices"
-	"time"
+
+	"github.com/pkg/errors"
 )
 
 const (
-	CurrencyEUR = "EUR"
-	CurrencyGBP = "GBP"
-	CurrencyUSD = "USD"
+	RoundPerDay  = "per-day"
+	RoundOnTotal = "on-total"
 )
 
+const (
+	AccrualStartInclusive = "start-inclusive"
+	AccrualEndInclusive   = "end-inclusive"
+	AccrualBothInclusive  = "both-inclusive"
+	AccrualBothExclusive  = "both-exclusive"
+)
+
+// calculationPrecision is the number of decimal places unrounded interest is held to when rounding on the total
+const calculationPrecision = 10
+
 var (
-	AllowedCurrencies = []Currency{
-		CurrencyEUR,
-		CurrencyGBP,
-		CurrencyUSD,
+	AllowedRoundingPoints = []RoundingPoint{
+		RoundPerDay,
+		RoundOnTotal,
+	}
+
+	AllowedAccrualBoundaries = []AccrualBoundaries{
+		AccrualStartInclusive,
+		AccrualEndInclusive,
+		AccrualBothInclusive,
+		AccrualBothExclusive,
 	}
 )
 
-// Currency holds a 3 letter ISO 4217 currency code
-type Currency string
+// RoundingPoint holds whether interest is rounded on each day's accrual or only on the running total
+type RoundingPoint string
 
-// String stringifies the currency
-func (c Currency) String() string {
-	return string(c)
+// String stringifies the rounding point
+func (r RoundingPoint) String() string {
+	return string(r)
 }
 
-// Symbol returns the corresponding symbol to the currency
-func (c Currency) Symbol() string {
-	switch c {
-	case CurrencyEUR:
-		return "€"
-	case CurrencyGBP:
-		return "£"
-	case CurrencyUSD:
-		return "$"
-	default:
-		return ""
+// Validate validates whether the rounding point is supported
+func (r RoundingPoint) Validate() error {
+	if ok := slices.Contains(AllowedRoundingPoints, r); !ok {
+		return ErrInvalidRoundingPoint
 	}
+
+	return nil
 }
 
-// Validate validates whether the ISO 4217 currency is supported
-func (c Currency) Validate() error {
-	if ok := slices.Contains(AllowedCurrencies, c); !ok {
-		return ErrInvalidCurrency
+// AccrualBoundaries holds whether the start date and end date of a loan accrue interest
+type AccrualBoundaries string
+
+// String stringifies the accrual boundaries
+func (a AccrualBoundaries) String() string {
+	return string(a)
+}
+
+// Validate validates whether the accrual boundaries are supported
+func (a AccrualBoundaries) Validate() error {
+	if ok := slices.Contains(AllowedAccrualBoundaries, a); !ok {
+		return ErrInvalidAccrualBoundaries
 	}
 
 	return nil
 }
 
+// includesStart returns whether the start date accrues interest, which it does unless excluded
+func (a AccrualBoundaries) includesStart() bool {
+	return a != AccrualEndInclusive && a != AccrualBothExclusive
+}
+
+// includesEnd returns whether the end date accrues interest, which it only does when included
+func (a AccrualBoundaries) includesEnd() bool {
+	return a == AccrualEndInclusive || a == AccrualBothInclusive
+}
+
+// accrualBoundariesOf returns the accrual boundaries including the start date and end date as given
+func accrualBoundariesOf(includeStart, includeEnd bool) AccrualBoundaries {
+	switch {
+	case includeStart && includeEnd:
+		return AccrualBothInclusive
+	case includeEnd:
+		return AccrualEndInclusive
+	case includeStart:
+		return AccrualStartInclusive
+	default:
+		return AccrualBothExclusive
+	}
+}
+
 // Loan represents a loan and the accompanying daily accrued interest
 type Loan struct {
-	LoanDetails   LoanDetails `json:"loan_details"`   // LoanDetails contains all details of the loan
-	DailyInterest []Interest  `json:"daily_interest"` // DailyInterest contains interest data for each day of the loan period
+	LoanDetails   LoanDetails   `json:"loan_details"`       // LoanDetails contains all details of the loan
+	Transactions  []Transaction `json:"transactions"`       // Transactions contains the repayments, drawdowns and fees made against the loan
+	DailyInterest []Interest    `json:"daily_interest"`     // DailyInterest contains interest data for each day of the loan period
+	Schedule      []Instalment  `json:"schedule"`           // Schedule contains the contractual instalments from the repayment profile
+	Revision      int           `json:"revision,omitempty"` // Revision counts the writes of the loan from 1 when it was created, used as its ETag so a write can expect the loan to be unchanged
+}
+
+// NewLoan creates a new Loan from its details and transactions, calculating the daily accrued interest
+func NewLoan(details LoanDetails, transactions []Transaction) (Loan, error) {
+	if details.FloatingRate != nil {
+		if err := details.FloatingRate.Validate(); err != nil {
+			return Loan{}, err
+		}
+	}
+	if err := details.validateBusinessDays(); err != nil {
+		return Loan{}, err
+	}
+	if details.AccrualBoundaries == "" {
+		// older loans without accrual boundaries always accrued from the start date up to but excluding the end date
+		details.AccrualBoundaries = AccrualStartInclusive
+	}
+	if err := details.AccrualBoundaries.Validate(); err != nil {
+		return Loan{}, err
+	}
+	if err := validateTransactions(details, transactions); err != nil {
+		return Loan{}, err
+	}
+
+	schedule, err := GenerateSchedule(details, details.RepaymentProfile)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	transactions = slices.Clone(transactions)
+	sortTransactions(transactions)
+
+	return Loan{
+		LoanDetails:   details,
+		Transactions:  transactions,
+		DailyInterest: details.CalculationMethod.InterestCalculator().Calculate(details, transactions),
+		Schedule:      schedule,
+	}, nil
+}
+
+// AddTransaction returns a copy of the loan with the transaction added and the daily interest recalculated, keeping its revision
+func (l Loan) AddTransaction(transaction Transaction) (Loan, error) {
+	loan, err := NewLoan(l.LoanDetails, append(slices.Clone(l.Transactions), transaction))
+	if err != nil {
+		return Loan{}, err
+	}
+
+	loan.Revision = l.Revision
+	return loan, nil
 }
 
 // LoanDetails holds details of a loan
 type LoanDetails struct {
-	ID               string    `json:"id"`                 // ID is the unique identifier for the loan
-	StartDate        time.Time `json:"start_date"`         // StartDate is the the start of the loan period
-	EndDate          time.Time `json:"end_date"`           // EndDate is the end of the loan period
-	Currency         Currency  `json:"currency"`           // Currency is an ISO 4217 3-letter currency code
-	PrincipalAmount  float64   `json:"principal_amount"`   // PrincipalAmount is the initial loan amount
-	BaseInterestRate float64   `json:"base_interest_rate"` // BaseInterestRate represents a percentage for the base interest rate
-	Margin           float64   `json:"margin"`             // Margin is the additional interest on top of the base interest rate
+	ID               string  `json:"id"`                 // ID is the unique identifier for the loan
+	StartDate        Date    `json:"start_date"`         // StartDate is the the start of the loan period
+	EndDate          Date    `json:"end_date"`           // EndDate is the end of the loan period
+	PrincipalAmount  Money   `json:"principal_amount"`   // PrincipalAmount is the initial loan amount and currency
+	BaseInterestRate Decimal `json:"base_interest_rate"` // BaseInterestRate represents a percentage for the base interest rate
+	Margin           Decimal `json:"margin"`             // Margin is the additional interest on top of the base interest rate
+
+	CalculationMethod CalculationMethod `json:"calculation_method"` // CalculationMethod is how interest accrues and compounds, defaulting to daily simple interest
+
+	FloatingRate     *FloatingRate    `json:"floating_rate,omitempty"` // FloatingRate is the base rate fixing timeline for floating rate loans, where BaseInterestRate applies before the first fixing
+	RepaymentProfile RepaymentProfile `json:"repayment_profile"`       // RepaymentProfile is how the principal is repaid, used to generate the amortisation schedule
+
+	DayCountConvention DayCountConvention `json:"day_count_convention"` // DayCountConvention is the convention used to accrue interest, defaulting to ACT/365F
+	RoundingMode       RoundingMode       `json:"rounding_mode"`        // RoundingMode is how interest is rounded to the currency's minor units, defaulting to half-even
+	RoundingPoint      RoundingPoint      `json:"rounding_point"`       // RoundingPoint is whether interest is rounded per day or on the running total, defaulting to per day
+
+	Calendar              Calendar              `json:"calendar,omitempty"`                // Calendar is the holiday calendar business days are taken from, where weekends are never business days
+	BusinessDayConvention BusinessDayConvention `json:"business_day_convention,omitempty"` // BusinessDayConvention is how an end date or payment date on a non-business day is adjusted, leaving them unadjusted when blank
+
+	AccrualBoundaries AccrualBoundaries `json:"accrual_boundaries"` // AccrualBoundaries is whether the start date and end date accrue interest, defaulting to start-inclusive where the start date accrues and the end date does not
+}
+
+// Currency returns the currency the loan is denominated in
+func (l LoanDetails) Currency() Currency {
+	return l.PrincipalAmount.Currency
+}
+
+// Validate validates the loan details given outside of the input form, where blank options take their defaults
+func (l LoanDetails) Validate() error {
+	if !l.EndDate.After(l.StartDate) {
+		return errors.Wrap(ErrInvalidInput, "end date needs to be after start date")
+	}
+	if err := l.Currency().Validate(); err != nil {
+		return err
+	}
+	if l.PrincipalAmount.Amount.Sign() < 0 || l.BaseInterestRate.Sign() < 0 || l.Margin.Sign() < 0 {
+		return errors.Wrap(ErrInvalidInput, "principal amount, base interest rate and margin must not be negative")
+	}
+	if l.PrincipalAmount.Amount.Scale() > l.Currency().MinorUnits() {
+		return errors.Wrapf(ErrInvalidDecimalPlaces, "principal amount should not exceed %d decimal places", l.Currency().MinorUnits())
+	}
+	if l.BaseInterestRate.Scale() > 2 || l.Margin.Scale() > 2 {
+		return errors.Wrap(ErrInvalidDecimalPlaces, "base interest rate and margin should not exceed 2 decimal places")
+	}
+
+	if l.CalculationMethod != "" {
+		if err := l.CalculationMethod.Validate(); err != nil {
+			return err
+		}
+	}
+	if l.DayCountConvention != "" {
+		if err := l.DayCountConvention.Validate(); err != nil {
+			return err
+		}
+	}
+	if l.RoundingMode != "" {
+		if err := l.RoundingMode.Validate(); err != nil {
+			return err
+		}
+	}
+	if l.RoundingPoint != "" {
+		if err := l.RoundingPoint.Validate(); err != nil {
+			return err
+		}
+	}
+	if l.AccrualBoundaries != "" {
+		if err := l.AccrualBoundaries.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := l.validateBusinessDays(); err != nil {
+		return err
+	}
+
+	if l.FloatingRate != nil {
+		return l.FloatingRate.Validate()
+	}
+
+	return nil
 }
 
 // Interest holds information about daily accrued interest from the loan
 type Interest struct {
-	AccrualDate                time.Time `json:"accrual_date"`                  // AccrualDate is the date the interest was accrued
-	DaysElapsed                int       `json:"days_elapsed"`                  // DaysElapsed is the number of days elapsed since the start date of the loan
-	DailyInterestWithoutMargin float64   `json:"daily_interest_without_margin"` // DailyInterestWithoutMargin is the daily interest accrued without the margin
-	DailyInterestAccrued       float64   `json:"daily_interest_accrued"`        // DailyInterestAccrued is the total daily interest accrued
-	TotalInterest              float64   `json:"total_interest"`                // TotalInterest is the total accrued interest calculated over the given period
+	AccrualDate                Date    `json:"accrual_date"`                  // AccrualDate is the date the interest was accrued
+	DaysElapsed                int     `json:"days_elapsed"`                  // DaysElapsed is the number of days elapsed since the start date of the loan
+	Balance                    Money   `json:"balance"`                       // Balance is the outstanding balance interest accrued on for the day
+	BaseInterestRate           Decimal `json:"base_interest_rate"`            // BaseInterestRate is the base rate percentage that applied for the day
+	InterestRate               Decimal `json:"interest_rate"`                 // InterestRate is the all-in rate percentage that applied for the day, after any floor or cap
+	DailyInterestWithoutMargin Money   `json:"daily_interest_without_margin"` // DailyInterestWithoutMargin is the daily interest accrued without the margin
+	DailyInterestAccrued       Money   `json:"daily_interest_accrued"`        // DailyInterestAccrued is the total daily interest accrued
+	TotalInterest              Money   `json:"total_interest"`                // TotalInterest is the total accrued interest calculated over the given period
 }
 
-// LoanRepository is an abstraction on the storage of loans
+// LoanRepository is an abstraction on the storage of loans, where every operation can be cancelled or given a deadline by its context
 type LoanRepository interface {
-	// Create creates a new loan
-	Create(loan Loan) error
+	// Create creates a new loan at revision 1, recording its details as version 1
+	Create(ctx context.Context, loan Loan, change LoanChange) error
+	// CreateBatch creates every one of a batch of new loans or, when any cannot be created, none of them
+	CreateBatch(ctx context.Context, loans []Loan, change LoanChange) error
 	// Loan reads a loan from the store
-	Read(id string) (Loan, error)
-	// List lists all available loans
-	List() map[string]Loan
-	// Update updates the details of an existing loan
-	Update(loan Loan) error
-	// Delete deletes an existing loan
-	Delete(id string) error
-}
-
-// CalculateDailySimpleInterest calculates the daily accrued interest using the daily simple interest formula
-func CalculateDailySimpleInterest(loan LoanDetails) []Interest {
-	totalDays := int(loan.EndDate.Sub(loan.StartDate).Hours() / 24)
-	dailyInterestRateWithoutMargin := dailyInterestRate(loan.BaseInterestRate)
-	dailyInterestRateWithMargin := dailyInterestRate(loan.BaseInterestRate + loan.Margin)
-	dailyInterest := make([]Interest, totalDays)
-	totalInterest := 0.0
-
-	for i := 0; i < totalDays; i++ {
-		dailyInterestWithoutMargin := dailyInterestRateWithoutMargin * loan.PrincipalAmount
-		dailyInterestWithMargin := dailyInterestRateWithMargin * loan.PrincipalAmount
-		totalInterest += dailyInterestWithMargin
-
-		interest := Interest{
-			AccrualDate:                loan.StartDate.Add(time.Duration(i) * 24 * time.Hour),
-			DaysElapsed:                i + 1,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              totalInterest,
-		}
-		dailyInterest[i] = interest
-	}
+	Read(ctx context.Context, id string) (Loan, error)
+	// ReadBatch reads a batch of loans in the order of their IDs, failing when any does not exist
+	ReadBatch(ctx context.Context, ids []string) ([]Loan, error)
+	// List lists the loans matching a query, filtered, ordered and paginated, where the zero query lists every loan ordered by ID
+	List(ctx context.Context, query LoanQuery) (LoanPage, error)
+	// Update updates an existing loan still at the loan's revision, or at any revision when 0, recording a new version when its details change.
+	// The loan is written with the next revision, failing with ErrLoanConflict when it has been written since the revision.
+	Update(ctx context.Context, loan Loan, change LoanChange) error
+	// Delete soft deletes an existing loan still at the revision, or at any revision when 0, keeping it and its versions so it can be undeleted
+	// until it is purged
+	Delete(ctx context.Context, id string, revision int, change LoanChange) error
+	// Versions lists the versions of an existing loan's details, oldest first
+	Versions(ctx context.Context, id string) ([]LoanVersion, error)
+	// Undelete restores a deleted loan at its next revision
+	Undelete(ctx context.Context, id string) error
+	// CreateDeleted creates a new loan already deleted, keeping when, by whom and why it was deleted, such as when restoring a backup
+	CreateDeleted(ctx context.Context, deleted DeletedLoan, change LoanChange) error
+	// Deleted lists all deleted loans that have not been purged, ordered by ID
+	Deleted(ctx context.Context) ([]DeletedLoan, error)
+	// Purge permanently removes a deleted loan along with its versions
+	Purge(ctx context.Context, id string) error
+}
+
+// CalculateDailySimpleInterest calculates the daily accrued interest on the outstanding balance using the daily simple interest formula
+func CalculateDailySimpleInterest(loan LoanDetails, transactions []Transaction) []Interest {
+	return calculateInterest(loan, transactions, func(day accrualDay, totalInterest Decimal) (Decimal, Decimal) {
+		return day.accrue(day.balance, day.baseRate), day.accrue(day.balance, day.allInRate)
+	})
+}
 
-	return dailyInterest
+// roundingMode returns the loan's rounding mode, defaulting to half-even
+func (l LoanDetails) roundingMode() RoundingMode {
+	if l.RoundingMode == "" {
+		return RoundHalfEven
+	}
+	return l.RoundingMode
 }
 
-// dailyInterestRate divides the annual interest rate into a daily amount
-func dailyInterestRate(baseRate float64) float64 {
-	return baseRate / 100 / 365
+// accrueInterest calculates the interest accrued on a principal at an annual percentage rate over days/daysInYear of a year
+func accrueInterest(principal, rate Decimal, days, daysInYear, places int, mode RoundingMode) Decimal {
+	numerator := principal.Mul(rate).Mul(NewDecimal(int64(days), 0))
+	denominator := NewDecimal(int64(100*daysInYear), 0)
+	return numerator.Div(denominator, places, mode)
 }
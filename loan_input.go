@@ -0,0 +1,281 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// loanInput holds loan details as text, such as from flags or an imported file, before being validated
+type loanInput struct {
+	start, end, currency, principal, base, margin string
+	fixings, index, lookback, floor, rateCap      string
+	observationShift                              bool
+	method, dayCount, roundingMode, roundingPoint string
+	repayment, frequency                          string
+	calendar, businessDay, accrual                string
+	instalments                                   []string // instalments are custom instalments as YYYY-MM-DD=principal
+
+	keepFixings bool // keepFixings is whether an existing loan keeps its fixings when no fixings file is given
+}
+
+// newLoanInput creates a loanInput with the options a new loan defaults to
+func newLoanInput() loanInput {
+	return loanInput{
+		method:        CalculationDailySimple,
+		dayCount:      DayCountACT365F,
+		roundingMode:  RoundHalfEven,
+		roundingPoint: RoundPerDay,
+		repayment:     RepaymentBullet,
+		accrual:       AccrualStartInclusive,
+	}
+}
+
+// fields returns the text fields of the input keyed by the name of the equivalent create command flag
+func (f *loanInput) fields() map[string]*string {
+	return map[string]*string{
+		"start":          &f.start,
+		"end":            &f.end,
+		"currency":       &f.currency,
+		"principal":      &f.principal,
+		"base":           &f.base,
+		"margin":         &f.margin,
+		"fixings":        &f.fixings,
+		"index":          &f.index,
+		"lookback":       &f.lookback,
+		"floor":          &f.floor,
+		"cap":            &f.rateCap,
+		"method":         &f.method,
+		"day-count":      &f.dayCount,
+		"rounding":       &f.roundingMode,
+		"rounding-point": &f.roundingPoint,
+		"repayment":      &f.repayment,
+		"frequency":      &f.frequency,
+		"calendar":       &f.calendar,
+		"business-day":   &f.businessDay,
+		"accrual":        &f.accrual,
+	}
+}
+
+// loanDetails validates the input in the same way as the loan details input form, and outputs a LoanDetails struct.
+// When updating, the floating rate fixings and custom instalments of the existing loan are kept unless replaced.
+func (f *loanInput) loanDetails(id string, existing *LoanDetails) (LoanDetails, error) {
+	required := []struct{ name, value string }{
+		{"start", f.start},
+		{"end", f.end},
+		{"currency", f.currency},
+		{"principal", f.principal},
+		{"base", f.base},
+		{"margin", f.margin},
+	}
+	for _, input := range required {
+		if len(input.value) == 0 {
+			return LoanDetails{}, errors.Wrapf(ErrInvalidInput, "%s is required", input.name)
+		}
+	}
+
+	startDate, err := parseDateInput(f.start)
+	if err != nil {
+		return LoanDetails{}, errors.Wrap(err, "start")
+	}
+
+	endDate, err := parseDateInput(f.end)
+	if err != nil {
+		return LoanDetails{}, errors.Wrap(err, "end")
+	}
+	if err := validateDateAfter(endDate, startDate); err != nil {
+		return LoanDetails{}, err
+	}
+
+	loanCurrency, err := parseRequiredOption("currency", f.currency, AllowedCurrencies)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	loanAmount, err := parsePositiveDecimalInput(f.principal, loanCurrency.MinorUnits())
+	if err != nil {
+		return LoanDetails{}, errors.Wrap(err, "principal")
+	}
+
+	baseInterestRate, err := parsePositiveDecimalInput(f.base, 2)
+	if err != nil {
+		return LoanDetails{}, errors.Wrap(err, "base")
+	}
+
+	margin, err := parsePositiveDecimalInput(f.margin, 2)
+	if err != nil {
+		return LoanDetails{}, errors.Wrap(err, "margin")
+	}
+
+	floatingRate, err := f.floatingRate(existing)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	method, err := parseRequiredOption("method", f.method, AllowedCalculationMethods)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	dayCount, err := parseRequiredOption("day-count", f.dayCount, AllowedDayCountConventions)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	roundingMode, err := parseRequiredOption("rounding", f.roundingMode, AllowedRoundingModes)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	roundingPoint, err := parseRequiredOption("rounding-point", f.roundingPoint, AllowedRoundingPoints)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	accrualBoundaries, err := parseRequiredOption("accrual", f.accrual, AllowedAccrualBoundaries)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	businessDay, err := parseOptionInput(f.businessDay, AllowedBusinessDayConventions)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	calendar, err := parseOptionInput(f.calendar, AllowedCalendars)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	principal := NewMoney(loanAmount, loanCurrency)
+	repaymentProfile, err := f.repaymentProfile(startDate, endDate, principal, existing)
+	if err != nil {
+		return LoanDetails{}, err
+	}
+
+	return LoanDetails{
+		ID:               id,
+		StartDate:        startDate,
+		EndDate:          endDate,
+		PrincipalAmount:  principal,
+		BaseInterestRate: baseInterestRate,
+		Margin:           margin,
+
+		FloatingRate:     floatingRate,
+		RepaymentProfile: repaymentProfile,
+
+		CalculationMethod:  method,
+		DayCountConvention: dayCount,
+		RoundingMode:       roundingMode,
+		RoundingPoint:      roundingPoint,
+
+		Calendar:              calendar,
+		BusinessDayConvention: businessDay,
+
+		AccrualBoundaries: accrualBoundaries,
+	}, nil
+}
+
+// floatingRate loads the base rate fixings and floating rate terms, returning nil for a fixed rate loan
+func (f *loanInput) floatingRate(existing *LoanDetails) (*FloatingRate, error) {
+	var fixings []RateFixing
+	switch {
+	case len(f.fixings) > 0:
+		loaded, err := LoadRateFixingsFile(f.fixings)
+		if err != nil {
+			return nil, err
+		}
+		fixings = loaded
+	case f.keepFixings && existing != nil && existing.FloatingRate != nil:
+		fixings = existing.FloatingRate.Fixings
+	default:
+		return nil, nil
+	}
+
+	lookbackDays, err := parseNonNegativeIntInput(f.lookback)
+	if err != nil {
+		return nil, errors.Wrap(err, "lookback")
+	}
+
+	floor, err := parseOptionalDecimalInput(f.floor, 2)
+	if err != nil {
+		return nil, errors.Wrap(err, "floor")
+	}
+
+	rateCap, err := parseOptionalDecimalInput(f.rateCap, 2)
+	if err != nil {
+		return nil, errors.Wrap(err, "cap")
+	}
+
+	floatingRate := &FloatingRate{
+		Index:            f.index,
+		Fixings:          fixings,
+		LookbackDays:     lookbackDays,
+		ObservationShift: f.observationShift && lookbackDays > 0,
+		Floor:            floor,
+		Cap:              rateCap,
+	}
+
+	if err := floatingRate.Validate(); err != nil {
+		return nil, err
+	}
+
+	return floatingRate, nil
+}
+
+// repaymentProfile parses how the loan is repaid, including the instalments of a custom profile
+func (f *loanInput) repaymentProfile(startDate, endDate Date, principal Money, existing *LoanDetails) (RepaymentProfile, error) {
+	repaymentType, err := parseRequiredOption("repayment", f.repayment, AllowedRepaymentTypes)
+	if err != nil {
+		return RepaymentProfile{}, err
+	}
+
+	profile := RepaymentProfile{Type: repaymentType}
+
+	switch repaymentType {
+	case RepaymentCustom:
+		if len(f.instalments) == 0 && existing != nil {
+			profile.CustomInstalments = existing.RepaymentProfile.CustomInstalments
+			break
+		}
+
+		for _, instalment := range f.instalments {
+			dueDate, amount, ok := strings.Cut(instalment, "=")
+			if !ok {
+				return RepaymentProfile{}, errors.Wrapf(ErrInvalidInput, "instalment %q should be YYYY-MM-DD=principal", instalment)
+			}
+
+			date, err := parseDateInput(dueDate)
+			if err != nil {
+				return RepaymentProfile{}, err
+			}
+
+			value, err := parsePositiveDecimalInput(amount, principal.Currency.MinorUnits())
+			if err != nil {
+				return RepaymentProfile{}, errors.Wrapf(err, "instalment %s", dueDate)
+			}
+
+			profile.CustomInstalments = append(profile.CustomInstalments, CustomInstalment{DueDate: date, Principal: NewMoney(value, principal.Currency)})
+		}
+	default:
+		profile.Frequency, err = parseOptionInput(f.frequency, AllowedPaymentFrequencies)
+		if err != nil {
+			return RepaymentProfile{}, err
+		}
+	}
+
+	if err := profile.Validate(LoanDetails{StartDate: startDate, EndDate: endDate, PrincipalAmount: principal}); err != nil {
+		return RepaymentProfile{}, err
+	}
+
+	return profile, nil
+}
+
+// parseRequiredOption parses one of the allowed options given by the named input, which must not be blank
+func parseRequiredOption[T option](name, input string, allowed []T) (T, error) {
+	if len(input) == 0 {
+		return "", errors.Wrapf(ErrInvalidInput, "%s is required", name)
+	}
+
+	return parseOptionInput(input, allowed)
+}
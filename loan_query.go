@@ -0,0 +1,216 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	LoanSortID        = "id"
+	LoanSortCurrency  = "currency"
+	LoanSortPrincipal = "principal"
+	LoanSortRate      = "rate"
+	LoanSortStart     = "start"
+	LoanSortMaturity  = "maturity"
+)
+
+var AllowedLoanSorts = []LoanSort{
+	LoanSortID,
+	LoanSortCurrency,
+	LoanSortPrincipal,
+	LoanSortRate,
+	LoanSortStart,
+	LoanSortMaturity,
+}
+
+// LoanSort holds the loan field a list of loans is sorted by
+type LoanSort string
+
+// String stringifies the loan sort
+func (l LoanSort) String() string {
+	return string(l)
+}
+
+// Validate validates whether the loan sort is supported
+func (l LoanSort) Validate() error {
+	if ok := slices.Contains(AllowedLoanSorts, l); !ok {
+		return ErrInvalidLoanSort
+	}
+
+	return nil
+}
+
+// LoanQuery selects, orders and paginates the loans listed by a LoanRepository, where the zero query lists every loan ordered by ID
+type LoanQuery struct {
+	Currency       Currency // Currency selects the loans in a currency, or blank for every currency
+	MinPrincipal   *Decimal // MinPrincipal selects the loans with a principal amount of at least the amount, or nil for no minimum
+	MaxPrincipal   *Decimal // MaxPrincipal selects the loans with a principal amount of at most the amount, or nil for no maximum
+	MinRate        *Decimal // MinRate selects the loans with an all-in rate percentage of at least the rate, or nil for no minimum
+	MaxRate        *Decimal // MaxRate selects the loans with an all-in rate percentage of at most the rate, or nil for no maximum
+	ActiveOn       Date     // ActiveOn selects the loans running from their start date to their maturity date on the date, or the zero date for any
+	MaturingBefore Date     // MaturingBefore selects the loans maturing before the date, or the zero date for any
+	Sort           LoanSort // Sort is the field loans are ordered by, then by ID, defaulting to ID
+	Descending     bool     // Descending orders the loans from the highest value of the sort field
+	Page           int      // Page is the 1-based page of loans listed, defaulting to the first
+	PageSize       int      // PageSize is the number of loans per page, or 0 to list every loan on one page
+}
+
+// LoanPage holds a page of the loans matched by a query
+type LoanPage struct {
+	Loans []Loan `json:"loans"` // Loans are the loans on the page
+	Total int    `json:"total"` // Total is the number of loans matched across every page
+	Page  int    `json:"page"`  // Page is the 1-based number of the page
+	Pages int    `json:"pages"` // Pages is the number of pages
+}
+
+// Validate validates the ranges, sort and page of the query
+func (q LoanQuery) Validate() error {
+	if q.MinPrincipal != nil && q.MaxPrincipal != nil && q.MaxPrincipal.Cmp(*q.MinPrincipal) < 0 {
+		return errors.Wrap(ErrInvalidInput, "max principal must not be less than min principal")
+	}
+	if q.MinRate != nil && q.MaxRate != nil && q.MaxRate.Cmp(*q.MinRate) < 0 {
+		return errors.Wrap(ErrInvalidInput, "max rate must not be less than min rate")
+	}
+	if q.Sort != "" {
+		if err := q.Sort.Validate(); err != nil {
+			return err
+		}
+	}
+	if q.Page < 0 || q.PageSize < 0 {
+		return errors.Wrap(ErrInvalidInput, "page and page size must not be negative")
+	}
+
+	return nil
+}
+
+// Matches returns whether a loan is selected by every filter of the query
+func (q LoanQuery) Matches(loan Loan) bool {
+	details := loan.LoanDetails
+	principal := details.PrincipalAmount.Amount
+	rate := details.headlineRate()
+
+	switch {
+	case q.Currency != "" && details.Currency() != q.Currency:
+		return false
+	case q.MinPrincipal != nil && principal.Cmp(*q.MinPrincipal) < 0:
+		return false
+	case q.MaxPrincipal != nil && principal.Cmp(*q.MaxPrincipal) > 0:
+		return false
+	case q.MinRate != nil && rate.Cmp(*q.MinRate) < 0:
+		return false
+	case q.MaxRate != nil && rate.Cmp(*q.MaxRate) > 0:
+		return false
+	case !q.ActiveOn.IsZero() && !details.runningOn(q.ActiveOn):
+		return false
+	case !q.MaturingBefore.IsZero() && !details.MaturityDate().Before(q.MaturingBefore):
+		return false
+	}
+
+	return true
+}
+
+// Apply filters, orders and paginates loans in any order, leaving the loans given untouched
+func (q LoanQuery) Apply(loans []Loan) LoanPage {
+	matched := make([]Loan, 0, len(loans))
+	for _, loan := range loans {
+		if q.Matches(loan) {
+			matched = append(matched, loan)
+		}
+	}
+
+	slices.SortFunc(matched, func(a, b Loan) int {
+		order := q.compare(a.LoanDetails, b.LoanDetails)
+		if order == 0 {
+			order = strings.Compare(a.LoanDetails.ID, b.LoanDetails.ID)
+		}
+		if q.Descending {
+			return -order
+		}
+		return order
+	})
+
+	page := max(q.Page, 1)
+	loansOnPage, pages := paginate(matched, page, q.PageSize)
+
+	return LoanPage{Loans: loansOnPage, Total: len(matched), Page: page, Pages: pages}
+}
+
+// compare compares two loans by the sort field of the query
+func (q LoanQuery) compare(a, b LoanDetails) int {
+	switch q.Sort {
+	case LoanSortCurrency:
+		return strings.Compare(a.Currency().String(), b.Currency().String())
+	case LoanSortPrincipal:
+		return a.PrincipalAmount.Amount.Cmp(b.PrincipalAmount.Amount)
+	case LoanSortRate:
+		return a.headlineRate().Cmp(b.headlineRate())
+	case LoanSortStart:
+		return a.StartDate.Compare(b.StartDate)
+	case LoanSortMaturity:
+		return a.MaturityDate().Compare(b.MaturityDate())
+	default:
+		return strings.Compare(a.ID, b.ID)
+	}
+}
+
+// headlineRate returns the all-in rate percentage that applies on the loan's start date, which loans are filtered and sorted by
+func (l LoanDetails) headlineRate() Decimal {
+	_, rate := l.interestRates(l.StartDate)
+	return rate
+}
+
+// loanQueryInput holds the unparsed filters, sort and page of a loan query, named after the list command flags
+type loanQueryInput struct {
+	currency       string
+	minPrincipal   string
+	maxPrincipal   string
+	minRate        string
+	maxRate        string
+	activeOn       string
+	maturingBefore string
+	sort           string
+	descending     bool
+	page           string
+	pageSize       string
+}
+
+// query validates the input in the same way as the other loan inputs, and outputs a LoanQuery
+func (i loanQueryInput) query() (LoanQuery, error) {
+	query := LoanQuery{Currency: Currency(strings.ToUpper(strings.TrimSpace(i.currency))), Descending: i.descending}
+
+	var err error
+	if query.Currency != "" && !query.Currency.registered() {
+		return LoanQuery{}, errors.Wrapf(ErrInvalidCurrency, "unknown currency %q", i.currency)
+	}
+	if query.MinPrincipal, err = parseOptionalDecimalInput(i.minPrincipal, calculationPrecision); err != nil {
+		return LoanQuery{}, errors.Wrap(err, "min-principal")
+	}
+	if query.MaxPrincipal, err = parseOptionalDecimalInput(i.maxPrincipal, calculationPrecision); err != nil {
+		return LoanQuery{}, errors.Wrap(err, "max-principal")
+	}
+	if query.MinRate, err = parseOptionalDecimalInput(i.minRate, calculationPrecision); err != nil {
+		return LoanQuery{}, errors.Wrap(err, "min-rate")
+	}
+	if query.MaxRate, err = parseOptionalDecimalInput(i.maxRate, calculationPrecision); err != nil {
+		return LoanQuery{}, errors.Wrap(err, "max-rate")
+	}
+	if query.ActiveOn, err = parseOptionalDateInput(i.activeOn); err != nil {
+		return LoanQuery{}, errors.Wrap(err, "active-on")
+	}
+	if query.MaturingBefore, err = parseOptionalDateInput(i.maturingBefore); err != nil {
+		return LoanQuery{}, errors.Wrap(err, "maturing-before")
+	}
+	if query.Sort, err = parseOptionInput(i.sort, AllowedLoanSorts); err != nil {
+		return LoanQuery{}, err
+	}
+	if query.Page, err = parseNonNegativeIntInput(i.page); err != nil {
+		return LoanQuery{}, errors.Wrap(err, "page")
+	}
+	if query.PageSize, err = parseNonNegativeIntInput(i.pageSize); err != nil {
+		return LoanQuery{}, errors.Wrap(err, "page-size")
+	}
+
+	return query, query.Validate()
+}
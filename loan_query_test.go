@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestLoanQueryApply(t *testing.T) {
+	var loans []Loan
+	for _, loan := range []struct {
+		id        string
+		currency  Currency
+		principal int64
+		margin    int64
+		start     string
+		end       string
+	}{
+		{id: "a", currency: CurrencyGBP, principal: 1000, margin: 1, start: "2024-01-01", end: "2024-06-30"},
+		{id: "b", currency: CurrencyEUR, principal: 5000, margin: 3, start: "2024-03-01", end: "2025-03-01"},
+		{id: "c", currency: CurrencyGBP, principal: 2500, margin: 2, start: "2024-07-01", end: "2024-12-31"},
+		{id: "d", currency: CurrencyGBP, principal: 5000, margin: 0, start: "2023-01-01", end: "2024-01-01"},
+	} {
+		details := testLoanDetails(t)
+		details.ID = loan.id
+		details.PrincipalAmount = NewMoney(NewDecimal(loan.principal, 0), loan.currency)
+		details.Margin = NewDecimal(loan.margin, 0)
+		details.StartDate, _ = ParseDate(loan.start)
+		details.EndDate, _ = ParseDate(loan.end)
+		loans = append(loans, Loan{LoanDetails: details})
+	}
+
+	minPrincipal, maxRate := NewDecimal(2000, 0), NewDecimal(7, 0)
+	activeOn, _ := ParseDate("2024-04-01")
+	maturingBefore, _ := ParseDate("2024-12-31")
+
+	tests := []struct {
+		name  string
+		query LoanQuery
+		want  []string
+		total int
+		pages int
+	}{
+		{name: "every loan by id", query: LoanQuery{}, want: []string{"a", "b", "c", "d"}, total: 4, pages: 1},
+		{name: "currency", query: LoanQuery{Currency: CurrencyGBP}, want: []string{"a", "c", "d"}, total: 3, pages: 1},
+		{name: "principal and rate range", query: LoanQuery{MinPrincipal: &minPrincipal, MaxRate: &maxRate}, want: []string{"c", "d"}, total: 2, pages: 1},
+		{name: "active on", query: LoanQuery{ActiveOn: activeOn}, want: []string{"a", "b"}, total: 2, pages: 1},
+		{name: "maturing before", query: LoanQuery{MaturingBefore: maturingBefore}, want: []string{"a", "d"}, total: 2, pages: 1},
+		{name: "sort by principal descending with ties by id", query: LoanQuery{Sort: LoanSortPrincipal, Descending: true}, want: []string{"d", "b", "c", "a"}, total: 4, pages: 1},
+		{name: "sort by rate", query: LoanQuery{Sort: LoanSortRate}, want: []string{"d", "a", "c", "b"}, total: 4, pages: 1},
+		{name: "sort by maturity", query: LoanQuery{Sort: LoanSortMaturity}, want: []string{"d", "a", "c", "b"}, total: 4, pages: 1},
+		{name: "second page", query: LoanQuery{Sort: LoanSortStart, Page: 2, PageSize: 3}, want: []string{"c"}, total: 4, pages: 2},
+		{name: "page after the last", query: LoanQuery{Page: 3, PageSize: 3}, want: []string{}, total: 4, pages: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := tt.query.Apply(loans)
+
+			ids := []string{}
+			for _, loan := range page.Loans {
+				ids = append(ids, loan.LoanDetails.ID)
+			}
+			if !slices.Equal(ids, tt.want) || page.Total != tt.total || page.Pages != tt.pages {
+				t.Errorf("Unexpected page. got %v (total %d, pages %d), want %v (total %d, pages %d)", ids, page.Total, page.Pages, tt.want, tt.total, tt.pages)
+			}
+		})
+	}
+
+	if loans[0].LoanDetails.ID != "a" || loans[3].LoanDetails.ID != "d" {
+		t.Errorf("Expected Apply to leave the loans given untouched")
+	}
+}
+
+func TestLoanQueryInputErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input loanQueryInput
+		err   error
+	}{
+		{name: "unknown currency", input: loanQueryInput{currency: "XYZ"}, err: ErrInvalidCurrency},
+		{name: "invalid principal", input: loanQueryInput{minPrincipal: "lots"}, err: ErrInvalidDecimal},
+		{name: "max principal below min", input: loanQueryInput{minPrincipal: "100", maxPrincipal: "10"}, err: ErrInvalidInput},
+		{name: "max rate below min", input: loanQueryInput{minRate: "5", maxRate: "1"}, err: ErrInvalidInput},
+		{name: "invalid date", input: loanQueryInput{activeOn: "today"}, err: ErrInvalidInput},
+		{name: "invalid sort", input: loanQueryInput{sort: "name"}, err: ErrInvalidLoanSort},
+		{name: "negative page size", input: loanQueryInput{pageSize: "-1"}, err: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.input.query(); !errors.Is(err, tt.err) {
+				t.Errorf("Unexpected error. got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// listLoans lists every loan in a repository ordered by ID, failing the test when they cannot be listed
+func listLoans(t *testing.T, repo LoanRepository) []Loan {
+	t.Helper()
+
+	page, err := repo.List(context.Background(), LoanQuery{})
+	if err != nil {
+		t.Fatalf("Unexpected error in List: %v", err)
+	}
+
+	return page.Loans
+}
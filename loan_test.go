@@ -1,48 +1,16 @@
 package main
 
 import (
-	"math"
 	"testing"
-	"time"
 )
 
-func TestCurrencySymbol(t *testing.T) {
-	currencies := map[Currency]string{
-		CurrencyEUR: "€",
-		CurrencyGBP: "£",
-		CurrencyUSD: "$",
-	}
-
-	for currency, symbol := range currencies {
-		if currency.Symbol() != symbol {
-			t.Errorf("Incorrect currency->symbol mapping for %s. Got %s, want %s", currency, currency.Symbol(), symbol)
-		}
-
-		if err := currency.Validate(); err != nil {
-			t.Errorf("Unexpected error while validating currency %s: %v", currency, err)
-		}
-	}
-
-	invalidCurrency := Currency("someRandomCurrency")
-	err := invalidCurrency.Validate()
-	if err == nil {
-		t.Errorf("Expected error while validating an invalid currency but got none")
-	}
-
-	if symbol := invalidCurrency.Symbol(); symbol != "" {
-		t.Errorf("Expected empty symbol from an invalid currency but got %s", symbol)
-	}
-}
-
 func TestCalculateDailySimpleInterest(t *testing.T) {
-	const tolerance = 1e-9
-
-	startDate, err := time.Parse("2006-01-02", "2024-01-01")
+	startDate, err := ParseDate("2024-01-01")
 	if err != nil {
 		t.Errorf("Unexpected error parsing time: %v", err)
 	}
 
-	endDate, err := time.Parse("2006-01-02", "2024-01-11")
+	endDate, err := ParseDate("2024-01-11")
 	if err != nil {
 		t.Errorf("Unexpected error parsing time: %v", err)
 	}
@@ -50,89 +18,89 @@ func TestCalculateDailySimpleInterest(t *testing.T) {
 	loan := LoanDetails{
 		StartDate:        startDate,
 		EndDate:          endDate,
-		Currency:         CurrencyEUR,
-		PrincipalAmount:  1000,
-		BaseInterestRate: 10,
-		Margin:           1,
+		PrincipalAmount:  NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+		BaseInterestRate: NewDecimal(10, 0),
+		Margin:           NewDecimal(1, 0),
 	}
 
-	dailyInterestWithoutMargin := loan.BaseInterestRate / 100 / 365 * loan.PrincipalAmount
-	dailyInterestWithMargin := (loan.BaseInterestRate + loan.Margin) / 100 / 365 * loan.PrincipalAmount
+	// 1000 * 10% / 365 = 0.27397..., 1000 * 11% / 365 = 0.30136..., rounded half-even per day
+	dailyInterestWithoutMargin := NewDecimal(27, 2)
+	dailyInterestWithMargin := NewDecimal(30, 2)
 
 	expectedDailyInterest := []Interest{
 		{
 			AccrualDate:                startDate,
 			DaysElapsed:                1,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(1, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(1 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 1),
 			DaysElapsed:                2,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 2,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(2, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(2 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 2),
 			DaysElapsed:                3,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 3,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(3, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(3 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 3),
 			DaysElapsed:                4,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 4,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(4, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(4 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 4),
 			DaysElapsed:                5,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 5,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(5, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(5 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 5),
 			DaysElapsed:                6,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 6,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(6, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(6 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 6),
 			DaysElapsed:                7,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 7,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(7, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(7 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 7),
 			DaysElapsed:                8,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 8,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(8, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(8 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 8),
 			DaysElapsed:                9,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 9,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(9, 0)), CurrencyEUR),
 		},
 		{
-			AccrualDate:                startDate.Add(9 * 24 * time.Hour),
+			AccrualDate:                startDate.AddDate(0, 0, 9),
 			DaysElapsed:                10,
-			DailyInterestWithoutMargin: dailyInterestWithoutMargin,
-			DailyInterestAccrued:       dailyInterestWithMargin,
-			TotalInterest:              dailyInterestWithMargin * 10,
+			DailyInterestWithoutMargin: NewMoney(dailyInterestWithoutMargin, CurrencyEUR),
+			DailyInterestAccrued:       NewMoney(dailyInterestWithMargin, CurrencyEUR),
+			TotalInterest:              NewMoney(dailyInterestWithMargin.Mul(NewDecimal(10, 0)), CurrencyEUR),
 		},
 	}
 
-	dailyInterest := CalculateDailySimpleInterest(loan)
+	dailyInterest := CalculateDailySimpleInterest(loan, nil)
 
 	if len(dailyInterest) != len(expectedDailyInterest) {
 		t.Errorf("Daily interest returned more entries than expected. got %d, want %d", len(dailyInterest), len(expectedDailyInterest))
@@ -147,14 +115,52 @@ func TestCalculateDailySimpleInterest(t *testing.T) {
 		if interest.DaysElapsed != expected.DaysElapsed {
 			t.Errorf("Unexpected `days elapsed` in daily interest. got %v, expected %v", interest.DaysElapsed, expected.DaysElapsed)
 		}
-		if math.Abs(interest.DailyInterestWithoutMargin-expected.DailyInterestWithoutMargin) > tolerance {
-			t.Errorf("Unexpected `daily interest without margin` in daily interest out of tolerance. got %v, expected %v", interest.DailyInterestWithoutMargin, expected.DailyInterestWithoutMargin)
+		if interest.DailyInterestWithoutMargin.Amount.Cmp(expected.DailyInterestWithoutMargin.Amount) != 0 {
+			t.Errorf("Unexpected `daily interest without margin` in daily interest. got %v, expected %v", interest.DailyInterestWithoutMargin, expected.DailyInterestWithoutMargin)
+		}
+		if interest.DailyInterestAccrued.Amount.Cmp(expected.DailyInterestAccrued.Amount) != 0 {
+			t.Errorf("Unexpected `daily interest accrued` in daily interest. got %v, expected %v", interest.DailyInterestAccrued, expected.DailyInterestAccrued)
+		}
+		if interest.TotalInterest.Amount.Cmp(expected.TotalInterest.Amount) != 0 {
+			t.Errorf("Unexpected `total interest` in daily interest. got %v, expected %v", interest.TotalInterest, expected.TotalInterest)
 		}
-		if math.Abs(interest.DailyInterestAccrued-expected.DailyInterestAccrued) > tolerance {
-			t.Errorf("Unexpected `daily interest accrued` in daily interest out of tolerance. got %v, expected %v", interest.DailyInterestAccrued, expected.DailyInterestAccrued)
+	}
+}
+
+func TestCalculateDailySimpleInterestRounding(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-01-11")
+
+	tests := []struct {
+		mode          RoundingMode
+		point         RoundingPoint
+		dailyInterest string
+		totalInterest string
+	}{
+		{RoundHalfEven, RoundPerDay, "0.30", "3.00"},
+		{RoundHalfUp, RoundPerDay, "0.30", "3.00"},
+		{RoundTruncate, RoundPerDay, "0.30", "3.00"},
+		{RoundHalfEven, RoundOnTotal, "0.3013698630", "3.01"},
+		{RoundTruncate, RoundOnTotal, "0.3013698630", "3.01"},
+	}
+
+	for _, test := range tests {
+		dailyInterest := CalculateDailySimpleInterest(LoanDetails{
+			StartDate:        startDate,
+			EndDate:          endDate,
+			PrincipalAmount:  NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+			BaseInterestRate: NewDecimal(10, 0),
+			Margin:           NewDecimal(1, 0),
+			RoundingMode:     test.mode,
+			RoundingPoint:    test.point,
+		}, nil)
+
+		last := dailyInterest[len(dailyInterest)-1]
+		if got := last.DailyInterestAccrued.Amount.String(); got != test.dailyInterest {
+			t.Errorf("Unexpected daily interest rounding %s %s. got %s, want %s", test.mode, test.point, got, test.dailyInterest)
 		}
-		if math.Abs(interest.TotalInterest-expected.TotalInterest) > tolerance {
-			t.Errorf("Unexpected `total interest` in daily interest out of tolerance. got %v, expected %v", interest.TotalInterest, expected.TotalInterest)
+		if got := last.TotalInterest.Amount.String(); got != test.totalInterest {
+			t.Errorf("Unexpected total interest rounding %s %s. got %s, want %s", test.mode, test.point, got, test.totalInterest)
 		}
 	}
 }
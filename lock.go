@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	lockRetryDelay    = time.Millisecond       // lockRetryDelay is the delay before the first retry of a lock held by another process
+	lockMaxRetryDelay = 100 * time.Millisecond // lockMaxRetryDelay is the longest delay between retries of a lock held by another process
+)
+
+// retryLock calls tryLock until it takes the lock, backing off between attempts while the lock is held elsewhere, unless the
+// context is done first
+func retryLock(ctx context.Context, tryLock func() (bool, error)) error {
+	delay := lockRetryDelay
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		locked, err := tryLock()
+		if err != nil || locked {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(2*delay, lockMaxRetryDelay)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"os"
+	"syscall"
+)
+
+// lockFile waits until an advisory lock on the file is held, shared between readers or exclusive to a single writer,
+// unless the context is done first
+func lockFile(ctx context.Context, file *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH | syscall.LOCK_NB
+	if exclusive {
+		how = syscall.LOCK_EX | syscall.LOCK_NB
+	}
+
+	return retryLock(ctx, func() (bool, error) {
+		for {
+			switch err := syscall.Flock(int(file.Fd()), how); err {
+			case nil:
+				return true, nil
+			case syscall.EINTR:
+				continue
+			case syscall.EWOULDBLOCK:
+				return false, nil
+			default:
+				return false, err
+			}
+		}
+	})
+}
+
+// unlockFile releases an advisory lock on the file
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// syncDir syncs a directory so that renames within it are durable
+func syncDir(path string) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer dir.Close()
+
+	_ = dir.Sync()
+}
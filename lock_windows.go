@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile waits until a lock on the file is held, shared between readers or exclusive to a single writer,
+// unless the context is done first
+func lockFile(ctx context.Context, file *os.File, exclusive bool) error {
+	var flags uint32 = windows.LOCKFILE_FAIL_IMMEDIATELY
+	if exclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+
+	return retryLock(ctx, func() (bool, error) {
+		err := windows.LockFileEx(windows.Handle(file.Fd()), flags, 0, 1, 0, &windows.Overlapped{})
+		if err == windows.ERROR_LOCK_VIOLATION {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
+
+// unlockFile releases a lock on the file
+func unlockFile(file *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
+
+// syncDir is a no-op on Windows, where renames are durable once the file itself has been synced
+func syncDir(path string) {}
@@ -1,11 +1,95 @@
 package main
 
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+
+	_ "modernc.org/sqlite"
+)
+
 // main is the entrypoint to the program
 func main() {
-	loanRepository := NewInMemoryLoanRepository()
+	storage := flag.String("storage", "memory", "where loans are stored: memory, file or sqlite")
+	path := flag.String("path", "loans.jsonl", "path of the loan journal or SQLite database when using file or sqlite storage")
+	calendars := flag.String("calendars", "calendars", "directory of holiday calendar files, such as UK.txt, used for business day adjustments")
+	changedBy := flag.String("user", currentUsername(), "user that changes to loans are recorded against in their version history")
+	retentionDays := flag.Int("retention", 0, "days deleted loans are kept before purge removes them permanently, or 0 to keep them forever")
+	allowedCurrencies := flag.String("currencies", "", "comma separated ISO 4217 currency codes loans can be made in, such as GBP,CHF,JPY, all for every currency, or blank for EUR, GBP and USD")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), commandUsage, "\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := LoadHolidayCalendars(*calendars); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if err := AllowCurrencies(*allowedCurrencies); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
 
-	cli := NewCLI(loanRepository)
-	if err := cli.DrawMenu(); err != nil {
+	loanRepository, err := newLoanRepository(*storage, *path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	ctx := context.Background()
+	cli := NewCLI(loanRepository, *changedBy, RetentionPolicy{Days: *retentionDays})
+
+	// with a command given the calculator runs it and exits, otherwise it draws the interactive menu
+	if flag.NArg() > 0 {
+		os.Exit(cli.RunCommand(ctx, flag.Args()))
+	}
+
+	if err := cli.DrawMenu(ctx); err != nil {
 		panic(err)
 	}
 }
+
+// currentUsername returns the username of the user running the calculator, or blank when it cannot be found
+func currentUsername() string {
+	current, err := user.Current()
+	if err != nil {
+		return ""
+	}
+
+	return current.Username
+}
+
+// newLoanRepository creates the LoanRepository for the chosen storage backend
+func newLoanRepository(storage, path string) (LoanRepository, error) {
+	switch storage {
+	case "memory":
+		return NewInMemoryLoanRepository(), nil
+	case "file":
+		return NewJournalLoanRepository(path)
+	case "sqlite":
+		return openSQLiteLoanRepository(path)
+	default:
+		return nil, ErrInvalidStorage
+	}
+}
+
+// openSQLiteLoanRepository opens the SQLite database at path, creating it if needed, as a LoanRepository
+func openSQLiteLoanRepository(path string) (*sqlLoanRepository, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := NewSQLLoanRepository(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return repo, nil
+}
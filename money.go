@@ -0,0 +1,45 @@
+package main
+
+// Money holds an exact decimal amount in a given currency
+type Money struct {
+	Amount   Decimal  `json:"amount"`   // Amount is the exact decimal amount, serialised as a string
+	Currency Currency `json:"currency"` // Currency is an ISO 4217 3-letter currency code
+}
+
+// NewMoney creates a new Money from an amount and a currency
+func NewMoney(amount Decimal, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Add returns the sum of two amounts of money in the same currency
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount.Add(other.Amount), Currency: m.Currency}
+}
+
+// Sub returns the difference between two amounts of money in the same currency
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount.Sub(other.Amount), Currency: m.Currency}
+}
+
+// Round rounds the amount to the currency's minor units using the given rounding mode
+func (m Money) Round(mode RoundingMode) Money {
+	return Money{Amount: m.Amount.Round(m.Currency.MinorUnits(), mode), Currency: m.Currency}
+}
+
+// IsZero returns whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// String stringifies the money with the currency symbol, e.g. €1000.00, or its code for currencies without one, e.g. CHF 1000.00
+func (m Money) String() string {
+	symbol := m.Currency.Symbol()
+	if len(symbol) == 0 {
+		symbol = m.Currency.String() + " "
+	}
+
+	if m.Amount.Sign() < 0 {
+		return "-" + symbol + m.Amount.Neg().String()
+	}
+	return symbol + m.Amount.String()
+}
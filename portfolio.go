@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PortfolioLoan holds the exposure of a single loan as of a valuation date, in its own currency and the reporting currency
+type PortfolioLoan struct {
+	LoanID                   string   `json:"loan_id"`                    // LoanID is the ID of the loan
+	Currency                 Currency `json:"currency"`                   // Currency is the currency of the loan
+	FXRate                   Decimal  `json:"fx_rate"`                    // FXRate is the rate converting the loan currency to the reporting currency on the valuation date
+	Principal                Money    `json:"principal"`                  // Principal is the outstanding principal after transactions effective on or before the valuation date, or zero when the loan is not running
+	AccruedInterest          Money    `json:"accrued_interest"`           // AccruedInterest is the interest accrued up to and including the valuation date, or zero when the loan is not running
+	ReportingPrincipal       Money    `json:"reporting_principal"`        // ReportingPrincipal is the outstanding principal in the reporting currency
+	ReportingAccruedInterest Money    `json:"reporting_accrued_interest"` // ReportingAccruedInterest is the accrued interest in the reporting currency
+}
+
+// PortfolioCurrency holds the total exposure of the loans in a single currency
+type PortfolioCurrency struct {
+	Currency                 Currency `json:"currency"`                   // Currency is the currency of the loans
+	Loans                    int      `json:"loans"`                      // Loans is the number of loans in the currency
+	Principal                Money    `json:"principal"`                  // Principal is the outstanding principal of the loans
+	AccruedInterest          Money    `json:"accrued_interest"`           // AccruedInterest is the interest accrued on the loans
+	ReportingPrincipal       Money    `json:"reporting_principal"`        // ReportingPrincipal is the sum of the loans' outstanding principal in the reporting currency
+	ReportingAccruedInterest Money    `json:"reporting_accrued_interest"` // ReportingAccruedInterest is the sum of the loans' accrued interest in the reporting currency
+}
+
+// PortfolioReport holds the exposure of every loan as of a valuation date, totalled by currency and in aggregate in a reporting currency
+type PortfolioReport struct {
+	ReportingCurrency    Currency            `json:"reporting_currency"`     // ReportingCurrency is the currency every amount is converted to
+	ValuationDate        Date                `json:"valuation_date"`         // ValuationDate is the date exposure is measured and converted on
+	Loans                []PortfolioLoan     `json:"loans"`                  // Loans are the loans in the order they were given
+	Currencies           []PortfolioCurrency `json:"currencies"`             // Currencies are the totals of each loan currency ordered by code
+	TotalPrincipal       Money               `json:"total_principal"`        // TotalPrincipal is the outstanding principal of every loan in the reporting currency
+	TotalAccruedInterest Money               `json:"total_accrued_interest"` // TotalAccruedInterest is the accrued interest of every loan in the reporting currency
+	TotalExposure        Money               `json:"total_exposure"`         // TotalExposure is the total principal plus accrued interest in the reporting currency
+}
+
+// NewPortfolioReport reports the exposure of loans as of a valuation date, converting each loan to the reporting currency with the FX rates.
+// Converted amounts are rounded per loan, so the currency and aggregate totals add up to the loans they are made of.
+func NewPortfolioReport(loans []Loan, reportingCurrency Currency, valuationDate Date, rates *FXRates) (PortfolioReport, error) {
+	if !reportingCurrency.registered() {
+		return PortfolioReport{}, errors.Wrapf(ErrInvalidCurrency, "unknown reporting currency %q", reportingCurrency)
+	}
+
+	zero := NewMoney(NewDecimal(0, reportingCurrency.MinorUnits()), reportingCurrency)
+	report := PortfolioReport{
+		ReportingCurrency:    reportingCurrency,
+		ValuationDate:        valuationDate,
+		Loans:                make([]PortfolioLoan, 0, len(loans)),
+		TotalPrincipal:       zero,
+		TotalAccruedInterest: zero,
+	}
+
+	byCurrency := map[Currency]*PortfolioCurrency{}
+	for _, loan := range loans {
+		portfolioLoan, err := newPortfolioLoan(loan, reportingCurrency, valuationDate, rates)
+		if err != nil {
+			return PortfolioReport{}, errors.Wrapf(err, "loan %s", loan.LoanDetails.ID)
+		}
+		report.Loans = append(report.Loans, portfolioLoan)
+
+		total, ok := byCurrency[portfolioLoan.Currency]
+		if !ok {
+			currencyZero := NewMoney(NewDecimal(0, portfolioLoan.Currency.MinorUnits()), portfolioLoan.Currency)
+			total = &PortfolioCurrency{
+				Currency:                 portfolioLoan.Currency,
+				Principal:                currencyZero,
+				AccruedInterest:          currencyZero,
+				ReportingPrincipal:       zero,
+				ReportingAccruedInterest: zero,
+			}
+			byCurrency[portfolioLoan.Currency] = total
+		}
+		total.Loans++
+		total.Principal = total.Principal.Add(portfolioLoan.Principal)
+		total.AccruedInterest = total.AccruedInterest.Add(portfolioLoan.AccruedInterest)
+		total.ReportingPrincipal = total.ReportingPrincipal.Add(portfolioLoan.ReportingPrincipal)
+		total.ReportingAccruedInterest = total.ReportingAccruedInterest.Add(portfolioLoan.ReportingAccruedInterest)
+
+		report.TotalPrincipal = report.TotalPrincipal.Add(portfolioLoan.ReportingPrincipal)
+		report.TotalAccruedInterest = report.TotalAccruedInterest.Add(portfolioLoan.ReportingAccruedInterest)
+	}
+	report.TotalExposure = report.TotalPrincipal.Add(report.TotalAccruedInterest)
+
+	report.Currencies = make([]PortfolioCurrency, 0, len(byCurrency))
+	for _, total := range byCurrency {
+		report.Currencies = append(report.Currencies, *total)
+	}
+	slices.SortFunc(report.Currencies, func(a, b PortfolioCurrency) int {
+		return strings.Compare(a.Currency.String(), b.Currency.String())
+	})
+
+	return report, nil
+}
+
+// newPortfolioLoan measures the exposure of a loan as of a valuation date and converts it to the reporting currency
+func newPortfolioLoan(loan Loan, reportingCurrency Currency, valuationDate Date, rates *FXRates) (PortfolioLoan, error) {
+	currency := loan.LoanDetails.Currency()
+	rate, err := rates.Rate(currency, reportingCurrency, valuationDate)
+	if err != nil {
+		return PortfolioLoan{}, err
+	}
+
+	principal := loan.principalOn(valuationDate).Round(loan.LoanDetails.roundingMode())
+	accrued := NewMoney(NewDecimal(0, currency.MinorUnits()), currency)
+	if loan.LoanDetails.runningOn(valuationDate) {
+		accrued = loan.totalInterestOn(valuationDate)
+	}
+
+	return PortfolioLoan{
+		LoanID:                   loan.LoanDetails.ID,
+		Currency:                 currency,
+		FXRate:                   rate,
+		Principal:                principal,
+		AccruedInterest:          accrued,
+		ReportingPrincipal:       NewMoney(principal.Amount.Mul(rate), reportingCurrency).Round(RoundHalfEven),
+		ReportingAccruedInterest: NewMoney(accrued.Amount.Mul(rate), reportingCurrency).Round(RoundHalfEven),
+	}, nil
+}
+
+// principalOn returns the outstanding principal after the transactions effective on or before a date,
+// or zero when the loan has not started or has matured by the date
+func (l Loan) principalOn(date Date) Money {
+	principal := l.LoanDetails.PrincipalAmount
+	if !l.LoanDetails.runningOn(date) {
+		return NewMoney(NewDecimal(0, principal.Currency.MinorUnits()), principal.Currency)
+	}
+	for _, transaction := range l.Transactions {
+		if !transaction.EffectiveDate.After(date) {
+			principal.Amount = principal.Amount.Add(transaction.BalanceChange())
+		}
+	}
+
+	return principal
+}
+
+// WritePortfolioJSON writes a portfolio report as indented JSON
+func WritePortfolioJSON(w io.Writer, report PortfolioReport) error {
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WritePortfolioCSV writes a portfolio report as CSV blocks of the aggregate totals, the totals by currency and then each loan
+func WritePortfolioCSV(w io.Writer, report PortfolioReport, delimiter rune) error {
+	writer := csv.NewWriter(w)
+	if delimiter != 0 {
+		writer.Comma = delimiter
+	}
+
+	rows := [][]string{
+		{"Reporting Currency", report.ReportingCurrency.String()},
+		{"Valuation Date", report.ValuationDate.Format("2006-01-02")},
+		{"Loans", strconv.Itoa(len(report.Loans))},
+		{"Total Principal", report.TotalPrincipal.Amount.String()},
+		{"Total Accrued Interest", report.TotalAccruedInterest.Amount.String()},
+		{"Total Exposure", report.TotalExposure.Amount.String()},
+		{},
+		{"Currency", "Loans", "Principal", "Accrued Interest", "Reporting Principal", "Reporting Accrued Interest"},
+	}
+	for _, total := range report.Currencies {
+		rows = append(rows, []string{
+			total.Currency.String(),
+			strconv.Itoa(total.Loans),
+			total.Principal.Amount.String(),
+			total.AccruedInterest.Amount.String(),
+			total.ReportingPrincipal.Amount.String(),
+			total.ReportingAccruedInterest.Amount.String(),
+		})
+	}
+
+	rows = append(rows, []string{}, []string{"Loan ID", "Currency", "FX Rate", "Principal", "Accrued Interest", "Reporting Principal", "Reporting Accrued Interest"})
+	for _, loan := range report.Loans {
+		rows = append(rows, []string{
+			loan.LoanID,
+			loan.Currency.String(),
+			loan.FXRate.String(),
+			loan.Principal.Amount.String(),
+			loan.AccruedInterest.Amount.String(),
+			loan.ReportingPrincipal.Amount.String(),
+			loan.ReportingAccruedInterest.Amount.String(),
+		})
+	}
+
+	return writer.WriteAll(rows)
+}
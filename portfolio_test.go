@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewPortfolioReport(t *testing.T) {
+	newLoan := func(id string, principal Money) Loan {
+		loan, err := NewLoan(LoanDetails{
+			ID:               id,
+			StartDate:        NewDate(2024, 1, 1),
+			EndDate:          NewDate(2025, 1, 1),
+			PrincipalAmount:  principal,
+			BaseInterestRate: NewDecimal(9, 0),
+			Margin:           NewDecimal(1, 0),
+		}, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating loan %s: %v", id, err)
+		}
+		return loan
+	}
+
+	usdLoan := newLoan("usd-1", NewMoney(NewDecimal(36500, 0), CurrencyUSD))
+	usdLoan, err := usdLoan.AddTransaction(Transaction{
+		ID:            "repayment",
+		Type:          TransactionRepayment,
+		EffectiveDate: NewDate(2024, 1, 6),
+		Amount:        NewMoney(NewDecimal(18250, 0), CurrencyUSD),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error adding repayment: %v", err)
+	}
+
+	loans := []Loan{
+		newLoan("gbp-1", NewMoney(NewDecimal(36500, 0), CurrencyGBP)),
+		usdLoan,
+		newLoan("usd-2", NewMoney(NewDecimal(3650, 0), CurrencyUSD)),
+		newLoan("jpy-1", NewMoney(NewDecimal(3650000, 0), CurrencyJPY)),
+	}
+	rates := NewFXRates([]FXRate{
+		{EffectiveDate: NewDate(2024, 1, 1), Base: CurrencyUSD, Quote: CurrencyGBP, Rate: NewDecimal(8, 1)},
+		{EffectiveDate: NewDate(2024, 1, 1), Base: CurrencyGBP, Quote: CurrencyJPY, Rate: NewDecimal(200, 0)},
+	})
+
+	report, err := NewPortfolioReport(loans, CurrencyGBP, NewDate(2024, 1, 10), rates)
+	if err != nil {
+		t.Fatalf("Unexpected error creating portfolio report: %v", err)
+	}
+
+	// 10 days of interest at 10% on 36,500 is 100, or 75 when half is repaid after 5 days
+	wantLoans := map[string][4]string{
+		"gbp-1": {"36500.00", "100.00", "36500.00", "100.00"},
+		"usd-1": {"18250.00", "75.00", "14600.00", "60.00"},
+		"usd-2": {"3650.00", "10.00", "2920.00", "8.00"},
+		"jpy-1": {"3650000", "10000", "18250.00", "50.00"},
+	}
+	if len(report.Loans) != len(wantLoans) {
+		t.Fatalf("Unexpected number of loans in portfolio report. got %d, want %d", len(report.Loans), len(wantLoans))
+	}
+	for _, loan := range report.Loans {
+		want := wantLoans[loan.LoanID]
+		got := [4]string{loan.Principal.Amount.String(), loan.AccruedInterest.Amount.String(), loan.ReportingPrincipal.Amount.String(), loan.ReportingAccruedInterest.Amount.String()}
+		if got != want {
+			t.Errorf("Unexpected exposure of loan %s. got %v, want %v", loan.LoanID, got, want)
+		}
+	}
+
+	if len(report.Currencies) != 3 || report.Currencies[2].Currency != CurrencyUSD {
+		t.Fatalf("Unexpected currencies in portfolio report. got %+v", report.Currencies)
+	}
+	if usd := report.Currencies[2]; usd.Loans != 2 || usd.Principal.Amount.String() != "21900.00" || usd.ReportingAccruedInterest.Amount.String() != "68.00" {
+		t.Errorf("Unexpected USD totals. got %d loans, %s principal and %s reporting accrued interest", usd.Loans, usd.Principal.Amount, usd.ReportingAccruedInterest.Amount)
+	}
+
+	if got := report.TotalPrincipal.Amount.String(); got != "72270.00" {
+		t.Errorf("Unexpected total principal. got %s, want 72270.00", got)
+	}
+	if got := report.TotalAccruedInterest.Amount.String(); got != "218.00" {
+		t.Errorf("Unexpected total accrued interest. got %s, want 218.00", got)
+	}
+	if got := report.TotalExposure.Amount.String(); got != "72488.00" {
+		t.Errorf("Unexpected total exposure. got %s, want 72488.00", got)
+	}
+
+	if _, err := NewPortfolioReport(loans, CurrencyEUR, NewDate(2024, 1, 10), rates); !errors.Is(err, ErrMissingFXRate) {
+		t.Errorf("Unexpected error reporting without an FX rate. got %v, want %v", err, ErrMissingFXRate)
+	}
+	if _, err := NewPortfolioReport(loans, "XYZ", NewDate(2024, 1, 10), rates); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("Unexpected error reporting in an unknown currency. got %v, want %v", err, ErrInvalidCurrency)
+	}
+}
+
+func TestNewPortfolioReportLoansNotRunning(t *testing.T) {
+	loan, err := NewLoan(LoanDetails{
+		ID:               "gbp-1",
+		StartDate:        NewDate(2024, 1, 1),
+		EndDate:          NewDate(2024, 12, 31),
+		PrincipalAmount:  NewMoney(NewDecimal(36500, 0), CurrencyGBP),
+		BaseInterestRate: NewDecimal(9, 0),
+		Margin:           NewDecimal(1, 0),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		valuationDate Date
+		principal     string
+		accrued       string
+		exposure      string
+	}{
+		{name: "not yet started", valuationDate: NewDate(2023, 12, 31), principal: "0.00", accrued: "0.00", exposure: "0.00"},
+		{name: "start date", valuationDate: NewDate(2024, 1, 1), principal: "36500.00", accrued: "10.00", exposure: "36510.00"},
+		{name: "maturity date", valuationDate: NewDate(2024, 12, 31), principal: "36500.00", accrued: "3650.00", exposure: "40150.00"},
+		{name: "matured", valuationDate: NewDate(2025, 1, 1), principal: "0.00", accrued: "0.00", exposure: "0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := NewPortfolioReport([]Loan{loan}, CurrencyGBP, tt.valuationDate, NewFXRates(nil))
+			if err != nil {
+				t.Fatalf("Unexpected error creating portfolio report: %v", err)
+			}
+			if got := report.Loans[0].Principal.Amount.String(); got != tt.principal {
+				t.Errorf("Unexpected principal. got %s, want %s", got, tt.principal)
+			}
+			if got := report.Loans[0].AccruedInterest.Amount.String(); got != tt.accrued {
+				t.Errorf("Unexpected accrued interest. got %s, want %s", got, tt.accrued)
+			}
+			if got := report.TotalExposure.Amount.String(); got != tt.exposure {
+				t.Errorf("Unexpected total exposure. got %s, want %s", got, tt.exposure)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RateFixing holds a base rate fixing that applies from its effective date until the next fixing
+type RateFixing struct {
+	EffectiveDate Date    `json:"effective_date"` // EffectiveDate is the first day the fixing applies
+	Rate          Decimal `json:"rate"`           // Rate is the base interest rate percentage
+}
+
+// FloatingRate describes a base rate that floats with a published reference rate such as SONIA, SOFR or EURIBOR
+type FloatingRate struct {
+	Index            string       `json:"index"`             // Index is the name of the reference rate
+	Fixings          []RateFixing `json:"fixings"`           // Fixings is the timeline of base rate fixings, sorted by effective date
+	LookbackDays     int          `json:"lookback_days"`     // LookbackDays is the number of days before each accrual date the base rate is observed
+	ObservationShift bool         `json:"observation_shift"` // ObservationShift weights each day by its observation period rather than its interest period
+	Floor            *Decimal     `json:"floor,omitempty"`   // Floor is the minimum all-in interest rate percentage
+	Cap              *Decimal     `json:"cap,omitempty"`     // Cap is the maximum all-in interest rate percentage
+}
+
+// Validate validates the floating rate's fixings, lookback and floor/cap
+func (f FloatingRate) Validate() error {
+	if len(f.Fixings) == 0 {
+		return errors.Wrap(ErrInvalidRateFixing, "at least one fixing is required")
+	}
+	if f.LookbackDays < 0 {
+		return errors.Wrap(ErrInvalidInput, "lookback days must not be negative")
+	}
+	if f.Floor != nil && f.Cap != nil && f.Floor.Cmp(*f.Cap) > 0 {
+		return errors.Wrap(ErrInvalidInput, "floor must not be greater than cap")
+	}
+
+	for i := 1; i < len(f.Fixings); i++ {
+		if !f.Fixings[i].EffectiveDate.After(f.Fixings[i-1].EffectiveDate) {
+			return errors.Wrapf(ErrInvalidRateFixing, "fixings must be in ascending date order without duplicates (%s)", f.Fixings[i].EffectiveDate.Format("2006-01-02"))
+		}
+	}
+
+	return nil
+}
+
+// observationDate returns the date the base rate is observed for an accrual date
+func (f FloatingRate) observationDate(date Date) Date {
+	return date.AddDate(0, 0, -f.LookbackDays)
+}
+
+// baseRate returns the base rate fixing in effect on a date and whether one was found
+func (f FloatingRate) baseRate(date Date) (Decimal, bool) {
+	i, found := slices.BinarySearchFunc(f.Fixings, date, func(fixing RateFixing, date Date) int {
+		return daysBetween(date, fixing.EffectiveDate)
+	})
+	if found {
+		return f.Fixings[i].Rate, true
+	}
+	if i == 0 {
+		return Decimal{}, false
+	}
+
+	return f.Fixings[i-1].Rate, true
+}
+
+// applyFloorAndCap clamps an all-in rate between the floor and cap
+func (f FloatingRate) applyFloorAndCap(rate Decimal) Decimal {
+	if f.Floor != nil && rate.Cmp(*f.Floor) < 0 {
+		rate = *f.Floor
+	}
+	if f.Cap != nil && rate.Cmp(*f.Cap) > 0 {
+		rate = *f.Cap
+	}
+
+	return rate
+}
+
+// interestRates returns the base rate and the all-in rate percentages that apply to an accrual date
+func (l LoanDetails) interestRates(date Date) (Decimal, Decimal) {
+	if l.FloatingRate == nil {
+		return l.BaseInterestRate, l.BaseInterestRate.Add(l.Margin)
+	}
+
+	// before the first fixing the loan's base interest rate applies
+	baseRate, ok := l.FloatingRate.baseRate(l.FloatingRate.observationDate(date))
+	if !ok {
+		baseRate = l.BaseInterestRate
+	}
+
+	return baseRate, l.FloatingRate.applyFloorAndCap(baseRate.Add(l.Margin))
+}
+
+// accrualDays returns the days accrued on an accrual date and their year basis, shifting to the observation period if required
+func (l LoanDetails) accrualDays(dayCounter DayCounter, date Date) (int, int) {
+	if l.FloatingRate != nil && l.FloatingRate.ObservationShift {
+		return accrualDays(dayCounter, l.FloatingRate.observationDate(l.StartDate), l.FloatingRate.observationDate(date))
+	}
+
+	return accrualDays(dayCounter, l.StartDate, date)
+}
+
+// LoadRateFixingsFile loads base rate fixings from a local CSV file
+func LoadRateFixingsFile(path string) ([]RateFixing, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadRateFixingsCSV(file)
+}
+
+// LoadRateFixingsCSV loads base rate fixings from CSV rows of date (YYYY-MM-DD) and rate percentage, with an optional header
+func LoadRateFixingsCSV(r io.Reader) ([]RateFixing, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var fixings []RateFixing
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidRateFixing, "line %d: %v", line, err)
+		}
+
+		date, err := ParseDate(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, errors.Wrapf(ErrInvalidRateFixing, "line %d: invalid date %q", line, record[0])
+		}
+
+		rate, err := ParseDecimal(record[1])
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidRateFixing, "line %d: invalid rate %q", line, record[1])
+		}
+
+		fixings = append(fixings, RateFixing{EffectiveDate: date, Rate: rate})
+	}
+
+	slices.SortStableFunc(fixings, func(a, b RateFixing) int {
+		return a.EffectiveDate.Compare(b.EffectiveDate)
+	})
+
+	return fixings, nil
+}
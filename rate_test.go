@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadRateFixingsCSV(t *testing.T) {
+	input := "date,rate\n2024-01-03, 5.20\n2024-01-01,5.19\n"
+
+	fixings, err := LoadRateFixingsCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error loading fixings: %v", err)
+	}
+
+	if len(fixings) != 2 {
+		t.Fatalf("Unexpected number of fixings. got %d, want %d", len(fixings), 2)
+	}
+	if fixings[0].EffectiveDate.Format("2006-01-02") != "2024-01-01" || fixings[0].Rate.String() != "5.19" {
+		t.Errorf("Expected fixings to be sorted by date. got %v", fixings)
+	}
+
+	invalid := map[string]string{
+		"invalid rate": "2024-01-01,abc\n",
+		"invalid date": "2024-01-01,5\nyesterday,5\n",
+		"missing rate": "2024-01-01\n",
+	}
+
+	for name, input := range invalid {
+		if _, err := LoadRateFixingsCSV(strings.NewReader(input)); !errors.Is(err, ErrInvalidRateFixing) {
+			t.Errorf("Expected invalid rate fixing error for %s but got %v", name, err)
+		}
+	}
+}
+
+func TestCalculateDailySimpleInterestFloatingRate(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-01-06")
+
+	floor := NewDecimal(3, 0)
+	rateCap := NewDecimal(8, 0)
+
+	fixings := []RateFixing{
+		{EffectiveDate: startDate.AddDate(0, 0, 1), Rate: NewDecimal(1, 0)},
+		{EffectiveDate: startDate.AddDate(0, 0, 2), Rate: NewDecimal(5, 0)},
+		{EffectiveDate: startDate.AddDate(0, 0, 3), Rate: NewDecimal(9, 0)},
+	}
+
+	tests := []struct {
+		name         string
+		floatingRate FloatingRate
+		rates        []string
+	}{
+		{
+			name:         "no lookback",
+			floatingRate: FloatingRate{Fixings: fixings},
+			rates:        []string{"5", "2", "6", "10", "10"},
+		},
+		{
+			name:         "one day lookback",
+			floatingRate: FloatingRate{Fixings: fixings, LookbackDays: 1},
+			rates:        []string{"5", "5", "2", "6", "10"},
+		},
+		{
+			name:         "floor and cap",
+			floatingRate: FloatingRate{Fixings: fixings, Floor: &floor, Cap: &rateCap},
+			rates:        []string{"5", "3", "6", "8", "8"},
+		},
+	}
+
+	for _, test := range tests {
+		floatingRate := test.floatingRate
+		loan, err := NewLoan(LoanDetails{
+			StartDate:        startDate,
+			EndDate:          endDate,
+			PrincipalAmount:  NewMoney(NewDecimal(36500, 0), CurrencyGBP),
+			BaseInterestRate: NewDecimal(4, 0),
+			Margin:           NewDecimal(1, 0),
+			FloatingRate:     &floatingRate,
+		}, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating loan for %s: %v", test.name, err)
+		}
+
+		for i, interest := range loan.DailyInterest {
+			if interest.InterestRate.String() != test.rates[i] {
+				t.Errorf("Unexpected interest rate on day %d for %s. got %s, want %s", i+1, test.name, interest.InterestRate, test.rates[i])
+			}
+
+			// 36500 * rate% / 365 = rate * 1.00
+			if want := test.rates[i] + ".00"; interest.DailyInterestAccrued.Amount.String() != want {
+				t.Errorf("Unexpected daily interest on day %d for %s. got %s, want %s", i+1, test.name, interest.DailyInterestAccrued.Amount, want)
+			}
+		}
+	}
+}
+
+func TestFloatingRateValidate(t *testing.T) {
+	date, _ := ParseDate("2024-01-01")
+	floor := NewDecimal(5, 0)
+	rateCap := NewDecimal(4, 0)
+
+	invalid := map[string]FloatingRate{
+		"no fixings":         {},
+		"negative lookback":  {Fixings: []RateFixing{{EffectiveDate: date}}, LookbackDays: -1},
+		"floor above cap":    {Fixings: []RateFixing{{EffectiveDate: date}}, Floor: &floor, Cap: &rateCap},
+		"duplicate fixings":  {Fixings: []RateFixing{{EffectiveDate: date}, {EffectiveDate: date}}},
+		"descending fixings": {Fixings: []RateFixing{{EffectiveDate: date.AddDate(0, 0, 1)}, {EffectiveDate: date}}},
+	}
+
+	for name, floatingRate := range invalid {
+		if err := floatingRate.Validate(); err == nil {
+			t.Errorf("Expected error validating floating rate with %s but got none", name)
+		}
+	}
+}
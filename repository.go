@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var _ (LoanRepository) = (*storeLoanRepository)(nil)
+
+// loanStore is a LoanRepository without contexts, for stores such as the in-memory store whose operations never wait on anything that could be cancelled
+type loanStore interface {
+	Create(loan Loan, change LoanChange) error
+	CreateBatch(loans []Loan, change LoanChange) error
+	Read(id string) (Loan, error)
+	ReadBatch(ids []string) ([]Loan, error)
+	List(query LoanQuery) (LoanPage, error)
+	Update(loan Loan, change LoanChange) error
+	Delete(id string, revision int, change LoanChange) error
+	Versions(id string) ([]LoanVersion, error)
+	Undelete(id string) error
+	CreateDeleted(deleted DeletedLoan, change LoanChange) error
+	Deleted() ([]DeletedLoan, error)
+	Purge(id string) error
+}
+
+// storeLoanRepository adapts a loanStore into a LoanRepository, running each operation only if its context has not been cancelled or passed its deadline
+type storeLoanRepository struct {
+	store loanStore
+}
+
+// newStoreLoanRepository creates a LoanRepository over a store of loans
+func newStoreLoanRepository(store loanStore) *storeLoanRepository {
+	return &storeLoanRepository{store: store}
+}
+
+// Create implements LoanRepository
+func (s *storeLoanRepository) Create(ctx context.Context, loan Loan, change LoanChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.Create(loan, change)
+}
+
+// CreateBatch implements LoanRepository
+func (s *storeLoanRepository) CreateBatch(ctx context.Context, loans []Loan, change LoanChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.CreateBatch(loans, change)
+}
+
+// Read implements LoanRepository
+func (s *storeLoanRepository) Read(ctx context.Context, id string) (Loan, error) {
+	if err := ctx.Err(); err != nil {
+		return Loan{}, err
+	}
+	return s.store.Read(id)
+}
+
+// ReadBatch implements LoanRepository
+func (s *storeLoanRepository) ReadBatch(ctx context.Context, ids []string) ([]Loan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.store.ReadBatch(ids)
+}
+
+// List implements LoanRepository
+func (s *storeLoanRepository) List(ctx context.Context, query LoanQuery) (LoanPage, error) {
+	if err := ctx.Err(); err != nil {
+		return LoanPage{}, err
+	}
+	return s.store.List(query)
+}
+
+// Update implements LoanRepository
+func (s *storeLoanRepository) Update(ctx context.Context, loan Loan, change LoanChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.Update(loan, change)
+}
+
+// Delete implements LoanRepository
+func (s *storeLoanRepository) Delete(ctx context.Context, id string, revision int, change LoanChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.Delete(id, revision, change)
+}
+
+// Versions implements LoanRepository
+func (s *storeLoanRepository) Versions(ctx context.Context, id string) ([]LoanVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.store.Versions(id)
+}
+
+// Undelete implements LoanRepository
+func (s *storeLoanRepository) Undelete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.Undelete(id)
+}
+
+// CreateDeleted implements LoanRepository
+func (s *storeLoanRepository) CreateDeleted(ctx context.Context, deleted DeletedLoan, change LoanChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.CreateDeleted(deleted, change)
+}
+
+// Deleted implements LoanRepository
+func (s *storeLoanRepository) Deleted(ctx context.Context) ([]DeletedLoan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.store.Deleted()
+}
+
+// Purge implements LoanRepository
+func (s *storeLoanRepository) Purge(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.Purge(id)
+}
+
+// validateBatchIDs validates that no two loans of a batch share an ID
+func validateBatchIDs(loans []Loan) error {
+	ids := make(map[string]bool, len(loans))
+	for _, loan := range loans {
+		if ids[loan.LoanDetails.ID] {
+			return errors.Wrapf(ErrLoanAlreadyExists, "%s is in the batch more than once", loan.LoanDetails.ID)
+		}
+		ids[loan.LoanDetails.ID] = true
+	}
+
+	return nil
+}
+
+// batchError returns the error for a loan of a batch, naming the loan when the batch holds more than one
+func batchError(size int, id string, err error) error {
+	if size == 1 {
+		return err
+	}
+	return errors.Wrapf(err, "loan %s", id)
+}
+
+// checkRevision checks a loan at its stored revision is still at the revision a write expects, where 0 expects any revision
+func checkRevision(stored, expected int) error {
+	if expected != 0 && expected != stored {
+		return errors.Wrapf(ErrLoanConflict, "expected revision %d but the loan is at revision %d", expected, stored)
+	}
+	return nil
+}
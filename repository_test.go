@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testLoanRepositories are the LoanRepository implementations tested against each other, by name
+var testLoanRepositories = map[string]func(t *testing.T) LoanRepository{
+	"memory": func(t *testing.T) LoanRepository { return NewInMemoryLoanRepository() },
+	"file": func(t *testing.T) LoanRepository {
+		repo, err := NewJournalLoanRepository(filepath.Join(t.TempDir(), "loans.jsonl"))
+		if err != nil {
+			t.Fatalf("Unexpected error opening journal: %v", err)
+		}
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	},
+	"sqlite": func(t *testing.T) LoanRepository {
+		repo, err := openSQLiteLoanRepository(filepath.Join(t.TempDir(), "loans.db"))
+		if err != nil {
+			t.Fatalf("Unexpected error opening database: %v", err)
+		}
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	},
+}
+
+func TestLoanRepositoryBatches(t *testing.T) {
+	for name, newRepo := range testLoanRepositories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			loan := func(id string) Loan { return Loan{LoanDetails: LoanDetails{ID: id}} }
+			if err := repo.Create(ctx, loan("b"), LoanChange{}); err != nil {
+				t.Fatalf("Unexpected error in Create: %v", err)
+			}
+
+			if err := repo.CreateBatch(ctx, []Loan{loan("a"), loan("c"), loan("a")}, LoanChange{}); !errors.Is(err, ErrLoanAlreadyExists) {
+				t.Errorf("Expected ErrLoanAlreadyExists creating a batch with a repeated ID, got %v", err)
+			}
+			if err := repo.CreateBatch(ctx, []Loan{loan("a"), loan("b"), loan("c")}, LoanChange{}); !errors.Is(err, ErrLoanAlreadyExists) {
+				t.Errorf("Expected ErrLoanAlreadyExists creating a batch with an existing loan, got %v", err)
+			}
+			if loans := listLoans(t, repo); len(loans) != 1 {
+				t.Errorf("Expected a failed batch to create none of its loans. got %d loans", len(loans))
+			}
+
+			if err := repo.CreateBatch(ctx, []Loan{loan("c"), loan("a")}, LoanChange{ChangedBy: "tester"}); err != nil {
+				t.Fatalf("Unexpected error in CreateBatch: %v", err)
+			}
+			if versions, err := repo.Versions(ctx, "a"); err != nil || len(versions) != 1 || versions[0].ChangedBy != "tester" {
+				t.Errorf("Expected a batch created loan to record version 1. got %+v, %v", versions, err)
+			}
+
+			loans, err := repo.ReadBatch(ctx, []string{"c", "a", "b"})
+			if err != nil {
+				t.Fatalf("Unexpected error in ReadBatch: %v", err)
+			}
+			if len(loans) != 3 || loans[0].LoanDetails.ID != "c" || loans[1].LoanDetails.ID != "a" || loans[2].LoanDetails.ID != "b" {
+				t.Errorf("Expected ReadBatch to read the loans in the order of their IDs. got %+v", loans)
+			}
+			if _, err := repo.ReadBatch(ctx, []string{"a", "missing"}); !errors.Is(err, ErrLoanDoesNotExists) {
+				t.Errorf("Expected ErrLoanDoesNotExists reading a batch with a missing loan, got %v", err)
+			}
+
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+			if _, err := repo.Read(cancelled, "a"); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled reading with a cancelled context, got %v", err)
+			}
+			if err := repo.Create(cancelled, loan("d"), LoanChange{}); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled creating with a cancelled context, got %v", err)
+			}
+			if _, err := repo.Read(ctx, "d"); !errors.Is(err, ErrLoanDoesNotExists) {
+				t.Errorf("Expected a cancelled create to write nothing, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoanRepositoryRevisions(t *testing.T) {
+	for name, newRepo := range testLoanRepositories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			if err := repo.Create(ctx, Loan{LoanDetails: LoanDetails{ID: "a"}, Revision: 7}, LoanChange{}); err != nil {
+				t.Fatalf("Unexpected error in Create: %v", err)
+			}
+			loan, err := repo.Read(ctx, "a")
+			if err != nil || loan.Revision != 1 {
+				t.Fatalf("Expected a created loan to be at revision 1. got %d, %v", loan.Revision, err)
+			}
+
+			if err := repo.Update(ctx, loan, LoanChange{}); err != nil {
+				t.Fatalf("Unexpected error in Update: %v", err)
+			}
+			if err := repo.Update(ctx, loan, LoanChange{}); !errors.Is(err, ErrLoanConflict) {
+				t.Errorf("Expected ErrLoanConflict updating a stale revision, got %v", err)
+			}
+			if err := repo.Delete(ctx, "a", 1, LoanChange{}); !errors.Is(err, ErrLoanConflict) {
+				t.Errorf("Expected ErrLoanConflict deleting a stale revision, got %v", err)
+			}
+
+			loan.Revision = 0
+			if err := repo.Update(ctx, loan, LoanChange{}); err != nil {
+				t.Fatalf("Expected revision 0 to update any revision, got %v", err)
+			}
+			if loan, err := repo.Read(ctx, "a"); err != nil || loan.Revision != 3 {
+				t.Errorf("Expected each update to increment the revision to 3. got %d, %v", loan.Revision, err)
+			}
+
+			if err := repo.Delete(ctx, "a", 3, LoanChange{}); err != nil {
+				t.Errorf("Unexpected error deleting the current revision: %v", err)
+			}
+			if err := repo.Undelete(ctx, "a"); err != nil {
+				t.Fatalf("Unexpected error in Undelete: %v", err)
+			}
+			if loan, err := repo.Read(ctx, "a"); err != nil || loan.Revision != 4 {
+				t.Errorf("Expected undeleting to increment the revision to 4. got %d, %v", loan.Revision, err)
+			}
+			loan.Revision = 3
+			if err := repo.Update(ctx, loan, LoanChange{}); !errors.Is(err, ErrLoanConflict) {
+				t.Errorf("Expected ErrLoanConflict updating the revision read before the delete, got %v", err)
+			}
+			if err := repo.Delete(ctx, "a", 3, LoanChange{}); !errors.Is(err, ErrLoanConflict) {
+				t.Errorf("Expected ErrLoanConflict deleting the revision read before the delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoanRepositoryCreateDeleted(t *testing.T) {
+	for name, newRepo := range testLoanRepositories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			deleted := DeletedLoan{
+				Loan:         Loan{LoanDetails: LoanDetails{ID: "a"}, Revision: 4},
+				LoanDeletion: LoanDeletion{DeletedAt: deletedAt, DeletedBy: "alice", Reason: "booked in error"},
+			}
+			if err := repo.CreateDeleted(ctx, deleted, LoanChange{ChangedBy: "tester"}); err != nil {
+				t.Fatalf("Unexpected error in CreateDeleted: %v", err)
+			}
+			if err := repo.CreateDeleted(ctx, deleted, LoanChange{}); !errors.Is(err, ErrLoanAlreadyExists) {
+				t.Errorf("Expected ErrLoanAlreadyExists creating a deleted loan twice, got %v", err)
+			}
+			if _, err := repo.Read(ctx, "a"); !errors.Is(err, ErrLoanDoesNotExists) {
+				t.Errorf("Expected a loan created deleted to be hidden, got %v", err)
+			}
+
+			listed, err := repo.Deleted(ctx)
+			if err != nil || len(listed) != 1 || !listed[0].DeletedAt.Equal(deletedAt) || listed[0].DeletedBy != "alice" || listed[0].Reason != "booked in error" {
+				t.Errorf("Expected the deleted loan to keep its deletion. got %+v, %v", listed, err)
+			}
+
+			if err := repo.Undelete(ctx, "a"); err != nil {
+				t.Fatalf("Unexpected error in Undelete: %v", err)
+			}
+			if loan, err := repo.Read(ctx, "a"); err != nil || loan.Revision != 2 {
+				t.Errorf("Expected the undeleted loan at revision 2. got %d, %v", loan.Revision, err)
+			}
+			if versions, err := repo.Versions(ctx, "a"); err != nil || len(versions) != 1 || versions[0].ChangedBy != "tester" {
+				t.Errorf("Expected a loan created deleted to record version 1. got %+v, %v", versions, err)
+			}
+		})
+	}
+}
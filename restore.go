@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RestoreLoansFile restores loans from the JSON export at path
+func RestoreLoansFile(ctx context.Context, loanRepository LoanRepository, path string, replace bool, change LoanChange) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return RestoreLoans(ctx, loanRepository, file, replace, change)
+}
+
+// BackupLoan is a loan in a backup, holding when, by whom and why it was deleted when it is deleted
+type BackupLoan struct {
+	Loan
+	Deletion *LoanDeletion `json:"deletion,omitempty"` // Deletion is the deletion of a loan deleted but not yet purged
+}
+
+// BackupLoans returns every loan, including those deleted but not yet purged, ordered by ID
+func BackupLoans(ctx context.Context, loanRepository LoanRepository) ([]BackupLoan, error) {
+	page, err := loanRepository.List(ctx, LoanQuery{})
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := loanRepository.Deleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loans := make([]BackupLoan, 0, len(page.Loans)+len(deleted))
+	for _, loan := range page.Loans {
+		loans = append(loans, BackupLoan{Loan: loan})
+	}
+	for _, loan := range deleted {
+		loans = append(loans, BackupLoan{Loan: loan.Loan, Deletion: &loan.LoanDeletion})
+	}
+	slices.SortFunc(loans, func(a, b BackupLoan) int {
+		return strings.Compare(a.LoanDetails.ID, b.LoanDetails.ID)
+	})
+
+	return loans, nil
+}
+
+// RestoreLoans restores loans from the JSON export of a single loan or an array of loans, keeping their original IDs.
+// Every loan's daily interest is verified against a recalculation from its details before any loan is written,
+// and existing loans are only overwritten when replace is set, recording a new version of any whose details change.
+// Loans deleted in a backup are restored as deleted, and are left as they are when replacing a loan that is already deleted.
+func RestoreLoans(ctx context.Context, loanRepository LoanRepository, r io.Reader, replace bool, change LoanChange) ([]string, error) {
+	exported, err := decodeExportedLoans(r)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedLoans, err := loanRepository.Deleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alreadyDeleted := make(map[string]bool, len(deletedLoans))
+	for _, deleted := range deletedLoans {
+		alreadyDeleted[deleted.Loan.LoanDetails.ID] = true
+	}
+
+	loans := make([]BackupLoan, len(exported))
+	previous := map[string]Loan{}
+	for i, loan := range exported {
+		id := loan.LoanDetails.ID
+		if loans[i].Loan, err = verifyExportedLoan(loan.Loan); err != nil {
+			return nil, errors.Wrapf(err, "loan %d (%s)", i+1, id)
+		}
+		loans[i].Deletion = loan.Deletion
+
+		if _, ok := previous[id]; ok {
+			return nil, errors.Wrapf(ErrLoanAlreadyExists, "loan %d (%s) is exported more than once", i+1, id)
+		}
+		if alreadyDeleted[id] && (loan.Deletion == nil || !replace) {
+			return nil, errors.Wrapf(ErrLoanAlreadyExists, "loan %d (%s) has the same ID as a deleted loan", i+1, id)
+		}
+		existing, err := loanRepository.Read(ctx, id)
+		if err != nil && !errors.Is(err, ErrLoanDoesNotExists) {
+			return nil, errors.Wrapf(err, "loan %d (%s)", i+1, id)
+		}
+		if err == nil && (!replace || loan.Deletion != nil) {
+			return nil, errors.Wrapf(ErrLoanAlreadyExists, "loan %d (%s)", i+1, id)
+		}
+		previous[id] = existing
+	}
+
+	ids := make([]string, 0, len(loans))
+	restoredDeleted := map[string]bool{}
+	for _, loan := range loans {
+		id := loan.LoanDetails.ID
+		switch {
+		case alreadyDeleted[id]:
+			continue
+		case loan.Deletion != nil:
+			err = loanRepository.CreateDeleted(ctx, DeletedLoan{Loan: loan.Loan, LoanDeletion: *loan.Deletion}, change)
+			restoredDeleted[id] = true
+		case previous[id].LoanDetails.ID != "":
+			// the loan is only replaced if it has not been changed since it was checked
+			loan.Revision = previous[id].Revision
+			err = loanRepository.Update(ctx, loan.Loan, change)
+		default:
+			err = loanRepository.Create(ctx, loan.Loan, change)
+		}
+
+		if err != nil {
+			// put back the loans already written so a failed restore leaves the repository as it found it, even when it was cancelled
+			err = errors.Wrapf(err, "loan %s", id)
+			if rollbackErr := rollbackRestore(context.WithoutCancel(ctx), loanRepository, ids, previous, restoredDeleted, change); rollbackErr != nil {
+				return nil, errors.Wrapf(err, "%v, leaving the loans partly restored", rollbackErr)
+			}
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// rollbackRestore puts back the previous loan of each ID restored, discarding those that did not exist before,
+// returning every loan that could not be put back
+func rollbackRestore(ctx context.Context, loanRepository LoanRepository, ids []string, previous map[string]Loan, deleted map[string]bool, change LoanChange) error {
+	var failures []string
+	for _, id := range ids {
+		var err error
+		if deleted[id] {
+			err = loanRepository.Purge(ctx, id)
+		} else if rollback := previous[id]; rollback.LoanDetails.ID != "" {
+			rollback.Revision = 0
+			err = loanRepository.Update(ctx, rollback, LoanChange{ChangedBy: change.ChangedBy, Reason: "failed restore rolled back"})
+		} else {
+			err = discardLoan(ctx, loanRepository, id, change)
+		}
+
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("loan %s: %v", id, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("failed to roll back %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// decodeExportedLoans decodes the JSON export of a single loan or an array of loans, such as a backup
+func decodeExportedLoans(r io.Reader) ([]BackupLoan, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var loans []BackupLoan
+		if err := json.Unmarshal(data, &loans); err != nil {
+			return nil, errors.Wrap(ErrInvalidInput, err.Error())
+		}
+		return loans, nil
+	}
+
+	var loan Loan
+	if err := json.Unmarshal(data, &loan); err != nil {
+		return nil, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	return []BackupLoan{{Loan: loan}}, nil
+}
+
+// verifyExportedLoan recalculates an exported loan from its details and transactions, checking the exported daily interest matches
+func verifyExportedLoan(exported Loan) (Loan, error) {
+	if exported.LoanDetails.ID == "" {
+		return Loan{}, errors.Wrap(ErrInvalidInput, "loan ID is required")
+	}
+	if err := exported.LoanDetails.Validate(); err != nil {
+		return Loan{}, err
+	}
+
+	loan, err := NewLoan(exported.LoanDetails, exported.Transactions)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	if len(exported.DailyInterest) != len(loan.DailyInterest) {
+		return Loan{}, errors.Wrapf(ErrExportMismatch, "%d days of interest were exported but %d were recalculated", len(exported.DailyInterest), len(loan.DailyInterest))
+	}
+	for i, interest := range loan.DailyInterest {
+		if !interestEqual(exported.DailyInterest[i], interest) {
+			return Loan{}, errors.Wrapf(ErrExportMismatch, "interest accrued on %s", interest.AccrualDate.Format("2006-01-02"))
+		}
+	}
+
+	return loan, nil
+}
+
+// interestEqual returns whether two days of interest hold the same values, regardless of how many decimal places they were written with
+func interestEqual(a, b Interest) bool {
+	moneyEqual := func(a, b Money) bool {
+		return a.Currency == b.Currency && a.Amount.Cmp(b.Amount) == 0
+	}
+
+	return a.AccrualDate.Equal(b.AccrualDate) &&
+		a.DaysElapsed == b.DaysElapsed &&
+		moneyEqual(a.Balance, b.Balance) &&
+		a.BaseInterestRate.Cmp(b.BaseInterestRate) == 0 &&
+		a.InterestRate.Cmp(b.InterestRate) == 0 &&
+		moneyEqual(a.DailyInterestWithoutMargin, b.DailyInterestWithoutMargin) &&
+		moneyEqual(a.DailyInterestAccrued, b.DailyInterestAccrued) &&
+		moneyEqual(a.TotalInterest, b.TotalInterest)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRestoreLoans(t *testing.T) {
+	ctx := context.Background()
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-04-01")
+
+	loan, err := NewLoan(LoanDetails{
+		ID:               "loan1",
+		StartDate:        startDate,
+		EndDate:          endDate,
+		PrincipalAmount:  NewMoney(NewDecimal(10000, 0), CurrencyGBP),
+		BaseInterestRate: NewDecimal(525, 2),
+		Margin:           NewDecimal(1, 0),
+		RoundingPoint:    RoundOnTotal,
+	}, []Transaction{
+		{ID: "t1", Type: TransactionRepayment, EffectiveDate: startDate.AddDate(0, 1, 0), Amount: NewMoney(NewDecimal(2500, 0), CurrencyGBP)},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	var export bytes.Buffer
+	if err := WriteLoanJSON(&export, loan); err != nil {
+		t.Fatalf("Unexpected error exporting loan: %v", err)
+	}
+
+	repo := NewInMemoryLoanRepository()
+	ids, err := RestoreLoans(ctx, repo, bytes.NewReader(export.Bytes()), false, LoanChange{})
+	if err != nil {
+		t.Fatalf("Unexpected error restoring loan: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "loan1" {
+		t.Errorf("Expected the loan to be restored with its original ID. got %v", ids)
+	}
+
+	restored, err := repo.Read(ctx, "loan1")
+	if err != nil {
+		t.Fatalf("Unexpected error reading restored loan: %v", err)
+	}
+	if restored.Revision != 1 {
+		t.Errorf("Expected the restored loan to be created at revision 1. got %d", restored.Revision)
+	}
+	restored.Revision = loan.Revision
+
+	var reexport bytes.Buffer
+	WriteLoanJSON(&reexport, restored)
+	if reexport.String() != export.String() {
+		t.Errorf("Restored loan does not round trip.\ngot  %s\nwant %s", reexport.String(), export.String())
+	}
+
+	if _, err := RestoreLoans(ctx, repo, bytes.NewReader(export.Bytes()), false, LoanChange{}); !errors.Is(err, ErrLoanAlreadyExists) {
+		t.Errorf("Expected ErrLoanAlreadyExists restoring over an existing loan. got %v", err)
+	}
+	if _, err := RestoreLoans(ctx, repo, bytes.NewReader(export.Bytes()), true, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error replacing an existing loan: %v", err)
+	}
+
+	// a tampered daily interest entry fails verification
+	tampered := loan
+	tampered.DailyInterest = slices.Clone(loan.DailyInterest)
+	tampered.DailyInterest[10].DailyInterestAccrued = tampered.DailyInterest[10].DailyInterestAccrued.Add(NewMoney(NewDecimal(1, 2), CurrencyGBP))
+	export.Reset()
+	WriteLoanJSON(&export, tampered)
+	if _, err := RestoreLoans(ctx, NewInMemoryLoanRepository(), &export, false, LoanChange{}); !errors.Is(err, ErrExportMismatch) {
+		t.Errorf("Expected ErrExportMismatch restoring a tampered export. got %v", err)
+	}
+}
+
+func TestRestoreLoansBackup(t *testing.T) {
+	ctx := context.Background()
+	startDate, _ := ParseDate("2024-01-01")
+
+	repo := NewInMemoryLoanRepository()
+	for _, id := range []string{"b", "a"} {
+		loan, _ := NewLoan(LoanDetails{
+			ID:               id,
+			StartDate:        startDate,
+			EndDate:          startDate.AddDate(0, 0, 30),
+			PrincipalAmount:  NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+			BaseInterestRate: NewDecimal(4, 0),
+		}, nil)
+		repo.Create(ctx, loan, LoanChange{})
+	}
+
+	var backup bytes.Buffer
+	if err := WriteLoansJSON(&backup, listLoans(t, repo)); err != nil {
+		t.Fatalf("Unexpected error backing up loans: %v", err)
+	}
+
+	restoredRepo := NewInMemoryLoanRepository()
+	ids, err := RestoreLoans(ctx, restoredRepo, &backup, false, LoanChange{})
+	if err != nil {
+		t.Fatalf("Unexpected error restoring backup: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" || len(listLoans(t, restoredRepo)) != 2 {
+		t.Errorf("Expected every loan in the backup to be restored. got %v", ids)
+	}
+
+	// a failure part way through leaves nothing behind
+	loans := listLoans(t, repo)
+	loans[1].DailyInterest = loans[1].DailyInterest[1:]
+	backup.Reset()
+	WriteLoansJSON(&backup, loans)
+
+	emptyRepo := NewInMemoryLoanRepository()
+	if _, err := RestoreLoans(ctx, emptyRepo, &backup, false, LoanChange{}); !errors.Is(err, ErrExportMismatch) {
+		t.Errorf("Expected ErrExportMismatch restoring a backup with missing interest. got %v", err)
+	}
+	if len(listLoans(t, emptyRepo)) != 0 {
+		t.Errorf("Expected a failed restore to write nothing. got %d loans", len(listLoans(t, emptyRepo)))
+	}
+}
+
+func TestRestoreLoansBackupDeleted(t *testing.T) {
+	ctx := context.Background()
+	startDate, _ := ParseDate("2024-01-01")
+
+	repo := NewInMemoryLoanRepository()
+	for _, id := range []string{"b", "a"} {
+		loan, _ := NewLoan(LoanDetails{
+			ID:               id,
+			StartDate:        startDate,
+			EndDate:          startDate.AddDate(0, 0, 30),
+			PrincipalAmount:  NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+			BaseInterestRate: NewDecimal(4, 0),
+		}, nil)
+		repo.Create(ctx, loan, LoanChange{})
+	}
+	repo.Delete(ctx, "b", 0, LoanChange{ChangedBy: "alice", Reason: "booked in error"})
+
+	loans, err := BackupLoans(ctx, repo)
+	if err != nil {
+		t.Fatalf("Unexpected error backing up loans: %v", err)
+	}
+	if len(loans) != 2 || loans[0].Deletion != nil || loans[1].Deletion == nil || loans[1].Deletion.Reason != "booked in error" {
+		t.Fatalf("Expected the backup to hold the deleted loan with its deletion. got %+v", loans)
+	}
+
+	var backup bytes.Buffer
+	WriteLoansJSON(&backup, loans)
+	data := backup.Bytes()
+
+	restoredRepo := NewInMemoryLoanRepository()
+	ids, err := RestoreLoans(ctx, restoredRepo, bytes.NewReader(data), false, LoanChange{})
+	if err != nil {
+		t.Fatalf("Unexpected error restoring backup: %v", err)
+	}
+	deleted, err := restoredRepo.Deleted(ctx)
+	if !slices.Equal(ids, []string{"a", "b"}) || err != nil || len(deleted) != 1 || deleted[0].DeletedBy != "alice" || !deleted[0].DeletedAt.Equal(loans[1].Deletion.DeletedAt) {
+		t.Errorf("Expected the deleted loan to be restored as deleted. got %v, %+v, %v", ids, deleted, err)
+	}
+	if len(listLoans(t, restoredRepo)) != 1 {
+		t.Errorf("Expected only the loan that was not deleted to be listed")
+	}
+
+	if _, err := RestoreLoans(ctx, repo, bytes.NewReader(data), false, LoanChange{}); !errors.Is(err, ErrLoanAlreadyExists) {
+		t.Errorf("Expected ErrLoanAlreadyExists restoring over the loans backed up. got %v", err)
+	}
+	if ids, err := RestoreLoans(ctx, repo, bytes.NewReader(data), true, LoanChange{}); err != nil || !slices.Equal(ids, []string{"a"}) {
+		t.Errorf("Expected replacing to leave the loan already deleted as it is. got %v, %v", ids, err)
+	}
+}
+
+func TestRestoreLoansRollbackFailure(t *testing.T) {
+	ctx := context.Background()
+	startDate, _ := ParseDate("2024-01-01")
+
+	var loans []Loan
+	for _, id := range []string{"a", "b"} {
+		loan, _ := NewLoan(LoanDetails{
+			ID:               id,
+			StartDate:        startDate,
+			EndDate:          startDate.AddDate(0, 0, 30),
+			PrincipalAmount:  NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+			BaseInterestRate: NewDecimal(4, 0),
+		}, nil)
+		loans = append(loans, loan)
+	}
+
+	var backup bytes.Buffer
+	WriteLoansJSON(&backup, loans)
+
+	// the second create fails and so does purging the first while rolling it back
+	repo := &failingLoanRepository{LoanRepository: NewInMemoryLoanRepository(), creates: 1}
+	_, err := RestoreLoans(ctx, repo, &backup, false, LoanChange{})
+	if !errors.Is(err, errFailingRepository) || !strings.Contains(err.Error(), "failed to roll back loan a") {
+		t.Errorf("Expected the restore error to report the loan that could not be rolled back. got %v", err)
+	}
+}
+
+// errFailingRepository is the error returned by a failingLoanRepository
+var errFailingRepository = errors.New("storage unavailable")
+
+// failingLoanRepository is a LoanRepository that fails every create after the first creates, and every purge
+type failingLoanRepository struct {
+	LoanRepository
+	creates int
+}
+
+// Create implements LoanRepository
+func (f *failingLoanRepository) Create(ctx context.Context, loan Loan, change LoanChange) error {
+	if f.creates == 0 {
+		return errFailingRepository
+	}
+	f.creates--
+
+	return f.LoanRepository.Create(ctx, loan, change)
+}
+
+// Purge implements LoanRepository
+func (f *failingLoanRepository) Purge(ctx context.Context, id string) error {
+	return errFailingRepository
+}
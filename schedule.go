@@ -0,0 +1,304 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	RepaymentBullet         = "bullet"
+	RepaymentAnnuity        = "annuity"
+	RepaymentEqualPrincipal = "equal-principal"
+	RepaymentCustom         = "custom"
+)
+
+const (
+	FrequencyMonthly    = "monthly"
+	FrequencyQuarterly  = "quarterly"
+	FrequencySemiAnnual = "semi-annual"
+	FrequencyAnnual     = "annual"
+)
+
+var (
+	AllowedRepaymentTypes = []RepaymentType{
+		RepaymentBullet,
+		RepaymentAnnuity,
+		RepaymentEqualPrincipal,
+		RepaymentCustom,
+	}
+
+	AllowedPaymentFrequencies = []PaymentFrequency{
+		FrequencyMonthly,
+		FrequencyQuarterly,
+		FrequencySemiAnnual,
+		FrequencyAnnual,
+	}
+)
+
+// RepaymentType holds how the principal of a loan is repaid
+type RepaymentType string
+
+// String stringifies the repayment type
+func (r RepaymentType) String() string {
+	return string(r)
+}
+
+// Validate validates whether the repayment type is supported
+func (r RepaymentType) Validate() error {
+	if ok := slices.Contains(AllowedRepaymentTypes, r); !ok {
+		return ErrInvalidRepaymentProfile
+	}
+
+	return nil
+}
+
+// PaymentFrequency holds how often instalments fall due
+type PaymentFrequency string
+
+// String stringifies the payment frequency
+func (p PaymentFrequency) String() string {
+	return string(p)
+}
+
+// Validate validates whether the payment frequency is supported
+func (p PaymentFrequency) Validate() error {
+	if ok := slices.Contains(AllowedPaymentFrequencies, p); !ok {
+		return ErrInvalidPaymentFrequency
+	}
+
+	return nil
+}
+
+// Months returns the number of months between instalments
+func (p PaymentFrequency) Months() int {
+	switch p {
+	case FrequencyMonthly:
+		return 1
+	case FrequencyQuarterly:
+		return 3
+	case FrequencySemiAnnual:
+		return 6
+	case FrequencyAnnual:
+		return 12
+	default:
+		return 0
+	}
+}
+
+// RepaymentProfile describes how and when the borrower repays the loan
+type RepaymentProfile struct {
+	Type              RepaymentType      `json:"type"`                         // Type is how the principal is repaid, defaulting to a bullet repayment at maturity
+	Frequency         PaymentFrequency   `json:"frequency,omitempty"`          // Frequency is how often instalments fall due, where a bullet without one pays everything at maturity
+	CustomInstalments []CustomInstalment `json:"custom_instalments,omitempty"` // CustomInstalments are the principal repayments of a custom profile
+}
+
+// CustomInstalment holds a principal repayment on a custom repayment profile
+type CustomInstalment struct {
+	DueDate   Date  `json:"due_date"`  // DueDate is the date the instalment falls due
+	Principal Money `json:"principal"` // Principal is the principal repaid by the instalment
+}
+
+// Instalment holds a single dated payment in an amortisation schedule
+type Instalment struct {
+	Number           int   `json:"number"`            // Number is the 1-based position of the instalment in the schedule
+	DueDate          Date  `json:"due_date"`          // DueDate is the date the instalment falls due
+	Principal        Money `json:"principal"`         // Principal is the principal repaid by the instalment
+	Interest         Money `json:"interest"`          // Interest is the interest accrued since the previous instalment
+	Payment          Money `json:"payment"`           // Payment is the total amount payable, principal plus interest
+	RemainingBalance Money `json:"remaining_balance"` // RemainingBalance is the principal outstanding after the instalment
+}
+
+// repaymentType returns the profile's repayment type, defaulting to bullet
+func (r RepaymentProfile) repaymentType() RepaymentType {
+	if r.Type == "" {
+		return RepaymentBullet
+	}
+	return r.Type
+}
+
+// Validate validates the repayment profile against the loan it belongs to
+func (r RepaymentProfile) Validate(loan LoanDetails) error {
+	repaymentType := r.repaymentType()
+	if err := repaymentType.Validate(); err != nil {
+		return err
+	}
+
+	switch repaymentType {
+	case RepaymentBullet:
+		if r.Frequency != "" {
+			return r.Frequency.Validate()
+		}
+	case RepaymentAnnuity, RepaymentEqualPrincipal:
+		return r.Frequency.Validate()
+	case RepaymentCustom:
+		return validateCustomInstalments(loan, r.CustomInstalments)
+	}
+
+	return nil
+}
+
+// validateCustomInstalments validates custom instalments fall within the loan period in order and repay the whole principal
+func validateCustomInstalments(loan LoanDetails, instalments []CustomInstalment) error {
+	if len(instalments) == 0 {
+		return errors.Wrap(ErrInvalidRepaymentProfile, "at least one custom instalment is required")
+	}
+
+	total := Decimal{}
+	previous := loan.StartDate
+	for i, instalment := range instalments {
+		if !instalment.DueDate.After(previous) || instalment.DueDate.After(loan.EndDate) {
+			return errors.Wrapf(ErrInvalidRepaymentProfile, "instalment %d must fall due after the previous instalment and by the end date", i+1)
+		}
+		if instalment.Principal.Currency != loan.Currency() {
+			return errors.Wrapf(ErrInvalidCurrency, "instalment %d is not in %s", i+1, loan.Currency())
+		}
+		if instalment.Principal.Amount.Sign() < 0 {
+			return errors.Wrapf(ErrInvalidRepaymentProfile, "instalment %d principal must not be negative", i+1)
+		}
+
+		total = total.Add(instalment.Principal.Amount)
+		previous = instalment.DueDate
+	}
+
+	if total.Cmp(loan.PrincipalAmount.Amount) != 0 {
+		return errors.Wrapf(ErrInvalidRepaymentProfile, "custom instalments repay %s of %s principal", total, loan.PrincipalAmount.Amount)
+	}
+
+	return nil
+}
+
+// GenerateSchedule generates the amortisation schedule of dated instalments for a loan and its repayment profile
+func GenerateSchedule(loan LoanDetails, profile RepaymentProfile) ([]Instalment, error) {
+	if err := profile.Validate(loan); err != nil {
+		return nil, err
+	}
+
+	currency := loan.Currency()
+	rounding := loan.roundingMode()
+	dueDates := scheduledDates(loan, profile)
+	numberOfInstalments := len(dueDates)
+	equalPrincipal := loan.PrincipalAmount.Amount.Div(NewDecimal(int64(numberOfInstalments), 0), currency.MinorUnits(), rounding)
+	annuityPayment := annuityPayment(loan, profile.Frequency, numberOfInstalments)
+
+	schedule := make([]Instalment, numberOfInstalments)
+	balance := loan.PrincipalAmount.Amount
+	previousDate := loan.StartDate
+
+	for i, dueDate := range dueDates {
+		// each day accrues in a single period, so only the first and last periods follow the loan's accrual boundaries
+		boundaries := accrualBoundariesOf(i > 0 || loan.AccrualBoundaries.includesStart(), i == numberOfInstalments-1 && loan.AccrualBoundaries.includesEnd())
+		interest := periodInterest(loan, balance, previousDate, dueDate, boundaries)
+
+		var principal Decimal
+		switch profile.repaymentType() {
+		case RepaymentAnnuity:
+			principal = annuityPayment.Sub(interest)
+		case RepaymentEqualPrincipal:
+			principal = equalPrincipal
+		case RepaymentCustom:
+			principal = profile.CustomInstalments[i].Principal.Amount
+		}
+
+		// the final instalment always clears the remaining balance, absorbing any rounding
+		if i == numberOfInstalments-1 || principal.Cmp(balance) > 0 {
+			principal = balance
+		}
+		if principal.Sign() < 0 {
+			principal = Decimal{}
+		}
+		balance = balance.Sub(principal)
+
+		schedule[i] = Instalment{
+			Number:           i + 1,
+			DueDate:          dueDate,
+			Principal:        NewMoney(principal, currency).Round(rounding),
+			Interest:         NewMoney(interest, currency),
+			Payment:          NewMoney(principal.Add(interest), currency).Round(rounding),
+			RemainingBalance: NewMoney(balance, currency).Round(rounding),
+		}
+		previousDate = dueDate
+	}
+
+	return schedule, nil
+}
+
+// scheduledDates returns the due dates of each instalment adjusted for the loan's business day convention,
+// with the final instalment always falling due on the maturity date
+func scheduledDates(loan LoanDetails, profile RepaymentProfile) []Date {
+	if profile.repaymentType() == RepaymentCustom {
+		dates := make([]Date, len(profile.CustomInstalments))
+		for i, instalment := range profile.CustomInstalments {
+			dates[i] = loan.adjustDate(instalment.DueDate)
+		}
+		return dates
+	}
+
+	maturityDate := loan.MaturityDate()
+	months := profile.Frequency.Months()
+	if months == 0 {
+		return []Date{maturityDate}
+	}
+
+	var dates []Date
+	for i := 1; ; i++ {
+		// dates are rolled on from the unadjusted date so an adjustment never carries into the following instalments
+		dueDate := loan.adjustDate(addMonths(loan.StartDate, i*months))
+		if !dueDate.Before(maturityDate) {
+			break
+		}
+		dates = append(dates, dueDate)
+	}
+
+	return append(dates, maturityDate)
+}
+
+// periodInterest returns the interest accrued on a constant balance between two dates using the loan's calculation method
+func periodInterest(loan LoanDetails, balance Decimal, start, end Date, boundaries AccrualBoundaries) Decimal {
+	period := loan
+	period.StartDate = start
+	period.EndDate = end
+	period.AccrualBoundaries = boundaries
+	period.PrincipalAmount = NewMoney(balance, loan.Currency())
+
+	dailyInterest := period.CalculationMethod.InterestCalculator().Calculate(period, nil)
+	if len(dailyInterest) == 0 {
+		return Decimal{}.Round(loan.Currency().MinorUnits(), RoundTruncate)
+	}
+
+	return dailyInterest[len(dailyInterest)-1].TotalInterest.Amount
+}
+
+// annuityPayment returns the level payment that repays the principal with interest over the number of instalments,
+// using the all-in rate at the start of the loan divided evenly across the year
+func annuityPayment(loan LoanDetails, frequency PaymentFrequency, instalments int) Decimal {
+	months := frequency.Months()
+	if months == 0 || instalments == 0 {
+		return Decimal{}
+	}
+
+	currency := loan.Currency()
+	_, allInRate := loan.interestRates(loan.StartDate)
+	periodRate := allInRate.Div(NewDecimal(int64(100*12/months), 0), compoundingPrecision, RoundHalfEven)
+	if periodRate.IsZero() {
+		return loan.PrincipalAmount.Amount.Div(NewDecimal(int64(instalments), 0), currency.MinorUnits(), loan.roundingMode())
+	}
+
+	// payment = P * r / (1 - (1 + r)^-n) = P * r * (1 + r)^n / ((1 + r)^n - 1)
+	one := NewDecimal(1, 0)
+	growth := one
+	for i := 0; i < instalments; i++ {
+		growth = growth.Mul(one.Add(periodRate)).Round(compoundingPrecision, RoundHalfEven)
+	}
+
+	numerator := loan.PrincipalAmount.Amount.Mul(periodRate).Mul(growth)
+	return numerator.Div(growth.Sub(one), currency.MinorUnits(), loan.roundingMode())
+}
+
+// addMonths adds a number of months to a date, clamping to the end of the month rather than overflowing into the next
+func addMonths(date Date, months int) Date {
+	firstOfMonth := NewDate(date.Year(), date.Month()+time.Month(months), 1)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return NewDate(firstOfMonth.Year(), firstOfMonth.Month(), min(date.Day(), lastDay))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGenerateSchedule(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-31")
+	endDate, _ := ParseDate("2025-01-31")
+
+	loan := LoanDetails{
+		StartDate:        startDate,
+		EndDate:          endDate,
+		PrincipalAmount:  NewMoney(NewDecimal(12000, 0), CurrencyGBP),
+		BaseInterestRate: NewDecimal(5, 0),
+		Margin:           NewDecimal(1, 0),
+	}
+
+	tests := []struct {
+		profile     RepaymentProfile
+		instalments int
+		dueDates    map[int]string
+		principals  map[int]string
+	}{
+		{
+			profile:     RepaymentProfile{Type: RepaymentBullet},
+			instalments: 1,
+			dueDates:    map[int]string{0: "2025-01-31"},
+			principals:  map[int]string{0: "12000.00"},
+		},
+		{
+			profile:     RepaymentProfile{Type: RepaymentBullet, Frequency: FrequencyQuarterly},
+			instalments: 4,
+			dueDates:    map[int]string{0: "2024-04-30", 3: "2025-01-31"},
+			principals:  map[int]string{0: "0.00", 3: "12000.00"},
+		},
+		{
+			profile:     RepaymentProfile{Type: RepaymentEqualPrincipal, Frequency: FrequencyMonthly},
+			instalments: 12,
+			dueDates:    map[int]string{0: "2024-02-29", 1: "2024-03-31", 11: "2025-01-31"},
+			principals:  map[int]string{0: "1000.00", 11: "1000.00"},
+		},
+		{
+			profile:     RepaymentProfile{Type: RepaymentAnnuity, Frequency: FrequencyMonthly},
+			instalments: 12,
+			dueDates:    map[int]string{11: "2025-01-31"},
+		},
+		{
+			profile: RepaymentProfile{Type: RepaymentCustom, CustomInstalments: []CustomInstalment{
+				{DueDate: startDate.AddDate(0, 6, 0), Principal: NewMoney(NewDecimal(2000, 0), CurrencyGBP)},
+				{DueDate: endDate, Principal: NewMoney(NewDecimal(10000, 0), CurrencyGBP)},
+			}},
+			instalments: 2,
+			principals:  map[int]string{0: "2000.00", 1: "10000.00"},
+		},
+	}
+
+	for _, test := range tests {
+		name := test.profile.repaymentType().String() + " " + test.profile.Frequency.String()
+
+		schedule, err := GenerateSchedule(loan, test.profile)
+		if err != nil {
+			t.Errorf("Unexpected error generating %s schedule: %v", name, err)
+			continue
+		}
+
+		if len(schedule) != test.instalments {
+			t.Errorf("Unexpected number of instalments in %s schedule. got %d, want %d", name, len(schedule), test.instalments)
+			continue
+		}
+
+		for i, dueDate := range test.dueDates {
+			if got := schedule[i].DueDate.Format("2006-01-02"); got != dueDate {
+				t.Errorf("Unexpected due date for instalment %d of %s schedule. got %s, want %s", i+1, name, got, dueDate)
+			}
+		}
+		for i, principal := range test.principals {
+			if got := schedule[i].Principal.Amount.String(); got != principal {
+				t.Errorf("Unexpected principal for instalment %d of %s schedule. got %s, want %s", i+1, name, got, principal)
+			}
+		}
+
+		totalPrincipal := Decimal{}
+		for _, instalment := range schedule {
+			totalPrincipal = totalPrincipal.Add(instalment.Principal.Amount)
+			if instalment.Payment.Amount.Cmp(instalment.Principal.Amount.Add(instalment.Interest.Amount)) != 0 {
+				t.Errorf("Payment does not equal principal plus interest for instalment %d of %s schedule", instalment.Number, name)
+			}
+		}
+		if totalPrincipal.Cmp(loan.PrincipalAmount.Amount) != 0 {
+			t.Errorf("Schedule %s does not repay the principal. got %s, want %s", name, totalPrincipal, loan.PrincipalAmount.Amount)
+		}
+		if !schedule[len(schedule)-1].RemainingBalance.IsZero() {
+			t.Errorf("Schedule %s does not end with a zero balance. got %s", name, schedule[len(schedule)-1].RemainingBalance)
+		}
+	}
+}
+
+func TestGenerateScheduleAnnuityPayments(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2025-01-01")
+
+	loan := LoanDetails{
+		StartDate:        startDate,
+		EndDate:          endDate,
+		PrincipalAmount:  NewMoney(NewDecimal(10000, 0), CurrencyEUR),
+		BaseInterestRate: NewDecimal(11, 0),
+		Margin:           NewDecimal(1, 0),
+	}
+
+	// 10000 * 1% / (1 - 1.01^-12) = 888.49
+	if got := annuityPayment(loan, FrequencyMonthly, 12).String(); got != "888.49" {
+		t.Errorf("Unexpected annuity payment. got %s, want %s", got, "888.49")
+	}
+
+	schedule, err := GenerateSchedule(loan, RepaymentProfile{Type: RepaymentAnnuity, Frequency: FrequencyMonthly})
+	if err != nil {
+		t.Fatalf("Unexpected error generating annuity schedule: %v", err)
+	}
+
+	for _, instalment := range schedule[:len(schedule)-1] {
+		if got := instalment.Payment.Amount.String(); got != "888.49" {
+			t.Errorf("Unexpected payment for annuity instalment %d. got %s, want %s", instalment.Number, got, "888.49")
+		}
+	}
+}
+
+func TestRepaymentProfileValidate(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2025-01-01")
+
+	loan := LoanDetails{
+		StartDate:       startDate,
+		EndDate:         endDate,
+		PrincipalAmount: NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+	}
+
+	invalid := map[string]RepaymentProfile{
+		"unknown type":        {Type: "balloon"},
+		"annuity frequency":   {Type: RepaymentAnnuity},
+		"unknown frequency":   {Type: RepaymentEqualPrincipal, Frequency: "fortnightly"},
+		"no instalments":      {Type: RepaymentCustom},
+		"partial repayment":   {Type: RepaymentCustom, CustomInstalments: []CustomInstalment{{DueDate: endDate, Principal: NewMoney(NewDecimal(999, 0), CurrencyEUR)}}},
+		"after end date":      {Type: RepaymentCustom, CustomInstalments: []CustomInstalment{{DueDate: endDate.AddDate(0, 0, 1), Principal: NewMoney(NewDecimal(1000, 0), CurrencyEUR)}}},
+		"instalment currency": {Type: RepaymentCustom, CustomInstalments: []CustomInstalment{{DueDate: endDate, Principal: NewMoney(NewDecimal(1000, 0), CurrencyUSD)}}},
+	}
+
+	for name, profile := range invalid {
+		if _, err := GenerateSchedule(loan, profile); err == nil {
+			t.Errorf("Expected error generating schedule with %s but got none", name)
+		}
+	}
+
+	if _, err := NewLoan(LoanDetails{StartDate: startDate, EndDate: endDate, RepaymentProfile: RepaymentProfile{Type: RepaymentAnnuity}}, nil); !errors.Is(err, ErrInvalidPaymentFrequency) {
+		t.Errorf("Expected NewLoan to validate the repayment profile. got %v", err)
+	}
+}
+
+func TestGenerateScheduleAccrualBoundaries(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-31")
+	endDate, _ := ParseDate("2025-01-31")
+
+	for _, boundaries := range AllowedAccrualBoundaries {
+		loan, err := NewLoan(LoanDetails{
+			StartDate:         startDate,
+			EndDate:           endDate,
+			PrincipalAmount:   NewMoney(NewDecimal(36500, 0), CurrencyGBP),
+			BaseInterestRate:  NewDecimal(9, 0),
+			Margin:            NewDecimal(1, 0),
+			RepaymentProfile:  RepaymentProfile{Type: RepaymentBullet, Frequency: FrequencyQuarterly},
+			AccrualBoundaries: boundaries,
+		}, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating %s loan: %v", boundaries, err)
+		}
+
+		// every accrued day falls in exactly one period, so the instalments add up to the interest accrued over the loan
+		scheduled := Decimal{}
+		for _, instalment := range loan.Schedule {
+			scheduled = scheduled.Add(instalment.Interest.Amount)
+		}
+		if want := loan.DailyInterest[len(loan.DailyInterest)-1].TotalInterest.Amount; scheduled.Cmp(want) != 0 {
+			t.Errorf("Unexpected scheduled interest of %s loan. got %s, want %s", boundaries, scheduled, want)
+		}
+	}
+}
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxRequestBytes is the largest request body accepted, leaving room for long rate fixing timelines
+const maxRequestBytes = 10 << 20
+
+// shutdownTimeout is how long in-flight requests are given to finish when the server shuts down
+const shutdownTimeout = 10 * time.Second
+
+// loanRequest is the body of a request creating or replacing a loan, in the same shape as an exported loan
+type loanRequest struct {
+	LoanDetails  LoanDetails   `json:"loan_details"`     // LoanDetails are the details of the loan
+	Transactions []Transaction `json:"transactions"`     // Transactions are the loan's transactions, where a replacement without any keeps the existing ones
+	Reason       string        `json:"reason,omitempty"` // Reason is why the loan was created or replaced, recorded against the version of its details
+}
+
+// errorResponse is the body of a response to a failed request
+type errorResponse struct {
+	Error string `json:"error"` // Error describes why the request failed
+}
+
+// server serves the LoanRepository operations and daily interest calculations as a JSON REST API
+type server struct {
+	loanRepository LoanRepository
+}
+
+// NewServer creates the HTTP handler of the REST API
+func NewServer(loanRepository LoanRepository) http.Handler {
+	s := &server{loanRepository: loanRepository}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /loans", s.handle(s.handleCreate))
+	mux.HandleFunc("GET /loans", s.handle(s.handleList))
+	mux.HandleFunc("GET /loans/{id}", s.handle(s.handleRead))
+	mux.HandleFunc("PUT /loans/{id}", s.handle(s.handleUpdate))
+	mux.HandleFunc("DELETE /loans/{id}", s.handle(s.handleDelete))
+	mux.HandleFunc("POST /loans/{id}/undelete", s.handle(s.handleUndelete))
+	mux.HandleFunc("GET /deleted-loans", s.handle(s.handleDeleted))
+	mux.HandleFunc("GET /loans/{id}/interest", s.handle(s.handleInterest))
+	mux.HandleFunc("GET /loans/{id}/accrued", s.handle(s.handleAccrued))
+	mux.HandleFunc("GET /loans/{id}/versions", s.handle(s.handleVersions))
+	mux.HandleFunc("GET /loans/{id}/versions/{version}", s.handle(s.handleVersion))
+
+	return mux
+}
+
+// Serve serves the REST API on addr until the context is cancelled, then shuts down gracefully, letting in-flight requests finish
+func Serve(ctx context.Context, addr string, handler http.Handler) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+// handle adapts a handler returning an error into a http.HandlerFunc, writing any error as a JSON response
+func (s *server) handle(fn func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			status := errorStatus(err)
+			message := err.Error()
+			if status == http.StatusInternalServerError {
+				fmt.Fprintf(os.Stderr, "error: %s %s: %v\n", r.Method, r.URL.Path, err)
+				message = http.StatusText(status)
+			}
+			writeJSON(w, status, errorResponse{Error: message})
+		}
+	}
+}
+
+// handleCreate handles creating a new loan, generating its ID if none is given
+func (s *server) handleCreate(w http.ResponseWriter, r *http.Request) error {
+	request, err := decodeLoanRequest(w, r)
+	if err != nil {
+		return err
+	}
+
+	if request.LoanDetails.ID == "" {
+		request.LoanDetails.ID = randomString(8)
+	}
+
+	loan, err := newLoanFromRequest(request.LoanDetails, request.Transactions)
+	if err != nil {
+		return err
+	}
+
+	if err := s.loanRepository.Create(r.Context(), loan, requestChange(r, request)); err != nil {
+		return err
+	}
+	loan.Revision = 1
+
+	w.Header().Set("Location", "/loans/"+loan.LoanDetails.ID)
+	setETag(w, loan)
+	writeJSON(w, http.StatusCreated, loan)
+
+	return nil
+}
+
+// handleList handles listing the loans matching the query parameters, named after the list command flags, with the number matched across every page in
+// the X-Total-Count header
+func (s *server) handleList(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	descending, err := parseOptionalBoolInput(query.Get("desc"))
+	if err != nil {
+		return errors.Wrap(err, "desc")
+	}
+
+	loanQuery, err := loanQueryInput{
+		currency:       query.Get("currency"),
+		minPrincipal:   query.Get("min-principal"),
+		maxPrincipal:   query.Get("max-principal"),
+		minRate:        query.Get("min-rate"),
+		maxRate:        query.Get("max-rate"),
+		activeOn:       query.Get("active-on"),
+		maturingBefore: query.Get("maturing-before"),
+		sort:           query.Get("sort"),
+		descending:     descending,
+		page:           query.Get("page"),
+		pageSize:       query.Get("page-size"),
+	}.query()
+	if err != nil {
+		return err
+	}
+
+	page, err := s.loanRepository.List(r.Context(), loanQuery)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	writeJSON(w, http.StatusOK, page.Loans)
+
+	return nil
+}
+
+// handleRead handles reading a loan, with its revision as the ETag header
+func (s *server) handleRead(w http.ResponseWriter, r *http.Request) error {
+	loan, err := s.loanRepository.Read(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+
+	setETag(w, loan)
+	writeJSON(w, http.StatusOK, loan)
+
+	return nil
+}
+
+// handleUpdate handles replacing the details of a loan and recalculating its daily interest, only if the loan is still at a revision given by the
+// If-Match header when there is one
+func (s *server) handleUpdate(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	revisions, err := ifMatchRevisions(r)
+	if err != nil {
+		return err
+	}
+
+	request, err := decodeLoanRequest(w, r)
+	if err != nil {
+		return err
+	}
+
+	if request.LoanDetails.ID != "" && request.LoanDetails.ID != id {
+		return errors.Wrap(ErrInvalidInput, "loan ID does not match the path")
+	}
+	request.LoanDetails.ID = id
+
+	loan, err := s.loanRepository.Read(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	transactions := request.Transactions
+	if transactions == nil {
+		transactions = loan.Transactions
+	}
+
+	updatedLoan, err := newLoanFromRequest(request.LoanDetails, transactions)
+	if err != nil {
+		return err
+	}
+
+	// the loan is replaced only if it has not changed since it was read above
+	updatedLoan.Revision, err = matchRevision(revisions, loan.Revision)
+	if err != nil {
+		return err
+	}
+	if err := s.loanRepository.Update(r.Context(), updatedLoan, requestChange(r, request)); err != nil {
+		return preconditionError(err, revisions)
+	}
+	updatedLoan.Revision++
+
+	setETag(w, updatedLoan)
+	writeJSON(w, http.StatusOK, updatedLoan)
+
+	return nil
+}
+
+// handleDelete handles soft deleting a loan, for the reason given by the reason query parameter, only if the loan is still at a revision given by
+// the If-Match header when there is one
+func (s *server) handleDelete(w http.ResponseWriter, r *http.Request) error {
+	revisions, err := ifMatchRevisions(r)
+	if err != nil {
+		return err
+	}
+
+	// the loan is deleted only if it has not changed since it is read here
+	id := r.PathValue("id")
+	loan, err := s.loanRepository.Read(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	expected, err := matchRevision(revisions, loan.Revision)
+	if err != nil {
+		return err
+	}
+
+	change := LoanChange{ChangedBy: r.Header.Get("X-User"), Reason: r.URL.Query().Get("reason")}
+	if err := s.loanRepository.Delete(r.Context(), id, expected, change); err != nil {
+		return preconditionError(err, revisions)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// handleUndelete handles restoring a deleted loan
+func (s *server) handleUndelete(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+	if err := s.loanRepository.Undelete(r.Context(), id); err != nil {
+		return err
+	}
+
+	loan, err := s.loanRepository.Read(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, loan)
+	writeJSON(w, http.StatusOK, loan)
+
+	return nil
+}
+
+// handleDeleted handles listing every deleted loan that has not been purged, ordered by ID
+func (s *server) handleDeleted(w http.ResponseWriter, r *http.Request) error {
+	deleted, err := s.loanRepository.Deleted(r.Context())
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, deleted)
+
+	return nil
+}
+
+// handleInterest handles fetching the daily interest of a loan, optionally filtered to accrual dates between the from and to query parameters inclusive
+// and summarised per month, quarter or year with the summary query parameter
+func (s *server) handleInterest(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	filter, err := parseHistoryFilterInput(query.Get("from"), query.Get("to"), query.Get("summary"))
+	if err != nil {
+		return err
+	}
+
+	loan, err := s.loanRepository.Read(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+
+	dailyInterest := filter.Apply(loan.DailyInterest)
+	if filter.Summary != "" {
+		writeJSON(w, http.StatusOK, SummariseInterest(dailyInterest, filter.Summary))
+		return nil
+	}
+
+	writeJSON(w, http.StatusOK, dailyInterest)
+
+	return nil
+}
+
+// handleAccrued handles fetching the interest accrued on a loan as of the date query parameter, in the period starting on the from query parameter
+func (s *server) handleAccrued(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	if query.Get("date") == "" {
+		return errors.Wrap(ErrInvalidInput, "date is required")
+	}
+	valuationDate, err := parseDateInput(query.Get("date"))
+	if err != nil {
+		return errors.Wrap(err, "date")
+	}
+	from, err := parseOptionalDateInput(query.Get("from"))
+	if err != nil {
+		return errors.Wrap(err, "from")
+	}
+
+	loan, err := s.loanRepository.Read(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+
+	accrued, err := loan.Accrued(valuationDate, from)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, accrued)
+
+	return nil
+}
+
+// handleVersions handles listing the versions of a loan's details, or the changes between the from and to query parameters when given
+func (s *server) handleVersions(w http.ResponseWriter, r *http.Request) error {
+	versions, err := s.loanRepository.Versions(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	if query.Get("from") == "" && query.Get("to") == "" {
+		writeJSON(w, http.StatusOK, versions)
+		return nil
+	}
+
+	from, err := parseVersionInput(query.Get("from"))
+	if err != nil {
+		return errors.Wrap(err, "from")
+	}
+	to, err := parseVersionInput(query.Get("to"))
+	if err != nil {
+		return errors.Wrap(err, "to")
+	}
+
+	changes, err := DiffVersions(versions, from, to)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, changes)
+
+	return nil
+}
+
+// handleVersion handles fetching a loan as of a version of its details, with its interest recalculated from them
+func (s *server) handleVersion(w http.ResponseWriter, r *http.Request) error {
+	number, err := parseVersionInput(r.PathValue("version"))
+	if err != nil {
+		return err
+	}
+
+	loan, err := s.loanRepository.Read(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	versions, err := s.loanRepository.Versions(r.Context(), loan.LoanDetails.ID)
+	if err != nil {
+		return err
+	}
+
+	asOf, err := LoanAsOf(loan, versions, number)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, asOf)
+
+	return nil
+}
+
+// decodeLoanRequest decodes the body of a request creating or replacing a loan
+func decodeLoanRequest(w http.ResponseWriter, r *http.Request) (loanRequest, error) {
+	var request loanRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&request); err != nil {
+		return loanRequest{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	return request, nil
+}
+
+// requestChange returns the change a request makes to a loan, made by the user named in the X-User header
+func requestChange(r *http.Request, request loanRequest) LoanChange {
+	return LoanChange{ChangedBy: r.Header.Get("X-User"), Reason: request.Reason}
+}
+
+// newLoanFromRequest validates loan details given in a request and creates the loan
+func newLoanFromRequest(details LoanDetails, transactions []Transaction) (Loan, error) {
+	if err := details.Validate(); err != nil {
+		return Loan{}, err
+	}
+
+	return NewLoan(details, transactions)
+}
+
+// errorStatus returns the HTTP status code for an error
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrLoanDoesNotExists), errors.Is(err, ErrVersionDoesNotExist):
+		return http.StatusNotFound
+	case errors.As(err, new(preconditionFailed)):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrLoanAlreadyExists), errors.Is(err, ErrLoanNotDeleted), errors.Is(err, ErrLoanConflict):
+		return http.StatusConflict
+	case isValidationError(err):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// setETag sets the ETag header to the loan's revision
+func setETag(w http.ResponseWriter, loan Loan) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(loan.Revision)))
+}
+
+// ifMatchRevisions returns the revisions of a loan listed as its ETags by the If-Match header, or nil for any revision when it is missing or *.
+// If-Match compares ETags strongly, so weak ETags never match and are skipped.
+func ifMatchRevisions(r *http.Request) ([]int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var revisions []int
+	for _, etag := range strings.Split(header, ",") {
+		etag = strings.TrimSpace(etag)
+		if strings.HasPrefix(etag, "W/") {
+			continue
+		}
+
+		revision, err := strconv.Atoi(strings.Trim(etag, `"`))
+		if err != nil || revision < 1 {
+			return nil, errors.Wrap(ErrInvalidInput, "If-Match must list ETags of the loan")
+		}
+		revisions = append(revisions, revision)
+	}
+	if len(revisions) == 0 {
+		return nil, preconditionFailed{errors.Wrap(ErrLoanConflict, "If-Match must list a strong ETag")}
+	}
+
+	return revisions, nil
+}
+
+// matchRevision returns the current revision of a loan when the If-Match header lists it or is missing,
+// and a failed precondition when it does not
+func matchRevision(revisions []int, current int) (int, error) {
+	if revisions != nil && !slices.Contains(revisions, current) {
+		return 0, preconditionFailed{errors.Wrapf(ErrLoanConflict, "If-Match does not list the loan's revision %d", current)}
+	}
+
+	return current, nil
+}
+
+// preconditionFailed is a conflict with the revision given by an If-Match header, answered with 412 Precondition Failed
+// rather than the 409 Conflict of a loan changed since the request read it
+type preconditionFailed struct {
+	error
+}
+
+// Unwrap returns the conflict
+func (p preconditionFailed) Unwrap() error {
+	return p.error
+}
+
+// preconditionError returns a conflict as a failed precondition when the revisions expected were given by an If-Match header
+func preconditionError(err error, ifMatch []int) error {
+	if ifMatch != nil && errors.Is(err, ErrLoanConflict) {
+		return preconditionFailed{err}
+	}
+	return err
+}
+
+// writeJSON writes a value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testLoanRequest = `{
+	"loan_details": {
+		"id": "loan1",
+		"start_date": "2024-01-01",
+		"end_date": "2024-02-01",
+		"principal_amount": {"amount": "36500", "currency": "EUR"},
+		"base_interest_rate": "5",
+		"margin": "1"
+	}
+}`
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(NewServer(NewInMemoryLoanRepository()))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		ifMatch string
+		status  int
+	}{
+		{name: "create", method: http.MethodPost, path: "/loans", body: testLoanRequest, status: http.StatusCreated},
+		{name: "create duplicate", method: http.MethodPost, path: "/loans", body: testLoanRequest, status: http.StatusConflict},
+		{name: "create malformed", method: http.MethodPost, path: "/loans", body: `{"loan_details":`, status: http.StatusBadRequest},
+		{name: "create invalid", method: http.MethodPost, path: "/loans", body: strings.Replace(testLoanRequest, "EUR", "XYZ", 1), status: http.StatusBadRequest},
+		{name: "create too many places", method: http.MethodPost, path: "/loans", body: strings.Replace(testLoanRequest, `"36500"`, `"36500.001"`, 1), status: http.StatusBadRequest},
+		{name: "read", method: http.MethodGet, path: "/loans/loan1", status: http.StatusOK},
+		{name: "read missing", method: http.MethodGet, path: "/loans/missing", status: http.StatusNotFound},
+		{name: "list", method: http.MethodGet, path: "/loans", status: http.StatusOK},
+		{name: "list filtered", method: http.MethodGet, path: "/loans?currency=eur&active-on=2024-01-15&sort=principal&desc=true&page-size=10", status: http.StatusOK},
+		{name: "list invalid sort", method: http.MethodGet, path: "/loans?sort=name", status: http.StatusBadRequest},
+		{name: "list invalid desc", method: http.MethodGet, path: "/loans?desc=maybe", status: http.StatusBadRequest},
+		{name: "update", method: http.MethodPut, path: "/loans/loan1", body: strings.Replace(testLoanRequest, `"margin": "1"`, `"margin": "2"`, 1), ifMatch: `"1"`, status: http.StatusOK},
+		{name: "update stale", method: http.MethodPut, path: "/loans/loan1", body: testLoanRequest, ifMatch: `"1"`, status: http.StatusPreconditionFailed},
+		{name: "update unlisted if-match", method: http.MethodPut, path: "/loans/loan1", body: testLoanRequest, ifMatch: `"1", W/"2", "3"`, status: http.StatusPreconditionFailed},
+		{name: "update invalid if-match", method: http.MethodPut, path: "/loans/loan1", body: testLoanRequest, ifMatch: "latest", status: http.StatusBadRequest},
+		{name: "update mismatched id", method: http.MethodPut, path: "/loans/loan2", body: testLoanRequest, status: http.StatusBadRequest},
+		{name: "update missing", method: http.MethodPut, path: "/loans/loan2", body: `{"loan_details":{"start_date":"2024-01-01","end_date":"2024-02-01","principal_amount":{"amount":"1","currency":"EUR"}}}`, status: http.StatusNotFound},
+		{name: "interest", method: http.MethodGet, path: "/loans/loan1/interest?from=2024-01-10&to=2024-01-12", status: http.StatusOK},
+		{name: "interest invalid range", method: http.MethodGet, path: "/loans/loan1/interest?from=2024-01-12&to=2024-01-10", status: http.StatusBadRequest},
+		{name: "interest invalid date", method: http.MethodGet, path: "/loans/loan1/interest?from=yesterday", status: http.StatusBadRequest},
+		{name: "interest summary", method: http.MethodGet, path: "/loans/loan1/interest?summary=month", status: http.StatusOK},
+		{name: "interest invalid summary", method: http.MethodGet, path: "/loans/loan1/interest?summary=week", status: http.StatusBadRequest},
+		{name: "accrued", method: http.MethodGet, path: "/loans/loan1/accrued?date=2024-01-10&from=2024-01-06", status: http.StatusOK},
+		{name: "accrued without date", method: http.MethodGet, path: "/loans/loan1/accrued", status: http.StatusBadRequest},
+		{name: "versions", method: http.MethodGet, path: "/loans/loan1/versions", status: http.StatusOK},
+		{name: "versions diff", method: http.MethodGet, path: "/loans/loan1/versions?from=1&to=2", status: http.StatusOK},
+		{name: "version", method: http.MethodGet, path: "/loans/loan1/versions/1", status: http.StatusOK},
+		{name: "version missing", method: http.MethodGet, path: "/loans/loan1/versions/3", status: http.StatusNotFound},
+		{name: "version invalid", method: http.MethodGet, path: "/loans/loan1/versions/first", status: http.StatusBadRequest},
+		{name: "delete stale", method: http.MethodDelete, path: "/loans/loan1", ifMatch: `"1"`, status: http.StatusPreconditionFailed},
+		{name: "delete weak if-match", method: http.MethodDelete, path: "/loans/loan1", ifMatch: `W/"2"`, status: http.StatusPreconditionFailed},
+		{name: "delete invalid if-match list", method: http.MethodDelete, path: "/loans/loan1", ifMatch: `"1", latest`, status: http.StatusBadRequest},
+		{name: "delete", method: http.MethodDelete, path: "/loans/loan1", ifMatch: `"1", "2"`, status: http.StatusNoContent},
+		{name: "delete missing", method: http.MethodDelete, path: "/loans/loan1", status: http.StatusNotFound},
+		{name: "deleted", method: http.MethodGet, path: "/deleted-loans", status: http.StatusOK},
+		{name: "undelete", method: http.MethodPost, path: "/loans/loan1/undelete", status: http.StatusOK},
+		{name: "undelete not deleted", method: http.MethodPost, path: "/loans/loan1/undelete", status: http.StatusConflict},
+	}
+
+	for _, test := range tests {
+		request, _ := http.NewRequest(test.method, server.URL+test.path, strings.NewReader(test.body))
+		if test.ifMatch != "" {
+			request.Header.Set("If-Match", test.ifMatch)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Unexpected error in %s request: %v", test.name, err)
+		}
+
+		var body json.RawMessage
+		json.NewDecoder(response.Body).Decode(&body)
+		response.Body.Close()
+
+		if response.StatusCode != test.status {
+			t.Errorf("Unexpected status for %s request. got %d, want %d: %s", test.name, response.StatusCode, test.status, body)
+			continue
+		}
+
+		switch test.name {
+		case "update":
+			var loan Loan
+			json.Unmarshal(body, &loan)
+			if loan.LoanDetails.Margin.String() != "2" {
+				t.Errorf("Loan was not updated. got margin %s", loan.LoanDetails.Margin)
+			}
+			if etag := response.Header.Get("ETag"); etag != `"2"` || loan.Revision != 2 {
+				t.Errorf("Expected the updated loan at revision 2. got ETag %s, revision %d", etag, loan.Revision)
+			}
+		case "interest":
+			var interest []Interest
+			json.Unmarshal(body, &interest)
+			if len(interest) != 3 || interest[0].AccrualDate.Format("2006-01-02") != "2024-01-10" {
+				t.Errorf("Interest was not filtered to the date range. got %s", body)
+			}
+		case "accrued":
+			var accrued AccruedInterest
+			json.Unmarshal(body, &accrued)
+			if accrued.AccruedInPeriod.IsZero() || accrued.AccruedToDate.Amount.Cmp(accrued.AccruedInPeriod.Amount) <= 0 {
+				t.Errorf("Unexpected accrued interest. got %s", body)
+			}
+		case "deleted":
+			var deleted []DeletedLoan
+			json.Unmarshal(body, &deleted)
+			if len(deleted) != 1 || deleted[0].Loan.LoanDetails.ID != "loan1" {
+				t.Errorf("Deleted loan was not listed. got %s", body)
+			}
+		case "versions diff":
+			var changes []FieldChange
+			json.Unmarshal(body, &changes)
+			if len(changes) != 1 || changes[0].String() != "margin: 1 -> 2" {
+				t.Errorf("Unexpected changes between versions. got %s", body)
+			}
+		case "version":
+			var loan Loan
+			json.Unmarshal(body, &loan)
+			if loan.LoanDetails.Margin.String() != "1" {
+				t.Errorf("Loan was not as of the first version. got margin %s", loan.LoanDetails.Margin)
+			}
+		case "interest summary":
+			var summaries []InterestSummary
+			json.Unmarshal(body, &summaries)
+			if len(summaries) != 1 || summaries[0].Period != "2024-01" {
+				t.Errorf("Interest was not summarised by month. got %s", body)
+			}
+		}
+	}
+}
+
+func TestServeShutsDownGracefully(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error finding a free port: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, addr, NewServer(NewInMemoryLoanRepository()))
+	}()
+
+	// wait for the server to start accepting requests
+	for i := 0; ; i++ {
+		response, err := http.Get("http://" + addr + "/loans")
+		if err == nil {
+			response.Body.Close()
+			break
+		}
+		if i == 50 {
+			t.Fatalf("Server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error shutting down: %v", err)
+		}
+	case <-time.After(shutdownTimeout):
+		t.Errorf("Server did not shut down")
+	}
+}
+
+func TestPreconditionError(t *testing.T) {
+	conflict := fmt.Errorf("expected revision 1 but the loan is at revision 2: %w", ErrLoanConflict)
+
+	if status := errorStatus(preconditionError(conflict, nil)); status != http.StatusConflict {
+		t.Errorf("Expected a conflict without If-Match to be answered with 409. got %d", status)
+	}
+	if status := errorStatus(preconditionError(conflict, []int{1})); status != http.StatusPreconditionFailed {
+		t.Errorf("Expected a conflict with If-Match to be answered with 412. got %d", status)
+	}
+	if status := errorStatus(preconditionError(ErrLoanDoesNotExists, []int{1})); status != http.StatusNotFound {
+		t.Errorf("Expected a missing loan to be answered with 404 whatever If-Match holds. got %d", status)
+	}
+}
@@ -0,0 +1,949 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ (LoanRepository) = (*sqlLoanRepository)(nil)
+
+// sqlMigrations are the schema migrations applied in order, where the version of each is its 1-based position
+var sqlMigrations = []string{
+	`CREATE TABLE loans (
+		id                   TEXT PRIMARY KEY,
+		start_date           TEXT NOT NULL,
+		end_date             TEXT NOT NULL,
+		currency             TEXT NOT NULL,
+		principal_amount     TEXT NOT NULL,
+		base_interest_rate   TEXT NOT NULL,
+		margin               TEXT NOT NULL,
+		calculation_method   TEXT NOT NULL,
+		day_count_convention TEXT NOT NULL,
+		rounding_mode        TEXT NOT NULL,
+		rounding_point       TEXT NOT NULL,
+		repayment_type       TEXT NOT NULL,
+		payment_frequency    TEXT NOT NULL
+	);
+
+	CREATE TABLE loan_floating_rates (
+		loan_id           TEXT PRIMARY KEY REFERENCES loans (id),
+		rate_index        TEXT NOT NULL,
+		lookback_days     INTEGER NOT NULL,
+		observation_shift INTEGER NOT NULL,
+		floor             TEXT,
+		cap               TEXT
+	);
+
+	CREATE TABLE loan_rate_fixings (
+		loan_id        TEXT NOT NULL REFERENCES loans (id),
+		effective_date TEXT NOT NULL,
+		rate           TEXT NOT NULL,
+		PRIMARY KEY (loan_id, effective_date)
+	);
+
+	CREATE TABLE loan_custom_instalments (
+		loan_id   TEXT NOT NULL REFERENCES loans (id),
+		number    INTEGER NOT NULL,
+		due_date  TEXT NOT NULL,
+		principal TEXT NOT NULL,
+		PRIMARY KEY (loan_id, number)
+	);
+
+	CREATE TABLE loan_transactions (
+		loan_id        TEXT NOT NULL REFERENCES loans (id),
+		id             TEXT NOT NULL,
+		position       INTEGER NOT NULL,
+		type           TEXT NOT NULL,
+		effective_date TEXT NOT NULL,
+		amount         TEXT NOT NULL,
+		PRIMARY KEY (loan_id, id)
+	);
+
+	CREATE TABLE loan_interest (
+		loan_id                       TEXT NOT NULL REFERENCES loans (id),
+		days_elapsed                  INTEGER NOT NULL,
+		accrual_date                  TEXT NOT NULL,
+		balance                       TEXT NOT NULL,
+		base_interest_rate            TEXT NOT NULL,
+		interest_rate                 TEXT NOT NULL,
+		daily_interest_without_margin TEXT NOT NULL,
+		daily_interest_accrued        TEXT NOT NULL,
+		total_interest                TEXT NOT NULL,
+		PRIMARY KEY (loan_id, days_elapsed)
+	);
+
+	CREATE TABLE loan_instalments (
+		loan_id           TEXT NOT NULL REFERENCES loans (id),
+		number            INTEGER NOT NULL,
+		due_date          TEXT NOT NULL,
+		principal         TEXT NOT NULL,
+		interest          TEXT NOT NULL,
+		payment           TEXT NOT NULL,
+		remaining_balance TEXT NOT NULL,
+		PRIMARY KEY (loan_id, number)
+	);`,
+	`ALTER TABLE loans ADD COLUMN calendar TEXT NOT NULL DEFAULT '';
+	ALTER TABLE loans ADD COLUMN business_day_convention TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE loans ADD COLUMN accrual_boundaries TEXT NOT NULL DEFAULT 'start-inclusive';`,
+	`CREATE TABLE loan_versions (
+		loan_id    TEXT NOT NULL REFERENCES loans (id),
+		version    INTEGER NOT NULL,
+		changed_at TEXT NOT NULL,
+		changed_by TEXT NOT NULL,
+		reason     TEXT NOT NULL,
+		changes    TEXT NOT NULL,
+		details    TEXT NOT NULL,
+		PRIMARY KEY (loan_id, version)
+	);`,
+	`ALTER TABLE loans ADD COLUMN deleted_at TEXT;
+	ALTER TABLE loans ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+	ALTER TABLE loans ADD COLUMN deleted_reason TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE loans ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;`,
+}
+
+// sqlLoanChildTables are the tables holding rows that belong to a loan, which are replaced whenever the loan is written.
+// Versions are only ever appended to, so loan_versions is not one of them.
+var sqlLoanChildTables = []string{
+	"loan_floating_rates",
+	"loan_rate_fixings",
+	"loan_custom_instalments",
+	"loan_transactions",
+	"loan_interest",
+	"loan_instalments",
+}
+
+// sqlLoanRepository is a LoanRepository backed by a relational database through database/sql
+type sqlLoanRepository struct {
+	db *sql.DB
+}
+
+// NewSQLLoanRepository creates a new LoanRepository over a database, migrating its schema to the latest version
+func NewSQLLoanRepository(db *sql.DB) (*sqlLoanRepository, error) {
+	s := &sqlLoanRepository{db: db}
+	if err := s.migrate(); err != nil {
+		return nil, errors.Wrap(err, "failed to migrate database")
+	}
+
+	return s, nil
+}
+
+// Close closes the underlying database
+func (s *sqlLoanRepository) Close() error {
+	return s.db.Close()
+}
+
+// Create implements LoanRepository
+func (s *sqlLoanRepository) Create(ctx context.Context, loan Loan, change LoanChange) error {
+	return s.CreateBatch(ctx, []Loan{loan}, change)
+}
+
+// CreateBatch implements LoanRepository
+func (s *sqlLoanRepository) CreateBatch(ctx context.Context, loans []Loan, change LoanChange) error {
+	if err := validateBatchIDs(loans); err != nil {
+		return err
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+		for _, loan := range loans {
+			if err := insertLoan(tx, loan, change, now); err != nil {
+				return batchError(len(loans), loan.LoanDetails.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Read implements LoanRepository
+func (s *sqlLoanRepository) Read(ctx context.Context, id string) (Loan, error) {
+	loans, err := s.ReadBatch(ctx, []string{id})
+	if err != nil {
+		return Loan{}, err
+	}
+
+	return loans[0], nil
+}
+
+// ReadBatch implements LoanRepository
+func (s *sqlLoanRepository) ReadBatch(ctx context.Context, ids []string) ([]Loan, error) {
+	loans := make([]Loan, len(ids))
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for n, id := range ids {
+			loan, err := readLoan(tx, id, false)
+			if err != nil {
+				return batchError(len(ids), id, err)
+			}
+			loans[n] = loan
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loans, nil
+}
+
+// List implements LoanRepository
+func (s *sqlLoanRepository) List(ctx context.Context, query LoanQuery) (LoanPage, error) {
+	if err := query.Validate(); err != nil {
+		return LoanPage{}, err
+	}
+
+	var page LoanPage
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if page, err = listLoanRows(tx, query); err != nil {
+			return err
+		}
+
+		// only the loans on the page are read in full, along with their interest and schedule
+		for n, loan := range page.Loans {
+			if page.Loans[n], err = readLoan(tx, loan.LoanDetails.ID, false); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return LoanPage{}, err
+	}
+
+	return page, nil
+}
+
+// Update implements LoanRepository
+func (s *sqlLoanRepository) Update(ctx context.Context, loan Loan, change LoanChange) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		previous, err := readLoan(tx, loan.LoanDetails.ID, false)
+		if err != nil {
+			return err
+		}
+		if err := checkRevision(previous.Revision, loan.Revision); err != nil {
+			return err
+		}
+		versions, err := readLoanVersions(tx, loan.LoanDetails.ID)
+		if err != nil {
+			return err
+		}
+
+		values := append(loanValues(loan.LoanDetails)[1:], previous.Revision+1, loan.LoanDetails.ID)
+		result, err := tx.Exec(`UPDATE loans SET start_date = ?, end_date = ?, currency = ?, principal_amount = ?, base_interest_rate = ?,
+			margin = ?, calculation_method = ?, day_count_convention = ?, rounding_mode = ?, rounding_point = ?, repayment_type = ?,
+			payment_frequency = ?, calendar = ?, business_day_convention = ?, accrual_boundaries = ?, revision = ? WHERE id = ?`, values...)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result); err != nil {
+			return err
+		}
+
+		if err := deleteLoanChildren(tx, loan.LoanDetails.ID); err != nil {
+			return err
+		}
+		if err := insertLoanChildren(tx, loan); err != nil {
+			return err
+		}
+
+		return insertLoanVersions(tx, nextVersions(versions, &previous.LoanDetails, loan.LoanDetails, change, time.Now().UTC()))
+	})
+}
+
+// Delete implements LoanRepository
+func (s *sqlLoanRepository) Delete(ctx context.Context, id string, revision int, change LoanChange) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var stored int
+		err := tx.QueryRow(`SELECT revision FROM loans WHERE id = ? AND deleted_at IS NULL`, id).Scan(&stored)
+		if err == sql.ErrNoRows {
+			return ErrLoanDoesNotExists
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkRevision(stored, revision); err != nil {
+			return err
+		}
+
+		deletion := newLoanDeletion(change, time.Now().UTC())
+		result, err := tx.Exec(`UPDATE loans SET deleted_at = ?, deleted_by = ?, deleted_reason = ? WHERE id = ? AND deleted_at IS NULL`,
+			deletion.DeletedAt.Format(time.RFC3339Nano), deletion.DeletedBy, deletion.Reason, id)
+		if err != nil {
+			return err
+		}
+
+		return requireAffected(result)
+	})
+}
+
+// CreateDeleted implements LoanRepository
+func (s *sqlLoanRepository) CreateDeleted(ctx context.Context, deleted DeletedLoan, change LoanChange) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := insertLoan(tx, deleted.Loan, change, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`UPDATE loans SET deleted_at = ?, deleted_by = ?, deleted_reason = ? WHERE id = ?`,
+			deleted.DeletedAt.Format(time.RFC3339Nano), deleted.DeletedBy, deleted.Reason, deleted.Loan.LoanDetails.ID)
+		return err
+	})
+}
+
+// Versions implements LoanRepository
+func (s *sqlLoanRepository) Versions(ctx context.Context, id string) ([]LoanVersion, error) {
+	var versions []LoanVersion
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		loan, err := readLoan(tx, id, false)
+		if err != nil {
+			return err
+		}
+		recorded, err := readLoanVersions(tx, id)
+		if err != nil {
+			return err
+		}
+		versions = versionsOf(loan, recorded)
+		return nil
+	})
+
+	return versions, err
+}
+
+// Undelete implements LoanRepository
+func (s *sqlLoanRepository) Undelete(ctx context.Context, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := requireDeleted(tx, id); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`UPDATE loans SET deleted_at = NULL, deleted_by = '', deleted_reason = '', revision = revision + 1 WHERE id = ?`, id)
+		return err
+	})
+}
+
+// Deleted implements LoanRepository
+func (s *sqlLoanRepository) Deleted(ctx context.Context) ([]DeletedLoan, error) {
+	var deleted []DeletedLoan
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var deletions []LoanDeletion
+		var ids []string
+		err := queryRows(tx, func(rows *sql.Rows) error {
+			var id, deletedAt string
+			var deletion LoanDeletion
+			if err := rows.Scan(&id, &deletedAt, &deletion.DeletedBy, &deletion.Reason); err != nil {
+				return err
+			}
+
+			var err error
+			if deletion.DeletedAt, err = time.Parse(time.RFC3339Nano, deletedAt); err != nil {
+				return err
+			}
+
+			ids = append(ids, id)
+			deletions = append(deletions, deletion)
+			return nil
+		}, `SELECT id, deleted_at, deleted_by, deleted_reason FROM loans WHERE deleted_at IS NOT NULL ORDER BY id`)
+		if err != nil {
+			return err
+		}
+
+		for i, id := range ids {
+			loan, err := readLoan(tx, id, true)
+			if err != nil {
+				return err
+			}
+			deleted = append(deleted, DeletedLoan{Loan: loan, LoanDeletion: deletions[i]})
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
+
+// Purge implements LoanRepository
+func (s *sqlLoanRepository) Purge(ctx context.Context, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := requireDeleted(tx, id); err != nil {
+			return err
+		}
+
+		if err := deleteLoanChildren(tx, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM loan_versions WHERE loan_id = ?`, id); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`DELETE FROM loans WHERE id = ?`, id)
+		return err
+	})
+}
+
+// migrate applies any schema migrations that have not yet been applied
+func (s *sqlLoanRepository) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+		return err
+	}
+
+	return s.inTx(context.Background(), func(tx *sql.Tx) error {
+		var version int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+			return err
+		}
+
+		for i := version; i < len(sqlMigrations); i++ {
+			if _, err := tx.Exec(sqlMigrations[i]); err != nil {
+				return errors.Wrapf(err, "migration %d", i+1)
+			}
+			if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, i+1, time.Now().UTC().Format(time.RFC3339)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// inTx runs fn inside a transaction, committing if it succeeds and rolling back otherwise, including when the context is done first
+func (s *sqlLoanRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertLoan inserts a new loan and its children, recording its details as version 1
+func insertLoan(tx *sql.Tx, loan Loan, change LoanChange, now time.Time) error {
+	exists, err := loanExists(tx, loan.LoanDetails.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrLoanAlreadyExists
+	}
+
+	if _, err := tx.Exec(`INSERT INTO loans (id, start_date, end_date, currency, principal_amount, base_interest_rate, margin,
+		calculation_method, day_count_convention, rounding_mode, rounding_point, repayment_type, payment_frequency, calendar,
+		business_day_convention, accrual_boundaries) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, loanValues(loan.LoanDetails)...); err != nil {
+		return err
+	}
+
+	if err := insertLoanChildren(tx, loan); err != nil {
+		return err
+	}
+
+	return insertLoanVersions(tx, nextVersions(nil, nil, loan.LoanDetails, change, now))
+}
+
+// loanValues returns the values of the loans table columns for a loan, in column order
+func loanValues(details LoanDetails) []any {
+	return []any{
+		details.ID,
+		formatSQLDate(details.StartDate),
+		formatSQLDate(details.EndDate),
+		details.Currency().String(),
+		details.PrincipalAmount.Amount.String(),
+		details.BaseInterestRate.String(),
+		details.Margin.String(),
+		details.CalculationMethod.String(),
+		details.DayCountConvention.String(),
+		details.RoundingMode.String(),
+		details.RoundingPoint.String(),
+		details.RepaymentProfile.Type.String(),
+		details.RepaymentProfile.Frequency.String(),
+		details.Calendar.String(),
+		details.BusinessDayConvention.String(),
+		details.AccrualBoundaries.String(),
+	}
+}
+
+// insertLoanChildren inserts the rows belonging to a loan into each of the child tables
+func insertLoanChildren(tx *sql.Tx, loan Loan) error {
+	id := loan.LoanDetails.ID
+
+	if floatingRate := loan.LoanDetails.FloatingRate; floatingRate != nil {
+		if _, err := tx.Exec(`INSERT INTO loan_floating_rates (loan_id, rate_index, lookback_days, observation_shift, floor, cap) VALUES (?, ?, ?, ?, ?, ?)`,
+			id, floatingRate.Index, floatingRate.LookbackDays, floatingRate.ObservationShift, nullableDecimal(floatingRate.Floor), nullableDecimal(floatingRate.Cap)); err != nil {
+			return err
+		}
+
+		for _, fixing := range floatingRate.Fixings {
+			if _, err := tx.Exec(`INSERT INTO loan_rate_fixings (loan_id, effective_date, rate) VALUES (?, ?, ?)`,
+				id, formatSQLDate(fixing.EffectiveDate), fixing.Rate.String()); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, instalment := range loan.LoanDetails.RepaymentProfile.CustomInstalments {
+		if _, err := tx.Exec(`INSERT INTO loan_custom_instalments (loan_id, number, due_date, principal) VALUES (?, ?, ?, ?)`,
+			id, i+1, formatSQLDate(instalment.DueDate), instalment.Principal.Amount.String()); err != nil {
+			return err
+		}
+	}
+
+	for i, transaction := range loan.Transactions {
+		if _, err := tx.Exec(`INSERT INTO loan_transactions (loan_id, id, position, type, effective_date, amount) VALUES (?, ?, ?, ?, ?, ?)`,
+			id, transaction.ID, i, transaction.Type.String(), formatSQLDate(transaction.EffectiveDate), transaction.Amount.Amount.String()); err != nil {
+			return err
+		}
+	}
+
+	for _, interest := range loan.DailyInterest {
+		if _, err := tx.Exec(`INSERT INTO loan_interest (loan_id, days_elapsed, accrual_date, balance, base_interest_rate, interest_rate,
+			daily_interest_without_margin, daily_interest_accrued, total_interest) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, interest.DaysElapsed, formatSQLDate(interest.AccrualDate), interest.Balance.Amount.String(), interest.BaseInterestRate.String(),
+			interest.InterestRate.String(), interest.DailyInterestWithoutMargin.Amount.String(), interest.DailyInterestAccrued.Amount.String(),
+			interest.TotalInterest.Amount.String()); err != nil {
+			return err
+		}
+	}
+
+	for _, instalment := range loan.Schedule {
+		if _, err := tx.Exec(`INSERT INTO loan_instalments (loan_id, number, due_date, principal, interest, payment, remaining_balance) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, instalment.Number, formatSQLDate(instalment.DueDate), instalment.Principal.Amount.String(), instalment.Interest.Amount.String(),
+			instalment.Payment.Amount.String(), instalment.RemainingBalance.Amount.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteLoanChildren deletes the rows belonging to a loan from each of the child tables
+func deleteLoanChildren(tx *sql.Tx, id string) error {
+	for _, table := range sqlLoanChildTables {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE loan_id = ?`, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertLoanVersions inserts versions of a loan, holding the changes and details of each as JSON
+func insertLoanVersions(tx *sql.Tx, versions []LoanVersion) error {
+	for _, version := range versions {
+		changes, err := json.Marshal(version.Changes)
+		if err != nil {
+			return err
+		}
+		details, err := json.Marshal(version.Details)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO loan_versions (loan_id, version, changed_at, changed_by, reason, changes, details) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			version.LoanID, version.Version, version.ChangedAt.Format(time.RFC3339Nano), version.ChangedBy, version.Reason, string(changes), string(details)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readLoanVersions reads the recorded versions of a loan in order
+func readLoanVersions(tx *sql.Tx, id string) ([]LoanVersion, error) {
+	var versions []LoanVersion
+	err := queryRows(tx, func(rows *sql.Rows) error {
+		var version LoanVersion
+		var changedAt, changes, details string
+		if err := rows.Scan(&version.LoanID, &version.Version, &changedAt, &version.ChangedBy, &version.Reason, &changes, &details); err != nil {
+			return err
+		}
+
+		var err error
+		if version.ChangedAt, err = time.Parse(time.RFC3339Nano, changedAt); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(changes), &version.Changes); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(details), &version.Details); err != nil {
+			return err
+		}
+
+		versions = append(versions, version)
+		return nil
+	}, `SELECT loan_id, version, changed_at, changed_by, reason, changes, details FROM loan_versions WHERE loan_id = ? ORDER BY version`, id)
+
+	return versions, err
+}
+
+// listLoanRows lists the page of live loans matching a query from their loans rows alone, without the child rows that hold
+// the rest of each loan other than its floating rate, which is only read when the query filters or sorts by the all-in rate
+func listLoanRows(tx *sql.Tx, query LoanQuery) (LoanPage, error) {
+	// the currency and the start date of the loans active on a date are filtered by the database, whereas the principal and rates
+	// are decimals held as text and the maturity date is adjusted for business days, so they are filtered and sorted by the query
+	activeOn := ""
+	if !query.ActiveOn.IsZero() {
+		activeOn = formatSQLDate(query.ActiveOn)
+	}
+	from := ` FROM loans WHERE deleted_at IS NULL AND (? = '' OR currency = ?) AND (? = '' OR start_date <= ?)`
+	args := []any{query.Currency.String(), query.Currency.String(), activeOn, activeOn}
+
+	if column, ok := sqlLoanOrder(query); ok {
+		var total int
+		if err := tx.QueryRow(`SELECT COUNT(*)`+from, args...).Scan(&total); err != nil {
+			return LoanPage{}, err
+		}
+
+		page, pages, limit, offset := max(query.Page, 1), 1, -1, 0
+		if query.PageSize > 0 {
+			pages = max((total+query.PageSize-1)/query.PageSize, 1)
+			limit, offset = query.PageSize, (page-1)*query.PageSize
+		}
+
+		direction := "ASC"
+		if query.Descending {
+			direction = "DESC"
+		}
+		loans, err := queryLoanRows(tx, `SELECT `+loanColumns+from+` ORDER BY `+column+` `+direction+`, id `+direction+` LIMIT ? OFFSET ?`,
+			append(args, limit, offset)...)
+		if err != nil {
+			return LoanPage{}, err
+		}
+
+		return LoanPage{Loans: loans, Total: total, Page: page, Pages: pages}, nil
+	}
+
+	loans, err := queryLoanRows(tx, `SELECT `+loanColumns+from, args...)
+	if err != nil {
+		return LoanPage{}, err
+	}
+
+	if query.MinRate != nil || query.MaxRate != nil || query.Sort == LoanSortRate {
+		for n := range loans {
+			parser := &sqlValueParser{currency: loans[n].LoanDetails.Currency()}
+			if err := readFloatingRate(tx, parser, &loans[n].LoanDetails); err != nil {
+				return LoanPage{}, err
+			}
+			if parser.err != nil {
+				return LoanPage{}, parser.err
+			}
+		}
+	}
+
+	return query.Apply(loans), nil
+}
+
+// sqlLoanOrder returns the loans column a query is ordered by when the database can both filter and order by every field of the query
+func sqlLoanOrder(query LoanQuery) (string, bool) {
+	if query.MinPrincipal != nil || query.MaxPrincipal != nil || query.MinRate != nil || query.MaxRate != nil ||
+		!query.ActiveOn.IsZero() || !query.MaturingBefore.IsZero() {
+		return "", false
+	}
+
+	switch query.Sort {
+	case "", LoanSortID:
+		return "id", true
+	case LoanSortCurrency:
+		return "currency", true
+	case LoanSortStart:
+		return "start_date", true
+	default:
+		return "", false
+	}
+}
+
+// queryLoanRows queries the loanColumns of loans rows, holding each loan's details without any of its child rows
+func queryLoanRows(tx *sql.Tx, query string, args ...any) ([]Loan, error) {
+	loans := []Loan{}
+	err := queryRows(tx, func(rows *sql.Rows) error {
+		loan, parser, err := scanLoanRow(rows)
+		if err != nil {
+			return err
+		}
+		if parser.err != nil {
+			return parser.err
+		}
+		loans = append(loans, loan)
+		return nil
+	}, query, args...)
+
+	return loans, err
+}
+
+// loanColumns are the columns of the loans table holding a loan's details and revision, in the order scanned by scanLoanRow
+const loanColumns = `id, start_date, end_date, currency, principal_amount, base_interest_rate, margin, calculation_method,
+	day_count_convention, rounding_mode, rounding_point, repayment_type, payment_frequency, calendar, business_day_convention,
+	accrual_boundaries, revision`
+
+// scanLoanRow scans the loanColumns of a loans row into a loan without its child rows, returning the parser of its values
+func scanLoanRow(row interface{ Scan(dest ...any) error }) (Loan, *sqlValueParser, error) {
+	var (
+		loan                                          Loan
+		startDate, endDate, currency                  string
+		principal, baseRate, margin                   string
+		method, dayCount, roundingMode, roundingPoint string
+		repaymentType, frequency                      string
+		calendar, businessDay, accrual                string
+	)
+
+	err := row.Scan(&loan.LoanDetails.ID, &startDate, &endDate, &currency, &principal, &baseRate, &margin, &method, &dayCount,
+		&roundingMode, &roundingPoint, &repaymentType, &frequency, &calendar, &businessDay, &accrual, &loan.Revision)
+	if err != nil {
+		return Loan{}, nil, err
+	}
+
+	parser := &sqlValueParser{currency: Currency(currency)}
+	details := &loan.LoanDetails
+	details.StartDate = parser.date(startDate)
+	details.EndDate = parser.date(endDate)
+	details.PrincipalAmount = parser.money(principal)
+	details.BaseInterestRate = parser.decimal(baseRate)
+	details.Margin = parser.decimal(margin)
+	details.CalculationMethod = CalculationMethod(method)
+	details.DayCountConvention = DayCountConvention(dayCount)
+	details.RoundingMode = RoundingMode(roundingMode)
+	details.RoundingPoint = RoundingPoint(roundingPoint)
+	details.RepaymentProfile = RepaymentProfile{Type: RepaymentType(repaymentType), Frequency: PaymentFrequency(frequency)}
+	details.Calendar = Calendar(calendar)
+	details.BusinessDayConvention = BusinessDayConvention(businessDay)
+	details.AccrualBoundaries = AccrualBoundaries(accrual)
+
+	return loan, parser, nil
+}
+
+// readLoan reads a live or deleted loan and all of its child rows
+func readLoan(tx *sql.Tx, id string, deleted bool) (Loan, error) {
+	loan, parser, err := scanLoanRow(tx.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE id = ? AND (deleted_at IS NOT NULL) = ?`, id, deleted))
+	if err == sql.ErrNoRows {
+		return Loan{}, ErrLoanDoesNotExists
+	}
+	if err != nil {
+		return Loan{}, err
+	}
+
+	details := &loan.LoanDetails
+	if err := readFloatingRate(tx, parser, details); err != nil {
+		return Loan{}, err
+	}
+
+	err = queryRows(tx, func(rows *sql.Rows) error {
+		var dueDate, amount string
+		if err := rows.Scan(&dueDate, &amount); err != nil {
+			return err
+		}
+		details.RepaymentProfile.CustomInstalments = append(details.RepaymentProfile.CustomInstalments, CustomInstalment{
+			DueDate:   parser.date(dueDate),
+			Principal: parser.money(amount),
+		})
+		return nil
+	}, `SELECT due_date, principal FROM loan_custom_instalments WHERE loan_id = ? ORDER BY number`, id)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	loan.Transactions = []Transaction{}
+	err = queryRows(tx, func(rows *sql.Rows) error {
+		var transaction Transaction
+		var effectiveDate, amount string
+		if err := rows.Scan(&transaction.ID, &transaction.Type, &effectiveDate, &amount); err != nil {
+			return err
+		}
+		transaction.EffectiveDate = parser.date(effectiveDate)
+		transaction.Amount = parser.money(amount)
+		loan.Transactions = append(loan.Transactions, transaction)
+		return nil
+	}, `SELECT id, type, effective_date, amount FROM loan_transactions WHERE loan_id = ? ORDER BY position`, id)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	loan.DailyInterest = []Interest{}
+	err = queryRows(tx, func(rows *sql.Rows) error {
+		var interest Interest
+		var accrualDate, balance, baseRate, rate, withoutMargin, accrued, total string
+		if err := rows.Scan(&interest.DaysElapsed, &accrualDate, &balance, &baseRate, &rate, &withoutMargin, &accrued, &total); err != nil {
+			return err
+		}
+		interest.AccrualDate = parser.date(accrualDate)
+		interest.Balance = parser.money(balance)
+		interest.BaseInterestRate = parser.decimal(baseRate)
+		interest.InterestRate = parser.decimal(rate)
+		interest.DailyInterestWithoutMargin = parser.money(withoutMargin)
+		interest.DailyInterestAccrued = parser.money(accrued)
+		interest.TotalInterest = parser.money(total)
+		loan.DailyInterest = append(loan.DailyInterest, interest)
+		return nil
+	}, `SELECT days_elapsed, accrual_date, balance, base_interest_rate, interest_rate, daily_interest_without_margin,
+		daily_interest_accrued, total_interest FROM loan_interest WHERE loan_id = ? ORDER BY days_elapsed`, id)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	loan.Schedule = []Instalment{}
+	err = queryRows(tx, func(rows *sql.Rows) error {
+		var instalment Instalment
+		var dueDate, principal, interest, payment, remaining string
+		if err := rows.Scan(&instalment.Number, &dueDate, &principal, &interest, &payment, &remaining); err != nil {
+			return err
+		}
+		instalment.DueDate = parser.date(dueDate)
+		instalment.Principal = parser.money(principal)
+		instalment.Interest = parser.money(interest)
+		instalment.Payment = parser.money(payment)
+		instalment.RemainingBalance = parser.money(remaining)
+		loan.Schedule = append(loan.Schedule, instalment)
+		return nil
+	}, `SELECT number, due_date, principal, interest, payment, remaining_balance FROM loan_instalments WHERE loan_id = ? ORDER BY number`, id)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	return loan, parser.err
+}
+
+// readFloatingRate reads the floating rate and its fixings for a loan, if it has one
+func readFloatingRate(tx *sql.Tx, parser *sqlValueParser, details *LoanDetails) error {
+	var (
+		floatingRate   FloatingRate
+		floor, rateCap sql.NullString
+	)
+
+	err := tx.QueryRow(`SELECT rate_index, lookback_days, observation_shift, floor, cap FROM loan_floating_rates WHERE loan_id = ?`, details.ID).Scan(
+		&floatingRate.Index, &floatingRate.LookbackDays, &floatingRate.ObservationShift, &floor, &rateCap)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if floor.Valid {
+		value := parser.decimal(floor.String)
+		floatingRate.Floor = &value
+	}
+	if rateCap.Valid {
+		value := parser.decimal(rateCap.String)
+		floatingRate.Cap = &value
+	}
+
+	err = queryRows(tx, func(rows *sql.Rows) error {
+		var effectiveDate, rate string
+		if err := rows.Scan(&effectiveDate, &rate); err != nil {
+			return err
+		}
+		floatingRate.Fixings = append(floatingRate.Fixings, RateFixing{EffectiveDate: parser.date(effectiveDate), Rate: parser.decimal(rate)})
+		return nil
+	}, `SELECT effective_date, rate FROM loan_rate_fixings WHERE loan_id = ? ORDER BY effective_date`, details.ID)
+	if err != nil {
+		return err
+	}
+
+	details.FloatingRate = &floatingRate
+	return nil
+}
+
+// loanExists returns whether a loan with the given ID exists
+func loanExists(tx *sql.Tx, id string) (bool, error) {
+	err := tx.QueryRow(`SELECT 1 FROM loans WHERE id = ?`, id).Scan(new(int))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// requireDeleted returns an error unless the loan exists and is deleted
+func requireDeleted(tx *sql.Tx, id string) error {
+	var deletedAt sql.NullString
+	err := tx.QueryRow(`SELECT deleted_at FROM loans WHERE id = ?`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return ErrLoanDoesNotExists
+	}
+	if err != nil {
+		return err
+	}
+	if !deletedAt.Valid {
+		return ErrLoanNotDeleted
+	}
+
+	return nil
+}
+
+// queryRows runs a query and calls fn for every row returned
+func queryRows(tx *sql.Tx, fn func(rows *sql.Rows) error, query string, args ...any) error {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// requireAffected maps a statement that affected no rows to ErrLoanDoesNotExists
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLoanDoesNotExists
+	}
+
+	return nil
+}
+
+// nullableDecimal returns a decimal as a nullable string column value
+func nullableDecimal(decimal *Decimal) sql.NullString {
+	if decimal == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: decimal.String(), Valid: true}
+}
+
+// formatSQLDate formats a date as a YYYY-MM-DD column value
+func formatSQLDate(date Date) string {
+	return date.Format("2006-01-02")
+}
+
+// sqlValueParser parses text column values, keeping the first error so rows can be scanned without checking every value
+type sqlValueParser struct {
+	currency Currency
+	err      error
+}
+
+// date parses a YYYY-MM-DD column value
+func (p *sqlValueParser) date(value string) Date {
+	date, err := ParseDate(value)
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	return date
+}
+
+// decimal parses a decimal column value
+func (p *sqlValueParser) decimal(value string) Decimal {
+	decimal, err := ParseDecimal(value)
+	if err != nil && p.err == nil {
+		p.err = errors.Wrapf(err, "%q", value)
+	}
+	return decimal
+}
+
+// money parses a decimal column value as money in the loan's currency
+func (p *sqlValueParser) money(value string) Money {
+	return NewMoney(p.decimal(value), p.currency)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSQLLoanRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "loans.db")
+
+	repo, err := openSQLiteLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer repo.Close()
+
+	loan1 := Loan{LoanDetails: LoanDetails{ID: "1", PrincipalAmount: NewMoney(NewDecimal(1000, 0), CurrencyEUR)}}
+	loan2 := Loan{LoanDetails: LoanDetails{ID: "2"}}
+
+	if err := repo.Create(ctx, loan1, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Create: %v", err)
+	}
+	if err := repo.Create(ctx, loan1, LoanChange{}); !errors.Is(err, ErrLoanAlreadyExists) {
+		t.Errorf("Expected ErrLoanAlreadyExists in Create when creating duplicate entry, got %v", err)
+	}
+	if err := repo.Create(ctx, loan2, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Create: %v", err)
+	}
+
+	loan1.LoanDetails.PrincipalAmount.Currency = CurrencyUSD
+	if err := repo.Update(ctx, loan1, LoanChange{ChangedBy: "tester", Reason: "redenominated"}); err != nil {
+		t.Errorf("Unexpected error in Update: %v", err)
+	}
+	if err := repo.Update(ctx, Loan{LoanDetails: LoanDetails{ID: "3"}}, LoanChange{}); !errors.Is(err, ErrLoanDoesNotExists) {
+		t.Errorf("Expected ErrLoanDoesNotExists when updating a non-existing loan, got %v", err)
+	}
+	if err := repo.Delete(ctx, loan2.LoanDetails.ID, 0, LoanChange{ChangedBy: "tester", Reason: "duplicate"}); err != nil {
+		t.Errorf("Unexpected error in Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, loan2.LoanDetails.ID, 0, LoanChange{}); !errors.Is(err, ErrLoanDoesNotExists) {
+		t.Errorf("Expected ErrLoanDoesNotExists when deleting a non-existing loan, got %v", err)
+	}
+
+	// reopening the database runs no further migrations and sees everything written
+	reopened, err := openSQLiteLoanRepository(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening database: %v", err)
+	}
+	defer reopened.Close()
+
+	readLoan, err := reopened.Read(ctx, loan1.LoanDetails.ID)
+	if err != nil {
+		t.Errorf("Unexpected error in Read: %v", err)
+	}
+	if readLoan.LoanDetails.Currency() != CurrencyUSD || readLoan.LoanDetails.PrincipalAmount.Amount.Cmp(loan1.LoanDetails.PrincipalAmount.Amount) != 0 {
+		t.Errorf("Updated loan was not persisted. got %v, want %v", readLoan.LoanDetails.PrincipalAmount, loan1.LoanDetails.PrincipalAmount)
+	}
+	if _, err := reopened.Read(ctx, loan2.LoanDetails.ID); !errors.Is(err, ErrLoanDoesNotExists) {
+		t.Errorf("Expected ErrLoanDoesNotExists when reading a deleted loan, got %v", err)
+	}
+	if loans := listLoans(t, reopened); len(loans) != 1 {
+		t.Errorf("List got wrong number of loans. Got %v, want %v", len(loans), 1)
+	}
+
+	versions, err := reopened.Versions(ctx, loan1.LoanDetails.ID)
+	if err != nil {
+		t.Errorf("Unexpected error in Versions: %v", err)
+	}
+	if len(versions) != 2 || versions[1].ChangedBy != "tester" || versions[1].Reason != "redenominated" || len(versions[1].Changes) != 1 {
+		t.Errorf("Versions were not persisted. got %+v", versions)
+	}
+	if _, err := reopened.Versions(ctx, loan2.LoanDetails.ID); !errors.Is(err, ErrLoanDoesNotExists) {
+		t.Errorf("Expected ErrLoanDoesNotExists when listing the versions of a deleted loan, got %v", err)
+	}
+
+	if deleted, err := reopened.Deleted(ctx); err != nil || len(deleted) != 1 || deleted[0].Loan.LoanDetails.ID != loan2.LoanDetails.ID || deleted[0].DeletedBy != "tester" || deleted[0].Reason != "duplicate" {
+		t.Errorf("Deleted loan was not kept. got %+v", deleted)
+	}
+	if err := reopened.Create(ctx, loan2, LoanChange{}); !errors.Is(err, ErrLoanAlreadyExists) {
+		t.Errorf("Expected ErrLoanAlreadyExists when creating a loan with the ID of a deleted loan, got %v", err)
+	}
+	if err := reopened.Purge(ctx, loan1.LoanDetails.ID); !errors.Is(err, ErrLoanNotDeleted) {
+		t.Errorf("Expected ErrLoanNotDeleted when purging a loan that is not deleted, got %v", err)
+	}
+	if err := reopened.Undelete(ctx, loan2.LoanDetails.ID); err != nil {
+		t.Errorf("Unexpected error in Undelete: %v", err)
+	}
+	if loans := listLoans(t, reopened); len(loans) != 2 {
+		t.Errorf("Expected the undeleted loan to be listed. got %v loans", len(loans))
+	}
+	if page, err := reopened.List(ctx, LoanQuery{Currency: CurrencyUSD}); err != nil || page.Total != 1 || page.Loans[0].LoanDetails.ID != loan1.LoanDetails.ID {
+		t.Errorf("Expected only the loan in the currency to be listed. got %+v, %v", page, err)
+	}
+
+	if err := reopened.Delete(ctx, loan2.LoanDetails.ID, 0, LoanChange{}); err != nil {
+		t.Errorf("Unexpected error in Delete: %v", err)
+	}
+	if err := reopened.Purge(ctx, loan2.LoanDetails.ID); err != nil {
+		t.Errorf("Unexpected error in Purge: %v", err)
+	}
+	if err := reopened.Undelete(ctx, loan2.LoanDetails.ID); !errors.Is(err, ErrLoanDoesNotExists) {
+		t.Errorf("Expected ErrLoanDoesNotExists when undeleting a purged loan, got %v", err)
+	}
+}
+
+func TestSQLLoanRepositoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo, err := openSQLiteLoanRepository(filepath.Join(t.TempDir(), "loans.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer repo.Close()
+
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-07-01")
+	floor := NewDecimal(0, 0)
+
+	loan, err := NewLoan(LoanDetails{
+		ID:                 "1",
+		StartDate:          startDate,
+		EndDate:            endDate,
+		PrincipalAmount:    NewMoney(NewDecimal(100000, 2), CurrencyGBP),
+		BaseInterestRate:   NewDecimal(500, 2),
+		Margin:             NewDecimal(150, 2),
+		CalculationMethod:  CalculationDailyCompound,
+		DayCountConvention: DayCountACT365F,
+		RoundingMode:       RoundHalfUp,
+		RoundingPoint:      RoundOnTotal,
+		FloatingRate: &FloatingRate{
+			Index:        "SONIA",
+			Fixings:      []RateFixing{{EffectiveDate: startDate.AddDate(0, 1, 0), Rate: NewDecimal(525, 2)}},
+			LookbackDays: 5,
+			Floor:        &floor,
+		},
+		RepaymentProfile:      RepaymentProfile{Type: RepaymentEqualPrincipal, Frequency: FrequencyQuarterly},
+		BusinessDayConvention: BusinessDayModifiedFollowing,
+	}, []Transaction{
+		{ID: "t1", Type: TransactionRepayment, EffectiveDate: startDate.AddDate(0, 2, 0), Amount: NewMoney(NewDecimal(10000, 2), CurrencyGBP)},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	if err := repo.Create(ctx, loan, LoanChange{}); err != nil {
+		t.Fatalf("Unexpected error in Create: %v", err)
+	}
+
+	readLoan, err := repo.Read(ctx, loan.LoanDetails.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error in Read: %v", err)
+	}
+
+	// compare the JSON representations, as decimals with equal values may hold different big.Int internals
+	loan.Revision = 1
+	got, _ := json.Marshal(readLoan)
+	want, _ := json.Marshal(loan)
+	if string(got) != string(want) {
+		t.Errorf("Loan did not round trip through the database.\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestSQLLoanRepositoryList(t *testing.T) {
+	ctx := context.Background()
+	repo, err := openSQLiteLoanRepository(filepath.Join(t.TempDir(), "loans.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer repo.Close()
+
+	var loans []Loan
+	for _, loan := range []struct {
+		id        string
+		currency  Currency
+		principal int64
+		margin    int64
+		start     string
+		end       string
+	}{
+		{id: "a", currency: CurrencyGBP, principal: 1000, margin: 1, start: "2024-01-01", end: "2024-06-30"},
+		{id: "b", currency: CurrencyEUR, principal: 5000, margin: 3, start: "2024-03-01", end: "2025-03-01"},
+		{id: "c", currency: CurrencyGBP, principal: 2500, margin: 2, start: "2024-07-01", end: "2024-12-31"},
+		{id: "d", currency: CurrencyGBP, principal: 900, margin: 0, start: "2023-01-01", end: "2024-01-01"},
+		{id: "e", currency: CurrencyUSD, principal: 5000, margin: 1, start: "2024-03-01", end: "2024-09-01"},
+	} {
+		details := testLoanDetails(t)
+		details.ID = loan.id
+		details.PrincipalAmount = NewMoney(NewDecimal(loan.principal, 0), loan.currency)
+		details.Margin = NewDecimal(loan.margin, 0)
+		details.StartDate, _ = ParseDate(loan.start)
+		details.EndDate, _ = ParseDate(loan.end)
+
+		created, err := NewLoan(details, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating loan: %v", err)
+		}
+		if err := repo.Create(ctx, created, LoanChange{}); err != nil {
+			t.Fatalf("Unexpected error in Create: %v", err)
+		}
+		loans = append(loans, created)
+	}
+
+	minPrincipal, maxRate := NewDecimal(1000, 0), NewDecimal(7, 0)
+	activeOn, _ := ParseDate("2024-04-01")
+
+	// the database lists the same page of loans as the query applied to every loan
+	for _, query := range []LoanQuery{
+		{},
+		{Page: 2, PageSize: 2},
+		{Page: 4, PageSize: 2},
+		{Currency: CurrencyGBP, Sort: LoanSortStart, Descending: true},
+		{Sort: LoanSortCurrency, Page: 2, PageSize: 3},
+		{Sort: LoanSortPrincipal, Descending: true, PageSize: 2},
+		{MinPrincipal: &minPrincipal, MaxRate: &maxRate, Sort: LoanSortRate},
+		{ActiveOn: activeOn, Sort: LoanSortMaturity},
+	} {
+		page, err := repo.List(ctx, query)
+		if err != nil {
+			t.Fatalf("Unexpected error in List: %v", err)
+		}
+		want := query.Apply(loans)
+
+		var got, wantIDs []string
+		for _, loan := range page.Loans {
+			got = append(got, loan.LoanDetails.ID)
+			if len(loan.DailyInterest) == 0 {
+				t.Errorf("Expected the loans on the page to be read in full. got %+v", loan)
+			}
+		}
+		for _, loan := range want.Loans {
+			wantIDs = append(wantIDs, loan.LoanDetails.ID)
+		}
+		if !slices.Equal(got, wantIDs) || page.Total != want.Total || page.Page != want.Page || page.Pages != want.Pages {
+			t.Errorf("Unexpected page for %+v. got %v (total %d, page %d of %d), want %v (total %d, page %d of %d)",
+				query, got, page.Total, page.Page, page.Pages, wantIDs, want.Total, want.Page, want.Pages)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TransactionRepayment = "repayment"
+	TransactionDrawdown  = "drawdown"
+	TransactionFee       = "fee"
+)
+
+var (
+	AllowedTransactionTypes = []TransactionType{
+		TransactionRepayment,
+		TransactionDrawdown,
+		TransactionFee,
+	}
+)
+
+// TransactionType holds the kind of movement a transaction makes on the outstanding balance
+type TransactionType string
+
+// String stringifies the transaction type
+func (t TransactionType) String() string {
+	return string(t)
+}
+
+// Validate validates whether the transaction type is supported
+func (t TransactionType) Validate() error {
+	if ok := slices.Contains(AllowedTransactionTypes, t); !ok {
+		return ErrInvalidTransactionType
+	}
+
+	return nil
+}
+
+// Transaction represents a repayment, additional drawdown or capitalised fee on a loan
+type Transaction struct {
+	ID            string          `json:"id"`             // ID is the unique identifier for the transaction
+	Type          TransactionType `json:"type"`           // Type is the kind of transaction
+	EffectiveDate Date            `json:"effective_date"` // EffectiveDate is the first day the new balance accrues interest
+	Amount        Money           `json:"amount"`         // Amount is the positive amount of the transaction
+}
+
+// BalanceChange returns the signed change the transaction makes to the outstanding balance
+func (t Transaction) BalanceChange() Decimal {
+	if t.Type == TransactionRepayment {
+		return t.Amount.Amount.Neg()
+	}
+	return t.Amount.Amount
+}
+
+// sortTransactions sorts transactions by effective date, keeping the order of transactions on the same day
+func sortTransactions(transactions []Transaction) {
+	slices.SortStableFunc(transactions, func(a, b Transaction) int {
+		return a.EffectiveDate.Compare(b.EffectiveDate)
+	})
+}
+
+// validateTransactions validates transactions against the loan they belong to, ensuring the balance never goes negative
+func validateTransactions(loan LoanDetails, transactions []Transaction) error {
+	sorted := slices.Clone(transactions)
+	sortTransactions(sorted)
+
+	balance := loan.PrincipalAmount.Amount
+	for _, transaction := range sorted {
+		if err := transaction.Type.Validate(); err != nil {
+			return err
+		}
+		if transaction.Amount.Currency != loan.Currency() {
+			return errors.Wrapf(ErrInvalidCurrency, "transaction %s is not in %s", transaction.ID, loan.Currency())
+		}
+		if transaction.Amount.Amount.Sign() <= 0 {
+			return errors.Wrapf(ErrInvalidInput, "transaction %s amount must be greater than 0", transaction.ID)
+		}
+		if transaction.EffectiveDate.Before(loan.StartDate) || !transaction.EffectiveDate.Before(loan.MaturityDate()) {
+			return errors.Wrapf(ErrInvalidInput, "transaction %s must be effective within the loan period", transaction.ID)
+		}
+
+		balance = balance.Add(transaction.BalanceChange())
+		if balance.Sign() < 0 {
+			return errors.Wrapf(ErrRepaymentExceedsBalance, "transaction %s", transaction.ID)
+		}
+	}
+
+	return nil
+}
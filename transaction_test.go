@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCalculateDailySimpleInterestTransactions(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-01-06")
+
+	details := LoanDetails{
+		StartDate:        startDate,
+		EndDate:          endDate,
+		PrincipalAmount:  NewMoney(NewDecimal(36500, 0), CurrencyEUR),
+		BaseInterestRate: NewDecimal(9, 0),
+		Margin:           NewDecimal(1, 0),
+	}
+
+	transactions := []Transaction{
+		{ID: "fee", Type: TransactionFee, EffectiveDate: startDate.AddDate(0, 0, 4), Amount: NewMoney(NewDecimal(3650, 0), CurrencyEUR)},
+		{ID: "repay", Type: TransactionRepayment, EffectiveDate: startDate.AddDate(0, 0, 2), Amount: NewMoney(NewDecimal(18250, 0), CurrencyEUR)},
+		{ID: "draw", Type: TransactionDrawdown, EffectiveDate: startDate.AddDate(0, 0, 3), Amount: NewMoney(NewDecimal(7300, 0), CurrencyEUR)},
+	}
+
+	loan, err := NewLoan(details, transactions)
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	if loan.Transactions[0].ID != "repay" || loan.Transactions[2].ID != "fee" {
+		t.Errorf("Expected transactions to be sorted by effective date. got %v", loan.Transactions)
+	}
+
+	expected := []struct {
+		balance       string
+		dailyInterest string
+		totalInterest string
+	}{
+		{"36500", "10.00", "10.00"},
+		{"36500", "10.00", "20.00"},
+		{"18250", "5.00", "25.00"},
+		{"25550", "7.00", "32.00"},
+		{"29200", "8.00", "40.00"},
+	}
+
+	if len(loan.DailyInterest) != len(expected) {
+		t.Fatalf("Unexpected number of daily interest entries. got %d, want %d", len(loan.DailyInterest), len(expected))
+	}
+
+	for i, interest := range loan.DailyInterest {
+		if got := interest.Balance.Amount.String(); got != expected[i].balance {
+			t.Errorf("Unexpected balance on day %d. got %s, want %s", i+1, got, expected[i].balance)
+		}
+		if got := interest.DailyInterestAccrued.Amount.String(); got != expected[i].dailyInterest {
+			t.Errorf("Unexpected daily interest on day %d. got %s, want %s", i+1, got, expected[i].dailyInterest)
+		}
+		if got := interest.TotalInterest.Amount.String(); got != expected[i].totalInterest {
+			t.Errorf("Unexpected total interest on day %d. got %s, want %s", i+1, got, expected[i].totalInterest)
+		}
+	}
+}
+
+func TestValidateTransactions(t *testing.T) {
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-02-01")
+
+	details := LoanDetails{
+		StartDate:       startDate,
+		EndDate:         endDate,
+		PrincipalAmount: NewMoney(NewDecimal(1000, 0), CurrencyEUR),
+	}
+
+	tests := map[string]struct {
+		transaction Transaction
+		err         error
+	}{
+		"overpayment": {
+			Transaction{Type: TransactionRepayment, EffectiveDate: startDate, Amount: NewMoney(NewDecimal(1001, 0), CurrencyEUR)},
+			ErrRepaymentExceedsBalance,
+		},
+		"wrong currency": {
+			Transaction{Type: TransactionDrawdown, EffectiveDate: startDate, Amount: NewMoney(NewDecimal(1, 0), CurrencyUSD)},
+			ErrInvalidCurrency,
+		},
+		"after end date": {
+			Transaction{Type: TransactionDrawdown, EffectiveDate: endDate, Amount: NewMoney(NewDecimal(1, 0), CurrencyEUR)},
+			ErrInvalidInput,
+		},
+		"zero amount": {
+			Transaction{Type: TransactionFee, EffectiveDate: startDate, Amount: NewMoney(NewDecimal(0, 0), CurrencyEUR)},
+			ErrInvalidInput,
+		},
+		"unknown type": {
+			Transaction{Type: "refund", EffectiveDate: startDate, Amount: NewMoney(NewDecimal(1, 0), CurrencyEUR)},
+			ErrInvalidTransactionType,
+		},
+	}
+
+	for name, test := range tests {
+		if _, err := NewLoan(details, []Transaction{test.transaction}); !errors.Is(err, test.err) {
+			t.Errorf("Unexpected error for %s transaction. got %v, want %v", name, err, test.err)
+		}
+	}
+
+	repaid := Transaction{Type: TransactionRepayment, EffectiveDate: startDate, Amount: NewMoney(NewDecimal(1000, 0), CurrencyEUR)}
+	if _, err := NewLoan(details, []Transaction{repaid}); err != nil {
+		t.Errorf("Unexpected error repaying the full balance: %v", err)
+	}
+}
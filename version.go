@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LoanChange holds who made a change to a loan and why, recorded against the version it creates
+type LoanChange struct {
+	ChangedBy string `json:"changed_by"`       // ChangedBy is the user who made the change
+	Reason    string `json:"reason,omitempty"` // Reason is why the change was made
+}
+
+// LoanVersion is an immutable record of a loan's details after a change, numbered from 1 when the loan was created
+type LoanVersion struct {
+	LoanID    string        `json:"loan_id"`           // LoanID is the ID of the loan
+	Version   int           `json:"version"`           // Version is the 1-based number of the version
+	ChangedAt time.Time     `json:"changed_at"`        // ChangedAt is when the change was made
+	ChangedBy string        `json:"changed_by"`        // ChangedBy is the user who made the change
+	Reason    string        `json:"reason,omitempty"`  // Reason is why the change was made
+	Changes   []FieldChange `json:"changes,omitempty"` // Changes are the old and new values of each field changed from the previous version
+	Details   LoanDetails   `json:"loan_details"`      // Details are the loan details as of the version
+}
+
+// FieldChange holds the old and new value of a single loan detail
+type FieldChange struct {
+	Field string `json:"field"` // Field is the name of the create flag setting the detail, e.g. margin
+	Old   string `json:"old"`   // Old is the value before the change, blank when unset
+	New   string `json:"new"`   // New is the value after the change, blank when unset
+}
+
+// String stringifies the change as field: old -> new
+func (f FieldChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", f.Field, valueOrNone(f.Old), valueOrNone(f.New))
+}
+
+// loanDetailFields are the loan details compared between versions, named after their create flags
+var loanDetailFields = []struct {
+	name  string
+	value func(details LoanDetails) string
+}{
+	{"start", func(d LoanDetails) string { return d.StartDate.String() }},
+	{"end", func(d LoanDetails) string { return d.EndDate.String() }},
+	{"currency", func(d LoanDetails) string { return d.Currency().String() }},
+	{"principal", func(d LoanDetails) string { return d.PrincipalAmount.Amount.String() }},
+	{"base", func(d LoanDetails) string { return d.BaseInterestRate.String() }},
+	{"margin", func(d LoanDetails) string { return d.Margin.String() }},
+	{"index", func(d LoanDetails) string {
+		return floatingRateValue(d, func(f FloatingRate) string { return f.Index })
+	}},
+	{"fixings", func(d LoanDetails) string {
+		return floatingRateValue(d, func(f FloatingRate) string {
+			fixings := make([]string, len(f.Fixings))
+			for i, fixing := range f.Fixings {
+				fixings[i] = fixing.EffectiveDate.String() + "=" + fixing.Rate.String()
+			}
+			return strings.Join(fixings, " ")
+		})
+	}},
+	{"lookback", func(d LoanDetails) string {
+		return floatingRateValue(d, func(f FloatingRate) string { return fmt.Sprint(f.LookbackDays) })
+	}},
+	{"observation-shift", func(d LoanDetails) string {
+		return floatingRateValue(d, func(f FloatingRate) string { return fmt.Sprint(f.ObservationShift) })
+	}},
+	{"floor", func(d LoanDetails) string {
+		return floatingRateValue(d, func(f FloatingRate) string { return optionalDecimal(f.Floor) })
+	}},
+	{"cap", func(d LoanDetails) string {
+		return floatingRateValue(d, func(f FloatingRate) string { return optionalDecimal(f.Cap) })
+	}},
+	{"method", func(d LoanDetails) string { return d.CalculationMethod.String() }},
+	{"day-count", func(d LoanDetails) string { return d.DayCountConvention.String() }},
+	{"rounding", func(d LoanDetails) string { return d.RoundingMode.String() }},
+	{"rounding-point", func(d LoanDetails) string { return d.RoundingPoint.String() }},
+	{"repayment", func(d LoanDetails) string { return d.RepaymentProfile.repaymentType().String() }},
+	{"frequency", func(d LoanDetails) string { return d.RepaymentProfile.Frequency.String() }},
+	{"instalments", func(d LoanDetails) string {
+		instalments := make([]string, len(d.RepaymentProfile.CustomInstalments))
+		for i, instalment := range d.RepaymentProfile.CustomInstalments {
+			instalments[i] = instalment.DueDate.String() + "=" + instalment.Principal.Amount.String()
+		}
+		return strings.Join(instalments, " ")
+	}},
+	{"calendar", func(d LoanDetails) string { return d.Calendar.String() }},
+	{"business-day", func(d LoanDetails) string { return d.BusinessDayConvention.String() }},
+	{"accrual", func(d LoanDetails) string { return d.AccrualBoundaries.String() }},
+}
+
+// DiffLoanDetails returns the old and new value of every loan detail that differs between two sets of details
+func DiffLoanDetails(old, new LoanDetails) []FieldChange {
+	var changes []FieldChange
+	for _, field := range loanDetailFields {
+		if oldValue, newValue := field.value(old), field.value(new); oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field.name, Old: oldValue, New: newValue})
+		}
+	}
+
+	return changes
+}
+
+// legacyVersionReason is the reason recorded against the details of a loan written before version history was kept
+const legacyVersionReason = "recorded before version history was kept"
+
+// nextVersions returns the versions to record when a loan is written with a change, which is none when its details are unchanged.
+// A loan written before version history was kept first has its previous details recorded as version 1.
+func nextVersions(versions []LoanVersion, previous *LoanDetails, details LoanDetails, change LoanChange, changedAt time.Time) []LoanVersion {
+	var next []LoanVersion
+	if len(versions) == 0 && previous != nil {
+		next = append(next, LoanVersion{
+			LoanID:    details.ID,
+			Version:   1,
+			ChangedAt: changedAt,
+			Reason:    legacyVersionReason,
+			Details:   *previous,
+		})
+	}
+
+	version := LoanVersion{
+		LoanID:    details.ID,
+		Version:   len(versions) + len(next) + 1,
+		ChangedAt: changedAt,
+		ChangedBy: change.ChangedBy,
+		Reason:    change.Reason,
+		Details:   details,
+	}
+	if previous != nil {
+		if version.Changes = DiffLoanDetails(*previous, details); len(version.Changes) == 0 {
+			return nil
+		}
+	}
+
+	return append(next, version)
+}
+
+// versionsOf returns the versions recorded for a loan, or its current details as version 1 when it was written before version history was kept
+func versionsOf(loan Loan, versions []LoanVersion) []LoanVersion {
+	if len(versions) == 0 {
+		return []LoanVersion{{LoanID: loan.LoanDetails.ID, Version: 1, Reason: legacyVersionReason, Details: loan.LoanDetails}}
+	}
+
+	return slices.Clone(versions)
+}
+
+// findVersion returns the numbered version of a loan
+func findVersion(versions []LoanVersion, number int) (LoanVersion, error) {
+	index := slices.IndexFunc(versions, func(v LoanVersion) bool { return v.Version == number })
+	if index == -1 {
+		return LoanVersion{}, errors.Wrapf(ErrVersionDoesNotExist, "version %d of %d", number, len(versions))
+	}
+
+	return versions[index], nil
+}
+
+// LoanAsOf returns the loan as of a previous version, with its interest recalculated from the version's details and the loan's current transactions
+func LoanAsOf(loan Loan, versions []LoanVersion, number int) (Loan, error) {
+	version, err := findVersion(versions, number)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	return NewLoan(version.Details, loan.Transactions)
+}
+
+// DiffVersions returns the old and new value of every loan detail that differs between two versions
+func DiffVersions(versions []LoanVersion, from, to int) ([]FieldChange, error) {
+	fromVersion, err := findVersion(versions, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := findVersion(versions, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffLoanDetails(fromVersion.Details, toVersion.Details), nil
+}
+
+// floatingRateValue returns a value of the loan's floating rate, or blank for a fixed rate loan
+func floatingRateValue(details LoanDetails, value func(f FloatingRate) string) string {
+	if details.FloatingRate == nil {
+		return ""
+	}
+	return value(*details.FloatingRate)
+}
+
+// optionalDecimal stringifies an optional decimal, returning blank when nil
+func optionalDecimal(decimal *Decimal) string {
+	if decimal == nil {
+		return ""
+	}
+	return decimal.String()
+}
+
+// valueOrNone returns the value, or (none) when blank
+func valueOrNone(value string) string {
+	if len(value) == 0 {
+		return "(none)"
+	}
+	return value
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDiffLoanDetails(t *testing.T) {
+	old := testLoanDetails(t)
+	new := old
+	new.Margin = NewDecimal(25, 1)
+	new.AccrualBoundaries = AccrualBothInclusive
+
+	changes := DiffLoanDetails(old, new)
+	want := []string{"margin: 1 -> 2.5", "accrual: start-inclusive -> both-inclusive"}
+	if len(changes) != len(want) {
+		t.Fatalf("DiffLoanDetails got wrong number of changes. got %v, want %v", changes, want)
+	}
+	for i, change := range changes {
+		if change.String() != want[i] {
+			t.Errorf("DiffLoanDetails got wrong change %d. got %q, want %q", i, change, want[i])
+		}
+	}
+
+	if changes := DiffLoanDetails(old, old); len(changes) != 0 {
+		t.Errorf("Expected no changes between identical details. got %v", changes)
+	}
+}
+
+func TestNextVersions(t *testing.T) {
+	changedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	details := testLoanDetails(t)
+
+	created := nextVersions(nil, nil, details, LoanChange{ChangedBy: "alice"}, changedAt)
+	if len(created) != 1 || created[0].Version != 1 || created[0].ChangedBy != "alice" || len(created[0].Changes) != 0 {
+		t.Errorf("Expected creating a loan to record version 1. got %+v", created)
+	}
+
+	if unchanged := nextVersions(created, &details, details, LoanChange{}, changedAt); unchanged != nil {
+		t.Errorf("Expected an unchanged update to record no version. got %+v", unchanged)
+	}
+
+	updated := details
+	updated.Margin = NewDecimal(2, 0)
+	next := nextVersions(created, &details, updated, LoanChange{ChangedBy: "bob", Reason: "repriced"}, changedAt)
+	if len(next) != 1 || next[0].Version != 2 || next[0].Reason != "repriced" || len(next[0].Changes) != 1 {
+		t.Errorf("Expected an update to record version 2. got %+v", next)
+	}
+
+	// a loan written before version history was kept has its previous details recorded first
+	legacy := nextVersions(nil, &details, updated, LoanChange{ChangedBy: "bob"}, changedAt)
+	if len(legacy) != 2 || legacy[0].Reason != legacyVersionReason || legacy[0].Details.Margin.Cmp(details.Margin) != 0 || legacy[1].Version != 2 {
+		t.Errorf("Expected the previous details of a legacy loan to be recorded as version 1. got %+v", legacy)
+	}
+}
+
+func TestLoanAsOf(t *testing.T) {
+	details := testLoanDetails(t)
+	loan, err := NewLoan(details, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating loan: %v", err)
+	}
+
+	updated := details
+	updated.Margin = NewDecimal(3, 0)
+	versions := append(nextVersions(nil, nil, details, LoanChange{}, time.Time{}), nextVersions(nil, &details, updated, LoanChange{}, time.Time{})[1:]...)
+
+	asOf, err := LoanAsOf(loan, versions, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error in LoanAsOf: %v", err)
+	}
+	if asOf.LoanDetails.Margin.Cmp(details.Margin) != 0 || len(asOf.DailyInterest) != len(loan.DailyInterest) {
+		t.Errorf("LoanAsOf got wrong loan. got margin %s with %d days", asOf.LoanDetails.Margin, len(asOf.DailyInterest))
+	}
+
+	changes, err := DiffVersions(versions, 1, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error in DiffVersions: %v", err)
+	}
+	if len(changes) != 1 || changes[0].String() != "margin: 1 -> 3" {
+		t.Errorf("DiffVersions got wrong changes. got %v", changes)
+	}
+
+	if _, err := LoanAsOf(loan, versions, 3); !errors.Is(err, ErrVersionDoesNotExist) {
+		t.Errorf("Expected ErrVersionDoesNotExist for a missing version, got %v", err)
+	}
+}
+
+// testLoanDetails returns the details of a valid fixed rate loan
+func testLoanDetails(t *testing.T) LoanDetails {
+	t.Helper()
+
+	startDate, _ := ParseDate("2024-01-01")
+	endDate, _ := ParseDate("2024-01-31")
+	details := LoanDetails{
+		ID:                 "1",
+		StartDate:          startDate,
+		EndDate:            endDate,
+		PrincipalAmount:    NewMoney(NewDecimal(1000, 0), CurrencyGBP),
+		BaseInterestRate:   NewDecimal(5, 0),
+		Margin:             NewDecimal(1, 0),
+		CalculationMethod:  CalculationDailySimple,
+		DayCountConvention: DayCountACT365F,
+		RoundingMode:       RoundHalfEven,
+		RoundingPoint:      RoundPerDay,
+		AccrualBoundaries:  AccrualStartInclusive,
+	}
+	if err := details.Validate(); err != nil {
+		t.Fatalf("Unexpected error validating test loan details: %v", err)
+	}
+
+	return details
+}